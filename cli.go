@@ -0,0 +1,397 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	tui "github.com/bingfengfeifei/switcher/tui"
+)
+
+// runCommand dispatches non-interactive subcommands and returns the process exit code.
+func runCommand(config *tui.Config, args []string) int {
+	switch args[0] {
+	case "switch":
+		return runSwitchCommand(config, args[1:])
+	case "codex":
+		return runCodexCommand(config, args[1:])
+	case "import":
+		return runImportCommand(config, args[1:])
+	case "status":
+		return runStatusCommand(config)
+	case "doctor":
+		return runDoctorCommand(config)
+	case "shell":
+		return runShellCommand(config, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", args[0])
+		printUsage()
+		return 2
+	}
+}
+
+// parseInterspersed parses flags that may appear before or after positional
+// arguments (e.g. `switch claude NAME --scope project`) and returns the positionals.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func runSwitchCommand(config *tui.Config, args []string) int {
+	fs := flag.NewFlagSet("switch", flag.ContinueOnError)
+	scope := fs.String("scope", tui.ClaudeScopeUser, "Claude Code settings scope: user, project or project-local")
+	force := fs.Bool("force", false, "Write credentials into the project-scoped Claude Code settings.json")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 2 {
+		printUsage()
+		return 2
+	}
+
+	tool, name := positional[0], positional[1]
+	adapter := findTool(tool)
+	if adapter == nil {
+		return 2
+	}
+	if tool != "claude" && (*scope != tui.ClaudeScopeUser || *force) {
+		fmt.Fprintf(os.Stderr, "--scope and --force are only supported for claude\n")
+		return 2
+	}
+	if tool == "claude" {
+		return switchClaude(config, name, *scope, *force)
+	}
+	return switchTool(config, adapter, name)
+}
+
+// findTool looks up a registered tool and reports unknown names on stderr.
+func findTool(id string) tui.ToolAdapter {
+	adapter := tui.FindToolAdapter(id)
+	if adapter == nil {
+		fmt.Fprintf(os.Stderr, "Unknown tool: %s (available: %s)\n", id, strings.Join(tui.ToolIDs(), ", "))
+	}
+	return adapter
+}
+
+func switchTool(config *tui.Config, adapter tui.ToolAdapter, name string) int {
+	idx := slices.Index(adapter.Names(config), name)
+	if idx == -1 {
+		fmt.Printf("%s config not found: %s\n", adapter.DisplayName(), name)
+		return 2
+	}
+	if err := adapter.Apply(config, idx); err != nil {
+		var activateErr *tui.ActivateError
+		if errors.As(err, &activateErr) {
+			fmt.Printf("Set active %s failed: %v\n", adapter.DisplayName(), activateErr.Err)
+			return 4
+		}
+		fmt.Printf("Switch %s failed: %v\n", adapter.DisplayName(), err)
+		return 3
+	}
+	fmt.Printf("Switched %s to '%s'\n", adapter.DisplayName(), name)
+	if n, ok := adapter.(tui.ToolNotifier); ok {
+		if notice := n.Notice(config, idx); notice != "" {
+			fmt.Println(notice)
+		}
+	}
+	return 0
+}
+
+func switchClaude(config *tui.Config, name, scope string, force bool) int {
+	idx := -1
+	for i, sc := range config.ClaudeCode {
+		if sc.Name == name {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		fmt.Printf("Claude Code config not found: %s\n", name)
+		return 2
+	}
+	sc := config.ClaudeCode[idx]
+	if tui.ClaudeScopeWritesSecrets(&sc, scope) && !force {
+		fmt.Fprintf(os.Stderr, "Refusing to write the API key of '%s' into ./.claude/settings.json, which is usually committed.\n", sc.Name)
+		fmt.Fprintf(os.Stderr, "Use --scope project-local for credentials, or pass --force to write it anyway.\n")
+		return 2
+	}
+	if err := config.SwitchClaudeCodeInScope(&sc, scope); err != nil {
+		fmt.Printf("Switch Claude Code failed: %v\n", err)
+		return 3
+	}
+	if err := config.SetActiveClaudeCodeInScope(idx, scope); err != nil {
+		fmt.Printf("Set active Claude Code failed: %v\n", err)
+		return 4
+	}
+	path, _ := tui.ClaudeSettingsPathFor(&sc, scope)
+	if scope == tui.ClaudeScopeUser && sc.TargetDir == "" {
+		fmt.Printf("Switched Claude Code to '%s'\n", sc.Name)
+	} else {
+		fmt.Printf("Switched Claude Code to '%s' (%s: %s)\n", sc.Name, scope, path)
+	}
+	return 0
+}
+
+func runCodexCommand(config *tui.Config, args []string) int {
+	if len(args) == 0 {
+		printUsage()
+		return 2
+	}
+
+	switch args[0] {
+	case "prune":
+		fs := flag.NewFlagSet("codex prune", flag.ContinueOnError)
+		apply := fs.Bool("apply", false, "Remove the stale sections instead of only listing them")
+		comment := fs.Bool("comment", config.CodexPruneComment, "Comment the stale sections out instead of removing them")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+
+		stale := config.StaleCodexProviderSections()
+		if len(stale) == 0 {
+			fmt.Println("No stale provider sections in config.toml")
+			return 0
+		}
+		if !*apply {
+			action := "removed from"
+			if *comment {
+				action = "commented out in"
+			}
+			fmt.Printf("Stale provider sections that would be %s config.toml:\n", action)
+			for _, provider := range stale {
+				fmt.Printf("  [model_providers.%s] (created by '%s')\n", provider, config.Managed.CodexProviders[provider])
+			}
+			if *comment {
+				fmt.Println("Run `switcher codex prune --apply --comment` to comment them out.")
+			} else {
+				fmt.Println("Run `switcher codex prune --apply` to remove them.")
+			}
+			return 0
+		}
+
+		removed, err := config.PruneCodexProviderSections(*comment)
+		if err != nil {
+			fmt.Printf("Prune failed: %v\n", err)
+			return 3
+		}
+		for _, provider := range removed {
+			if *comment {
+				fmt.Printf("Commented out [model_providers.%s]\n", provider)
+			} else {
+				fmt.Printf("Removed [model_providers.%s]\n", provider)
+			}
+		}
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown codex command: %s\n", args[0])
+		printUsage()
+		return 2
+	}
+}
+
+func runImportCommand(config *tui.Config, args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	scope := fs.String("scope", "all", "Claude Code settings to import: user, project, project-local or all")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 1 {
+		printUsage()
+		return 2
+	}
+	adapter := findTool(positional[0])
+	if adapter == nil {
+		return 2
+	}
+	if positional[0] != "claude" {
+		if *scope != "all" {
+			fmt.Fprintf(os.Stderr, "--scope is only supported for claude\n")
+			return 2
+		}
+		return importTool(config, adapter)
+	}
+
+	scopes := tui.ClaudeScopes
+	if *scope != "all" {
+		scopes = []string{*scope}
+	}
+	results, err := config.ImportClaudeCode(scopes)
+	if err != nil {
+		fmt.Printf("Import failed: %v\n", err)
+		return 3
+	}
+	if len(results) == 0 {
+		fmt.Println("No Claude Code settings to import")
+		return 0
+	}
+	for _, r := range results {
+		if r.Existing {
+			fmt.Printf("%s: already imported as '%s'\n", r.Path, r.Name)
+		} else {
+			fmt.Printf("%s: imported as '%s'\n", r.Path, r.Name)
+		}
+	}
+	return 0
+}
+
+func importTool(config *tui.Config, adapter tui.ToolAdapter) int {
+	results, err := adapter.Import(config)
+	if errors.Is(err, tui.ErrImportUnsupported) {
+		fmt.Printf("Importing existing settings is not supported for %s\n", adapter.DisplayName())
+		return 2
+	}
+	if err != nil {
+		fmt.Printf("Import failed: %v\n", err)
+		return 3
+	}
+	if len(results) == 0 {
+		fmt.Printf("No %s settings to import\n", adapter.DisplayName())
+		return 0
+	}
+	for _, r := range results {
+		active := ""
+		if r.Active {
+			active = " (active)"
+		}
+		if r.Existing {
+			fmt.Printf("'%s': already imported%s\n", r.Name, active)
+		} else {
+			fmt.Printf("'%s': imported%s\n", r.Name, active)
+		}
+	}
+	return 0
+}
+
+// statusLine describes one tool's status; ok is false when the active config is not applied.
+func statusLine(st tui.ToolStatus) (string, bool) {
+	switch {
+	case st.Active == "":
+		return "no active config", true
+	case st.Err != nil:
+		return fmt.Sprintf("'%s': check failed: %v", st.Active, st.Err), false
+	case st.Applied:
+		return fmt.Sprintf("'%s': applied", st.Active), true
+	case st.Actual == "":
+		return fmt.Sprintf("'%s': NOT applied", st.Active), false
+	default:
+		return fmt.Sprintf("'%s': NOT applied, files on disk point to %s", st.Active, st.Actual), false
+	}
+}
+
+// toolColumnWidth returns the width of the tool name column in status output.
+func toolColumnWidth() int {
+	width := 0
+	for _, id := range tui.ToolIDs() {
+		width = max(width, len(id))
+	}
+	return width
+}
+
+// runStatusCommand prints whether each tool's active config is what is actually on disk.
+// It exits with 1 when any tool has drifted so scripts can check it.
+func runStatusCommand(config *tui.Config) int {
+	code := 0
+	width := toolColumnWidth()
+	for _, st := range config.Status() {
+		line, ok := statusLine(st)
+		if !ok {
+			code = 1
+		}
+		fmt.Printf("%-*s %s\n", width, st.Tool, line)
+	}
+	return code
+}
+
+// runDoctorCommand checks every registered tool: whether its config files exist and
+// are readable, and whether the active config is applied. It exits with 1 on any problem.
+func runDoctorCommand(config *tui.Config) int {
+	code := 0
+	for _, st := range config.Status() {
+		adapter := tui.FindToolAdapter(st.Tool)
+		fmt.Printf("%s (%s)\n", adapter.DisplayName(), adapter.ID())
+		for _, check := range tui.CheckToolPaths(adapter) {
+			switch {
+			case check.Err != nil:
+				fmt.Printf("  ✗ %s: %v\n", check.Path, check.Err)
+				code = 1
+			case check.Exists:
+				fmt.Printf("  ✓ %s\n", check.Path)
+			default:
+				fmt.Printf("  - %s (not found)\n", check.Path)
+			}
+		}
+		line, ok := statusLine(st)
+		mark := "✓"
+		if !ok {
+			mark = "✗"
+			code = 1
+		}
+		fmt.Printf("  %s %s\n", mark, line)
+	}
+	return code
+}
+
+func runShellCommand(config *tui.Config, args []string) int {
+	if len(args) == 0 {
+		printUsage()
+		return 2
+	}
+
+	switch args[0] {
+	case "uninstall":
+		if err := config.UninstallShellIntegration(); err != nil {
+			fmt.Printf("Uninstall failed: %v\n", err)
+			return 3
+		}
+		fmt.Println("Removed switcher shell integration and environment variables")
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown shell command: %s\n", args[0])
+		printUsage()
+		return 2
+	}
+}
+
+func printUsage() {
+	tools := strings.Join(tui.ToolIDs(), "|")
+	fmt.Fprintf(os.Stderr, `Usage:
+  switcher                         Launch the interactive TUI
+  switcher -switch-claude NAME     Switch Claude Code to config by name
+  switcher -switch-codex NAME      Switch Codex to config by name
+  switcher -switch-droid NAME      Switch Droid to config by name
+  switcher switch %s NAME [--scope user|project|project-local] [--force]
+                                   Switch a tool to config by name; --scope picks
+                                   the Claude Code settings file (default user);
+                                   --force allows writing the API key into the
+                                   project scope, which is usually committed
+  switcher codex prune [--apply] [--comment]
+                                   List (or remove / comment out) stale provider
+                                   sections in config.toml
+  switcher import claude [--scope user|project|project-local|all]
+                                   Import the current Claude Code settings as configs
+                                   (default all: user settings and this project's files)
+  switcher import droid            Import every custom model in Factory config.json;
+                                   the one selected in settings.json becomes active
+  switcher import TOOL             Import the current settings of another tool
+  switcher status                  Show whether each tool's active config is applied on disk
+                                   (exit code 1 if any has drifted)
+  switcher doctor                  Check each tool's config files and active config
+                                   (exit code 1 on any problem)
+  switcher shell uninstall         Remove switcher's shell rc blocks and env files
+`, tools)
+}
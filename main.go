@@ -76,79 +76,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Subcommands, e.g. `switcher codex prune`
+	if args := flag.Args(); len(args) > 0 {
+		os.Exit(runCommand(config, args))
+	}
+
 	if switchCodexName != "" {
-		idx := -1
-		for i, sc := range config.Codex {
-			if sc.Name == switchCodexName {
-				idx = i
-				break
-			}
-		}
-		if idx == -1 {
-			fmt.Printf("Codex config not found: %s\n", switchCodexName)
-			os.Exit(2)
-		}
-		sc := config.Codex[idx]
-		if err := config.SwitchCodex(&sc); err != nil {
-			fmt.Printf("Switch Codex failed: %v\n", err)
-			os.Exit(3)
-		}
-		if err := config.SetActiveCodex(idx); err != nil {
-			fmt.Printf("Set active Codex failed: %v\n", err)
-			os.Exit(4)
-		}
-		fmt.Printf("Switched Codex to '%s'\n", sc.Name)
-		return
+		os.Exit(switchTool(config, tui.FindToolAdapter("codex"), switchCodexName))
 	}
 
 	if switchClaudeName != "" {
-		idx := -1
-		for i, sc := range config.ClaudeCode {
-			if sc.Name == switchClaudeName {
-				idx = i
-				break
-			}
-		}
-		if idx == -1 {
-			fmt.Printf("Claude Code config not found: %s\n", switchClaudeName)
-			os.Exit(2)
-		}
-		sc := config.ClaudeCode[idx]
-		if err := config.SwitchClaudeCode(&sc); err != nil {
-			fmt.Printf("Switch Claude Code failed: %v\n", err)
-			os.Exit(3)
-		}
-		if err := config.SetActiveClaudeCode(idx); err != nil {
-			fmt.Printf("Set active Claude Code failed: %v\n", err)
-			os.Exit(4)
-		}
-		fmt.Printf("Switched Claude Code to '%s'\n", sc.Name)
-		return
+		os.Exit(switchClaude(config, switchClaudeName, tui.ClaudeScopeUser, false))
 	}
 
 	if switchDroidName != "" {
-		idx := -1
-		for i, dc := range config.Droid {
-			if dc.ModelDisplayName == switchDroidName {
-				idx = i
-				break
-			}
-		}
-		if idx == -1 {
-			fmt.Printf("Droid config not found: %s\n", switchDroidName)
-			os.Exit(2)
-		}
-		dc := config.Droid[idx]
-		if err := config.SwitchDroid(&dc); err != nil {
-			fmt.Printf("Switch Droid failed: %v\n", err)
-			os.Exit(3)
-		}
-		if err := config.SetActiveDroid(idx); err != nil {
-			fmt.Printf("Set active Droid failed: %v\n", err)
-			os.Exit(4)
-		}
-		fmt.Printf("Switched Droid to '%s'\n", dc.ModelDisplayName)
-		return
+		os.Exit(switchTool(config, tui.FindToolAdapter("droid"), switchDroidName))
 	}
 
 	// Default to TUI
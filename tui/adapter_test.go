@@ -0,0 +1,178 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fakeTool writes the "url" value of the applied config to a single file.
+type fakeTool struct {
+	storedTool
+	path string
+}
+
+func (fakeTool) DisplayName() string { return "Fake" }
+
+func (fakeTool) Fields() []ToolField {
+	return []ToolField{
+		{Key: "url", Label: "field_base_url", Required: true},
+		{Key: "key", Label: "field_api_key", Secret: true},
+		{Key: "mode", Label: "field_wire_api", Options: []string{"a", "b"}},
+	}
+}
+
+func (f fakeTool) Paths() []string { return []string{f.path} }
+
+func (f fakeTool) Apply(c *Config, index int) error {
+	return c.applyStoredTool(f, index, func(tc ToolConfig) error {
+		return os.WriteFile(f.path, []byte(tc.Values["url"]), 0644)
+	})
+}
+
+func (f fakeTool) Verify(c *Config) (bool, string, error) {
+	active := c.GetActiveTool(f.id)
+	data, err := os.ReadFile(f.path)
+	if active == nil || err != nil {
+		return true, "", nil
+	}
+	return string(data) == active.Values["url"], string(data), nil
+}
+
+func (f fakeTool) Import(c *Config) ([]ToolImportResult, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, nil
+	}
+	r := c.importToolConfig(f, ToolConfig{Name: "imported", Values: map[string]string{"url": string(data)}}, true)
+	return []ToolImportResult{r}, c.Save()
+}
+
+// useFakeTool registers a fakeTool after the built-in tools for the duration of the test.
+func useFakeTool(t *testing.T) fakeTool {
+	t.Helper()
+	dir := useTempPlatformPaths(t)
+	fake := fakeTool{storedTool: storedTool{id: "fake"}, path: filepath.Join(dir, "fake.conf")}
+	old := toolAdapters
+	toolAdapters = append(slices.Clone(old), fake)
+	t.Cleanup(func() { toolAdapters = old })
+	return fake
+}
+
+func TestStoredToolLifecycle(t *testing.T) {
+	fake := useFakeTool(t)
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+
+	if err := c.AddToolConfig(fake, ToolConfig{Name: "a"}); err == nil {
+		t.Fatalf("missing required field should be rejected")
+	}
+	for _, name := range []string{"a", "b"} {
+		if err := c.AddToolConfig(fake, ToolConfig{Name: name, Values: map[string]string{"url": "https://" + name}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.AddToolConfig(fake, ToolConfig{Name: "a", Values: map[string]string{"url": "x"}}); err == nil {
+		t.Fatalf("duplicate name should be rejected")
+	}
+	if FindToolAdapter("fake") == nil || !slices.Contains(ToolIDs(), "fake") {
+		t.Fatalf("fake tool is not registered: %v", ToolIDs())
+	}
+
+	if err := fake.Apply(c, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := ActiveToolName(c, fake); got != "b" {
+		t.Fatalf("active = %q, want b", got)
+	}
+	status := c.Status()
+	if last := status[len(status)-1]; last.Tool != "fake" || last.Active != "b" || !last.Applied {
+		t.Fatalf("unexpected status %+v", last)
+	}
+
+	// 手动修改文件后导入：新增一个配置并设为活动配置；再次导入不会重复添加
+	if err := os.WriteFile(fake.path, []byte("https://hand"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ok, actual, _ := fake.Verify(c); ok || actual != "https://hand" {
+		t.Fatalf("Verify = %v, %q; want drift to https://hand", ok, actual)
+	}
+	for i := 0; i < 2; i++ {
+		results, err := fake.Import(c)
+		if err != nil || len(results) != 1 || results[0].Existing != (i == 1) || results[0].Name != "imported" {
+			t.Fatalf("import #%d = %+v (err=%v)", i, results, err)
+		}
+	}
+	if names := fake.Names(c); !slices.Equal(names, []string{"a", "b", "imported"}) {
+		t.Fatalf("names = %v", names)
+	}
+
+	if err := c.DeleteToolConfig(fake, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := ActiveToolName(c, fake); got != "imported" {
+		t.Fatalf("active after delete = %q, want imported", got)
+	}
+}
+
+func TestGenericToolScreens(t *testing.T) {
+	fake := useFakeTool(t)
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	var tm tea.Model = model{config: c, state: mainMenu, cursor: len(toolAdapters) - 1}
+	press := func(msgs ...tea.KeyMsg) {
+		for _, msg := range msgs {
+			tm, _ = tm.Update(msg)
+		}
+	}
+	typeText := func(s string) { press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}) }
+
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if m := tm.(model); m.state != toolList || m.tool.ID() != "fake" {
+		t.Fatalf("main menu did not open the generic list: state=%v", m.state)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	typeText("dev")
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	typeText("https://dev")
+	press(tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyRight})
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if m := tm.(model); m.state != toolList {
+		t.Fatalf("saving the form failed: %s", m.error)
+	}
+	want := ToolConfig{Name: "dev", Values: map[string]string{"url": "https://dev", "mode": "b"}}
+	if got := c.Tools["fake"].Configs; len(got) != 1 || got[0].Name != want.Name || !sameToolValues(got[0].Values, want.Values) {
+		t.Fatalf("saved configs = %+v", got)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if data, _ := os.ReadFile(fake.path); string(data) != "https://dev" {
+		t.Fatalf("Enter did not apply the config, file = %q", data)
+	}
+	if !strings.Contains(tm.(model).mainMenuView(), "Fake") || !strings.Contains(tm.(model).mainMenuView(), "dev") {
+		t.Fatalf("main menu does not show the active fake config")
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyDelete}, tea.KeyMsg{Type: tea.KeyUp}, tea.KeyMsg{Type: tea.KeyEnter})
+	if len(c.Tools["fake"].Configs) != 0 {
+		t.Fatalf("delete did not remove the config")
+	}
+}
+
+func TestActiveFirstOrder(t *testing.T) {
+	if got := activeFirstOrder([]string{"c", "a", "b"}, 2); !slices.Equal(got, []int{2, 1, 0}) {
+		t.Fatalf("activeFirstOrder = %v, want [2 1 0]", got)
+	}
+	droids := []DroidConfig{{ModelDisplayName: "z"}, {ModelDisplayName: "x"}, {ModelDisplayName: "y"}}
+	m := model{config: &Config{Droid: droids, Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: 0}}}
+	var names []string
+	for _, dc := range m.getSortedDroidConfigs() {
+		names = append(names, dc.ModelDisplayName)
+	}
+	if !slices.Equal(names, []string{"z", "x", "y"}) {
+		t.Fatalf("sorted Droid configs = %v, want [z x y]", names)
+	}
+}
@@ -0,0 +1,248 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Aider 的 API 类型：OpenAI 兼容端点或 Anthropic
+const (
+	AiderAPIOpenAI    = "openai"
+	AiderAPIAnthropic = "anthropic"
+)
+
+// aiderKeys API 类型 -> .aider.conf.yml 中的 API Key 和 Base URL 选项（空字符串表示不支持）
+var aiderKeys = map[string][2]string{
+	AiderAPIOpenAI:    {"openai-api-key", "openai-api-base"},
+	AiderAPIAnthropic: {"anthropic-api-key", ""},
+}
+
+// aiderEnvVars 选项 -> 对应的环境变量，环境变量优先于配置文件
+var aiderEnvVars = map[string]string{
+	"model":             "AIDER_MODEL",
+	"openai-api-key":    "OPENAI_API_KEY",
+	"openai-api-base":   "OPENAI_API_BASE",
+	"anthropic-api-key": "ANTHROPIC_API_KEY",
+}
+
+// aiderAdapter 管理 ~/.aider.conf.yml 末尾的 switcher 标记块（与 rc 文件使用相同的标记）。
+// Aider 读取 YAML 时重复的键以最后一个为准，标记块中的设置会覆盖文件前面的同名设置。
+type aiderAdapter struct {
+	storedTool
+}
+
+func (aiderAdapter) DisplayName() string { return "Aider" }
+
+func (aiderAdapter) Fields() []ToolField {
+	return []ToolField{
+		{Key: toolKeyEndpoint, Label: "field_aider_api", Options: []string{AiderAPIOpenAI, AiderAPIAnthropic}},
+		{Key: toolKeyBaseURL, Label: "field_base_url"},
+		{Key: toolKeyAPIKey, Label: "field_api_key", Secret: true, Required: true},
+		{Key: toolKeyModel, Label: "field_model", Required: true},
+	}
+}
+
+func aiderConfigPath() string {
+	return filepath.Join(platformPaths.GetHomeDir(), ".aider.conf.yml")
+}
+
+func (aiderAdapter) Paths() []string {
+	return []string{aiderConfigPath()}
+}
+
+// aiderAPI 返回配置的 API 类型，未设置或无法识别时为 OpenAI
+func aiderAPI(cfg ToolConfig) string {
+	if _, ok := aiderKeys[cfg.Values[toolKeyEndpoint]]; ok {
+		return cfg.Values[toolKeyEndpoint]
+	}
+	return AiderAPIOpenAI
+}
+
+// aiderOptions 返回配置要写入标记块的选项，以及按写入顺序排列的选项名。
+// 未设置 base URL 时不写入也不检查 base URL 选项，文件前面用户自己的设置保持生效
+func aiderOptions(cfg ToolConfig) (map[string]string, []string) {
+	keys := aiderKeys[aiderAPI(cfg)]
+	set := map[string]string{"model": cfg.Values[toolKeyModel], keys[0]: cfg.Values[toolKeyAPIKey]}
+	owned := []string{"model", keys[0]}
+	if v := cfg.Values[toolKeyBaseURL]; keys[1] != "" && v != "" {
+		set[keys[1]] = v
+		owned = append(owned, keys[1])
+	}
+	return set, owned
+}
+
+// yamlPlain 匹配可以不加引号写入的 YAML 标量
+var yamlPlain = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_./:@+-]*$`)
+
+// yamlScalar 在值可能被 YAML 解析为其他类型或包含特殊字符时加上双引号
+func yamlScalar(v string) string {
+	switch strings.ToLower(v) {
+	case "true", "false", "yes", "no", "on", "off", "null", "y", "n":
+		return strconv.Quote(v)
+	}
+	if yamlPlain.MatchString(v) {
+		return v
+	}
+	return strconv.Quote(v)
+}
+
+// yamlValue 解析单行 YAML 标量：双引号、单引号或去掉行内注释的普通值
+func yamlValue(raw string) string {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		if end := closingQuote(raw); end > 0 {
+			if v, err := strconv.Unquote(raw[:end+1]); err == nil {
+				return v
+			}
+			return raw[1:end]
+		}
+	case strings.HasPrefix(raw, "'"):
+		var b strings.Builder
+		for i := 1; i < len(raw); i++ {
+			if raw[i] == '\'' {
+				if i+1 < len(raw) && raw[i+1] == '\'' {
+					b.WriteByte('\'')
+					i++
+					continue
+				}
+				return b.String()
+			}
+			b.WriteByte(raw[i])
+		}
+	}
+	if i := strings.Index(raw, " #"); i >= 0 {
+		raw = strings.TrimSpace(raw[:i])
+	}
+	return raw
+}
+
+// parseAiderConf 读取 YAML 中顶层的 `key: value` 标量，重复的键以最后一个为准（与 Aider 一致）；
+// 列表、嵌套对象和多行值会被跳过
+func parseAiderConf(data []byte) map[string]string {
+	values := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' || line[0] == '-' {
+			continue
+		}
+		key, rest, found := strings.Cut(line, ":")
+		key = strings.TrimSpace(key)
+		if !found || key == "" || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+			continue
+		}
+		if v := yamlValue(rest); v != "" && v != "|" && v != ">" {
+			values[key] = v
+		}
+	}
+	return values
+}
+
+// readAiderConf 返回 Aider 实际使用的选项：配置文件中的值被对应的环境变量覆盖
+func readAiderConf() (map[string]string, error) {
+	values := map[string]string{}
+	data, err := os.ReadFile(aiderConfigPath())
+	if err == nil {
+		values = parseAiderConf(data)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	for key, env := range aiderEnvVars {
+		if v := os.Getenv(env); v != "" {
+			values[key] = v
+		}
+	}
+	return values, nil
+}
+
+func (a aiderAdapter) Apply(c *Config, index int) error {
+	return c.applyStoredTool(a, index, func(cfg ToolConfig) error {
+		path := aiderConfigPath()
+		old, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		set, owned := aiderOptions(cfg)
+		var body []string
+		for _, key := range owned {
+			body = append(body, key+": "+yamlScalar(set[key]))
+		}
+		return writeFileWithPerms(path, []byte(upsertShellBlock(string(old), strings.Join(body, "\n"))), 0600)
+	})
+}
+
+// Verify 检查 Aider 实际使用的选项（包括环境变量）是否与活动配置一致；
+// 不一致时返回实际生效的 base URL（未设置时为模型）
+func (a aiderAdapter) Verify(c *Config) (bool, string, error) {
+	active := c.GetActiveTool(a.id)
+	if active == nil {
+		return true, "", nil
+	}
+	values, err := readAiderConf()
+	if err != nil {
+		return true, "", err
+	}
+	set, owned := aiderOptions(*active)
+	ok := true
+	for _, key := range owned {
+		if values[key] != set[key] {
+			ok = false
+		}
+	}
+	shown := values[aiderKeys[aiderAPI(*active)][1]]
+	if shown == "" {
+		shown = values["model"]
+	}
+	if shown == "" {
+		shown = t("value_unset")
+	}
+	return ok, shown, nil
+}
+
+// aiderModelAPI 根据模型名推断 Aider 使用的 API 类型
+func aiderModelAPI(model string) string {
+	m := strings.ToLower(model)
+	for _, s := range []string{"anthropic/", "claude", "sonnet", "opus", "haiku"} {
+		if strings.Contains(m, s) {
+			return AiderAPIAnthropic
+		}
+	}
+	return AiderAPIOpenAI
+}
+
+// Import 把 Aider 当前使用的模型及其 API 类型的 Key 和 base URL 导入为配置并设为活动配置；
+// 模型对应的 API Key 未设置时使用另一种已设置 Key 的 API 类型
+func (a aiderAdapter) Import(c *Config) ([]ToolImportResult, error) {
+	values, err := readAiderConf()
+	if err != nil {
+		return nil, err
+	}
+	model := values["model"]
+	if model == "" {
+		return nil, nil
+	}
+	api := aiderModelAPI(model)
+	if values[aiderKeys[api][0]] == "" {
+		api = AiderAPIOpenAI
+		if values[aiderKeys[api][0]] == "" {
+			api = AiderAPIAnthropic
+		}
+	}
+	keys := aiderKeys[api]
+	if values[keys[0]] == "" {
+		return nil, nil
+	}
+	cfg := ToolConfig{Values: map[string]string{toolKeyEndpoint: api, toolKeyAPIKey: values[keys[0]], toolKeyModel: model}}
+	if base := values[keys[1]]; keys[1] != "" && base != "" {
+		cfg.Values[toolKeyBaseURL] = base
+	}
+	cfg.Name = hostOf(cfg.Values[toolKeyBaseURL])
+	if cfg.Name == "" {
+		cfg.Name = "aider-" + api
+	}
+	result := c.importToolConfig(a, cfg, true)
+	return []ToolImportResult{result}, c.Save()
+}
@@ -0,0 +1,111 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseAiderConf(t *testing.T) {
+	in := "# 用户设置\nmodel: gpt-4 # 旧模型\ndark-mode: true\nread:\n  - CONVENTIONS.md\nopenai-api-key: 'it''s'\nmodel: \"o3 \\\"mini\\\"\"\n"
+	got := parseAiderConf([]byte(in))
+	if got["model"] != `o3 "mini"` || got["openai-api-key"] != "it's" || got["dark-mode"] != "true" || got["read"] != "" {
+		t.Fatalf("parsed %v", got)
+	}
+	for _, v := range []string{"gpt-4o", "https://relay.example.com/v1", "yes", "sk-a b#c", "3.5", ""} {
+		if back := yamlValue(yamlScalar(v)); back != v {
+			t.Fatalf("round trip of %q gave %q (%s)", v, back, yamlScalar(v))
+		}
+	}
+}
+
+func TestAiderApplyVerifyAndImport(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	for _, env := range aiderEnvVars {
+		t.Setenv(env, "")
+	}
+	aider := FindToolAdapter("aider")
+	path := filepath.Join(dir, ".aider.conf.yml")
+	os.WriteFile(path, []byte("# 我的 Aider 设置\nmodel: gpt-4\ndark-mode: true\nread:\n  - CONVENTIONS.md\n"), 0644)
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	configs := []ToolConfig{
+		{Name: "relay", Values: map[string]string{toolKeyEndpoint: AiderAPIOpenAI, toolKeyBaseURL: "https://relay.example.com/v1", toolKeyAPIKey: "r-key", toolKeyModel: "openai/gpt-4o"}},
+		{Name: "claude", Values: map[string]string{toolKeyEndpoint: AiderAPIAnthropic, toolKeyAPIKey: "a-key", toolKeyModel: "sonnet"}},
+	}
+	for _, cfg := range configs {
+		if err := c.AddToolConfig(aider, cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := aider.Apply(c, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := aider.Apply(c, 1); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	want := "# 我的 Aider 设置\nmodel: gpt-4\ndark-mode: true\nread:\n  - CONVENTIONS.md\n\n" +
+		shellBlockBegin + "\nmodel: sonnet\nanthropic-api-key: a-key\n" + shellBlockEnd + "\n"
+	if string(data) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", data, want)
+	}
+	if ok, _, err := aider.Verify(c); !ok || err != nil {
+		t.Fatalf("Verify after apply = %v, %v", ok, err)
+	}
+
+	// 环境变量覆盖配置文件：检测到差异，导入后一致
+	t.Setenv("AIDER_MODEL", "opus")
+	if ok, actual, _ := aider.Verify(c); ok || actual != "opus" {
+		t.Fatalf("Verify with AIDER_MODEL = %v, %q", ok, actual)
+	}
+	results, err := aider.Import(c)
+	if err != nil || len(results) != 1 || results[0].Existing || results[0].Name != "aider-anthropic" {
+		t.Fatalf("import = %+v (err=%v)", results, err)
+	}
+	if ok, _, _ := aider.Verify(c); !ok {
+		t.Fatalf("imported config should match the effective settings")
+	}
+
+	t.Setenv("AIDER_MODEL", "")
+	if err := aider.Apply(c, 0); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(path)
+	if strings.Count(string(data), shellBlockBegin) != 1 || strings.Contains(string(data), "anthropic-api-key") ||
+		!strings.Contains(string(data), "openai-api-base: https://relay.example.com/v1") {
+		t.Fatalf("switching back did not replace the block:\n%s", data)
+	}
+}
+
+func TestAiderVerifyIgnoresUserBaseURLWhenUnset(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	for _, env := range aiderEnvVars {
+		t.Setenv(env, "")
+	}
+	aider := FindToolAdapter("aider")
+	path := filepath.Join(dir, ".aider.conf.yml")
+	if err := os.WriteFile(path, []byte("openai-api-base: https://mine.example.com/v1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	if err := c.AddToolConfig(aider, ToolConfig{Name: "o", Values: map[string]string{toolKeyEndpoint: AiderAPIOpenAI, toolKeyAPIKey: "k", toolKeyModel: "gpt-4o"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := aider.Apply(c, 0); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(data), "openai-api-base") != 1 {
+		t.Fatalf("block should not write openai-api-base when the config has none:\n%s", data)
+	}
+	if ok, _, err := aider.Verify(c); !ok || err != nil {
+		t.Fatalf("user's own openai-api-base should not count as drift: %v, %v", ok, err)
+	}
+}
@@ -4,9 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -15,18 +15,26 @@ func (m model) claudeCodeListView() string {
 
 	var rows []string
 
-	// 检查是否有警告信息
+	// 检查是否有警告信息；项目作用域下改为显示写入的项目文件
 	hasWarning := false
-	if ok, actualBase, _ := checkAppliedClaudeLocal(m.config); !ok && actualBase != "" {
+	if m.claudeScope != "" && m.claudeScope != ClaudeScopeUser {
+		path, err := ClaudeSettingsPath(m.claudeScope)
+		if err != nil {
+			path = err.Error()
+		}
+		rows = append(rows, itemBoxStyle.Render(fmt.Sprintf(t("display_scope"), m.claudeScope, path)))
+		hasWarning = true
+	} else if ok, actualBase, _ := checkAppliedClaudeLocal(m.config); !ok && actualBase != "" {
 		warn := errorStyle.Render(fmt.Sprintf(t("warn_mismatch"), "Claude") + actualBase)
 		rows = append(rows, itemBoxStyle.Render(warn))
 		hasWarning = true
 	}
+	activeIndex := m.config.ActiveClaudeCodeIndexInScope(m.claudeScope)
 
 	// Calculate viewport size based on window height
 	configCount := len(m.sortedClaudeCode)
 	if configCount > 0 {
-		viewportSize := calculateListViewportHeight(m.windowHeight, hasWarning, m.compact)
+		viewportSize := calculateListViewportHeight(m.windowHeight, hasWarning, m.compact, 2)
 
 		// Calculate visible range based on cursor position
 		start, end := updateCursorViewport(m.cursor, configCount, viewportSize)
@@ -34,8 +42,13 @@ func (m model) claudeCodeListView() string {
 		// Render visible configurations
 		for i := start; i < end; i++ {
 			cfg := m.sortedClaudeCode[i]
-			active := m.config.Active.ClaudeCode == findConfigIndex(m.config.ClaudeCode, cfg)
-			r := listRowView(cfg, i == m.cursor, active, m.compact)
+			active := activeIndex == findConfigIndex(m.config.ClaudeCode, cfg)
+			target, err := ClaudeSettingsPathFor(&cfg, m.claudeScope)
+			if err != nil {
+				target = err.Error()
+			}
+			r := listRowView(cfg, i == m.cursor, active, m.compact,
+				m.config.claudePrivacySummary(&cfg), fmt.Sprintf(t("display_target_path"), target))
 			if i == m.cursor {
 				r = itemBoxSelStyle.Render(r)
 			} else {
@@ -77,39 +90,13 @@ func (m model) claudeCodeListView() string {
 	content.WriteString("\n\n")
 	content.WriteString(body)
 	content.WriteString("\n")
-	content.WriteString(statusBarView(t("nav_select"), t("nav_confirm"), t("nav_edit"), t("nav_add")+"  "+t("nav_view")+"  "+t("nav_back")+"  "+t("nav_switch_buttons")))
+	content.WriteString(statusBarView(t("nav_select"), t("nav_confirm"), t("nav_edit"), t("nav_add")+"  "+t("nav_scope")+"  "+t("nav_presets")+"  "+t("nav_drift")+"  "+t("nav_view")+"  "+t("nav_back")+"  "+t("nav_switch_buttons")))
 	return content.String()
 }
 
 // getSortedClaudeCodeConfigs 获取排序后的 Claude Code 配置列表
 func (m model) getSortedClaudeCodeConfigs() []ServiceConfig {
-	sortedConfigs := make([]ServiceConfig, 0, len(m.config.ClaudeCode))
-	var activeConfig ServiceConfig
-	var otherConfigs []ServiceConfig
-
-	for i, cfg := range m.config.ClaudeCode {
-		if m.config.Active.ClaudeCode == i {
-			activeConfig = cfg
-		} else {
-			otherConfigs = append(otherConfigs, cfg)
-		}
-	}
-
-	// 按名称对剩余配置进行排序
-	for i := 0; i < len(otherConfigs)-1; i++ {
-		for j := i + 1; j < len(otherConfigs); j++ {
-			if otherConfigs[i].Name > otherConfigs[j].Name {
-				otherConfigs[i], otherConfigs[j] = otherConfigs[j], otherConfigs[i]
-			}
-		}
-	}
-
-	// 构建排序后的列表：当前配置在前，其余在后
-	if activeConfig.Name != "" {
-		sortedConfigs = append(sortedConfigs, activeConfig)
-	}
-	sortedConfigs = append(sortedConfigs, otherConfigs...)
-	return sortedConfigs
+	return activeFirst(m.config.ClaudeCode, m.config.ActiveClaudeCodeIndexInScope(m.claudeScope), func(sc ServiceConfig) string { return sc.Name })
 }
 
 // getOriginalClaudeCodeIndex 根据排序列表中的位置获取原始索引
@@ -126,17 +113,78 @@ func (m *model) sortClaudeCodeConfigs() {
 	m.sortedClaudeCode = m.getSortedClaudeCodeConfigs()
 }
 
+// switchClaudeCode 把配置写入当前作用域并记录为该作用域的活动配置
+func (m *model) switchClaudeCode(index int) {
+	if err := m.config.SwitchClaudeCodeInScope(&m.config.ClaudeCode[index], m.claudeScope); err != nil {
+		m.error = fmt.Sprintf(t("error_switch_claude"), err)
+	} else if err := m.config.SetActiveClaudeCodeInScope(index, m.claudeScope); err != nil {
+		m.error = err.Error()
+	} else {
+		m.error = t("success_switch_claude")
+		m.cursor = 0
+	}
+}
+
+// handleProjectSecretsKey 处理把凭证写入项目作用域前的确认，默认选中取消
+func (m model) handleProjectSecretsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = claudeCodeList
+		m.cursor = 0
+	case tea.KeyUp:
+		m.cursor = 0
+	case tea.KeyDown:
+		m.cursor = 1
+	case tea.KeyEnter:
+		m.state = claudeCodeList
+		if m.cursor == 0 && m.deleteIndex >= 0 && m.deleteIndex < len(m.config.ClaudeCode) {
+			m.switchClaudeCode(m.deleteIndex)
+		} else {
+			m.cursor = 0
+		}
+	}
+	return m, nil
+}
+
+// confirmProjectSecretsView 提示凭证将写入通常随项目提交的 settings.json
+func (m model) confirmProjectSecretsView() string {
+	var content strings.Builder
+	content.WriteString(headerView(t("confirm_project_secrets_title")))
+	content.WriteString("\n\n")
+	name := ""
+	if m.deleteIndex >= 0 && m.deleteIndex < len(m.config.ClaudeCode) {
+		name = m.config.ClaudeCode[m.deleteIndex].Name
+	}
+	path, _ := ClaudeSettingsPath(ClaudeScopeProject)
+	content.WriteString(errorStyle.Render(fmt.Sprintf(t("confirm_project_secrets_warn"), name, path)))
+	content.WriteString("\n\n")
+	content.WriteString(t("confirm_project_secrets_msg"))
+	content.WriteString("\n\n")
+	for i, option := range []string{t("confirm_project_secrets_yes"), t("confirm_project_secrets_no")} {
+		prefix := "  "
+		if m.cursor == i {
+			prefix = cursorStyle.Render(">")
+		}
+		content.WriteString(fmt.Sprintf("%s %s\n", prefix, option))
+	}
+	content.WriteString("\n")
+	content.WriteString(statusBarView(t("confirm_nav"), t("nav_confirm"), t("confirm_nav_back"), ""))
+	return content.String()
+}
+
 // Local checks for applied vs selected configs
+// checkAppliedClaudeLocal 检查用户级 settings.json 是否与当前选中的配置一致，
+// 按配置的认证方式校验 ANTHROPIC_AUTH_TOKEN、ANTHROPIC_API_KEY 或 apiKeyHelper。
 func checkAppliedClaudeLocal(c *Config) (bool, string, error) {
 	active := c.GetActiveClaudeCode()
 	if active == nil {
 		return true, "", nil
 	}
-	home, err := os.UserHomeDir()
+	settingsPath, err := ClaudeSettingsPathFor(active, ClaudeScopeUser)
 	if err != nil {
 		return true, "", err
 	}
-	data, err := os.ReadFile(filepath.Join(home, ".claude", "settings.json"))
+	data, err := os.ReadFile(settingsPath)
 	if err != nil {
 		return true, "", nil
 	}
@@ -144,8 +192,18 @@ func checkAppliedClaudeLocal(c *Config) (bool, string, error) {
 	if err := json.Unmarshal(data, &se); err != nil {
 		return true, "", err
 	}
-	ab := strings.TrimSpace(se.Env["ANTHROPIC_BASE_URL"])
-	ak := strings.TrimSpace(se.Env["ANTHROPIC_AUTH_TOKEN"])
-	ok := ab == strings.TrimSpace(active.BaseURL) && ak == strings.TrimSpace(active.APIKey)
+	ab := claudeEndpointOf(se.Env)
+	ok := ab == claudeEndpointOf(claudeProviderEnv(active))
+	// 端点与凭据（Bedrock/Vertex 为区域、项目等）逐项对比
+	for key, value := range claudeProviderEnv(active) {
+		ok = ok && strings.TrimSpace(se.Env[key]) == strings.TrimSpace(value)
+	}
+	if !usesClaudeCloud(active) && claudeAuthMode(active) == ClaudeAuthHelper {
+		ok = ok && strings.TrimSpace(se.APIKeyHelper) == strings.TrimSpace(active.APIKeyHelper)
+	}
+	// 模型设置：配置中为空的项在 settings.json 中也应不存在
+	for key, value := range claudeModelEnv(active) {
+		ok = ok && strings.TrimSpace(se.Env[key]) == strings.TrimSpace(*value)
+	}
 	return ok, ab, nil
 }
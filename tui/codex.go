@@ -25,8 +25,10 @@ func (m model) codexListView() string {
 
 	// Calculate viewport size based on window height
 	configCount := len(m.sortedCodex)
+	// 所有 Codex 配置写入同一个 config.toml（CODEX_HOME 或 ~/.codex）
+	configPath := filepath.Join(platformPaths.GetCodexConfigDir(), "config.toml")
 	if configCount > 0 {
-		viewportSize := calculateListViewportHeight(m.windowHeight, hasWarning, m.compact)
+		viewportSize := calculateListViewportHeight(m.windowHeight, hasWarning, m.compact, 1)
 
 		// Calculate visible range based on cursor position
 		start, end := updateCursorViewport(m.cursor, configCount, viewportSize)
@@ -35,7 +37,7 @@ func (m model) codexListView() string {
 		for i := start; i < end; i++ {
 			cfg := m.sortedCodex[i]
 			active := m.config.Active.Codex == findConfigIndex(m.config.Codex, cfg)
-			r := listRowView(cfg, i == m.cursor, active, m.compact)
+			r := listRowView(cfg, i == m.cursor, active, m.compact, fmt.Sprintf(t("display_target_path"), configPath))
 			if i == m.cursor {
 				r = itemBoxSelStyle.Render(r)
 			} else {
@@ -77,39 +79,13 @@ func (m model) codexListView() string {
 	content.WriteString("\n\n")
 	content.WriteString(body)
 	content.WriteString("\n")
-	content.WriteString(statusBarView(t("nav_select"), t("nav_confirm"), t("nav_edit"), t("nav_add")+"  "+t("nav_view")+"  "+t("nav_back")+"  "+t("nav_switch_buttons")))
+	content.WriteString(statusBarView(t("nav_select"), t("nav_confirm"), t("nav_edit"), t("nav_add")+"  "+t("nav_drift")+"  "+t("nav_view")+"  "+t("nav_back")+"  "+t("nav_switch_buttons")))
 	return content.String()
 }
 
 // getSortedCodexConfigs 获取排序后的 Codex 配置列表
 func (m model) getSortedCodexConfigs() []ServiceConfig {
-	sortedConfigs := make([]ServiceConfig, 0, len(m.config.Codex))
-	var activeConfig ServiceConfig
-	var otherConfigs []ServiceConfig
-
-	for i, cfg := range m.config.Codex {
-		if m.config.Active.Codex == i {
-			activeConfig = cfg
-		} else {
-			otherConfigs = append(otherConfigs, cfg)
-		}
-	}
-
-	// 按名称对剩余配置进行排序
-	for i := 0; i < len(otherConfigs)-1; i++ {
-		for j := i + 1; j < len(otherConfigs); j++ {
-			if otherConfigs[i].Name > otherConfigs[j].Name {
-				otherConfigs[i], otherConfigs[j] = otherConfigs[j], otherConfigs[i]
-			}
-		}
-	}
-
-	// 构建排序后的列表：当前配置在前，其余在后
-	if activeConfig.Name != "" {
-		sortedConfigs = append(sortedConfigs, activeConfig)
-	}
-	sortedConfigs = append(sortedConfigs, otherConfigs...)
-	return sortedConfigs
+	return activeFirst(m.config.Codex, m.config.Active.Codex, func(sc ServiceConfig) string { return sc.Name })
 }
 
 // getOriginalCodexIndex 根据排序列表中的位置获取原始索引
@@ -126,38 +102,62 @@ func (m *model) sortCodexConfigs() {
 	m.sortedCodex = m.getSortedCodexConfigs()
 }
 
+// checkAppliedCodexLocal 检查 Codex 实际生效的设置是否与当前选中的配置一致。
+// 不一致时返回实际生效的 base URL（内置 openai provider 未覆盖地址时返回 provider 名），用于警告提示。
 func checkAppliedCodexLocal(c *Config) (bool, string, error) {
 	active := c.GetActiveCodex()
 	if active == nil {
 		return true, "", nil
 	}
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return true, "", err
-	}
-	a, err := os.ReadFile(filepath.Join(home, ".codex", "auth.json"))
+	codexDir := platformPaths.GetCodexConfigDir()
+	b, err := os.ReadFile(filepath.Join(codexDir, "config.toml"))
 	if err != nil {
 		return true, "", nil
 	}
-	var au CodexAuth
-	if err := json.Unmarshal(a, &au); err != nil {
-		return true, "", err
+	cfg := strings.ReplaceAll(string(b), "\r\n", "\n")
+
+	// 按 config.toml 中实际的 model_provider 解析生效的地址：
+	// 内置 openai provider 读取 OPENAI_BASE_URL，自定义 provider 读取对应段的 base_url
+	actualProvider, _ := readTomlKey(cfg, "model_provider")
+	var actualBase string
+	if actualProvider == CodexProviderModeOpenAI {
+		actualBase, _ = shellManager.GetEnvVar(codexOpenAIBaseURLEnv)
+	} else {
+		actualBase, _ = readTomlSectionKey(cfg, "model_providers."+actualProvider, "base_url")
 	}
-	if strings.TrimSpace(au.OPENAI_API_KEY) != strings.TrimSpace(active.APIKey) {
-		return false, "", nil
+	actualBase = strings.TrimSpace(actualBase)
+	shown := actualBase
+	if shown == "" {
+		shown = actualProvider
+	}
+
+	wantProvider := strings.TrimSpace(active.Provider)
+	if active.UsesBuiltinOpenAI() {
+		wantProvider = CodexProviderModeOpenAI
+	}
+	if actualProvider != wantProvider || actualBase != strings.TrimSpace(active.BaseURL) {
+		return false, shown, nil
+	}
+	// 内置 openai provider 的地址来自 shell 环境：当前 shell 没有加载 switcher 设置的值时 Codex 看不到它
+	if actualProvider == CodexProviderModeOpenAI && strings.TrimSpace(os.Getenv(codexOpenAIBaseURLEnv)) != actualBase {
+		if env := os.Getenv(codexOpenAIBaseURLEnv); env != "" {
+			shown = env
+		} else {
+			shown = actualProvider
+		}
+		return false, shown, nil
 	}
-	b, err := os.ReadFile(filepath.Join(home, ".codex", "config.toml"))
+
+	a, err := os.ReadFile(filepath.Join(codexDir, "auth.json"))
 	if err != nil {
-		return true, "", nil
+		return true, shown, nil
 	}
-	cfg := string(b)
-	wantProv := fmt.Sprintf("model_provider = \"%s\"", strings.TrimSpace(active.Provider))
-	wantBase := fmt.Sprintf("base_url = \"%s\"", strings.TrimSpace(active.BaseURL))
-	if !strings.Contains(cfg, wantProv) {
-		return false, "", nil
+	var au CodexAuth
+	if err := json.Unmarshal(a, &au); err != nil {
+		return true, shown, err
 	}
-	if !strings.Contains(cfg, wantBase) {
-		return false, "", nil
+	if strings.TrimSpace(au.OPENAI_API_KEY) != strings.TrimSpace(active.APIKey) {
+		return false, shown, nil
 	}
-	return true, strings.TrimSpace(active.BaseURL), nil
+	return true, shown, nil
 }
@@ -3,8 +3,10 @@ package tui
 import (
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -23,6 +25,20 @@ const (
 	DefaultEnvKey               = "CODEX_KEY"
 )
 
+// Codex provider modes
+const (
+	// CodexProviderModeCustom 写入 [model_providers.X] 段，使用自定义 provider
+	CodexProviderModeCustom = "custom"
+	// CodexProviderModeOpenAI 使用 Codex 内置的 openai provider，通过 OPENAI_BASE_URL 覆盖地址
+	CodexProviderModeOpenAI = "openai"
+)
+
+// Codex 内置 openai provider 读取的环境变量
+const (
+	codexOpenAIBaseURLEnv = "OPENAI_BASE_URL"
+	codexOpenAIAPIKeyEnv  = "OPENAI_API_KEY"
+)
+
 // Model reasoning effort levels
 const (
 	ModelReasoningEffortLow    = "low"
@@ -33,6 +49,13 @@ const (
 	ModelReasoningEffortAuto   = "auto"
 )
 
+// Claude Code auth modes
+const (
+	ClaudeAuthToken  = "auth_token" // ANTHROPIC_AUTH_TOKEN（Authorization: Bearer）
+	ClaudeAuthAPIKey = "api_key"    // ANTHROPIC_API_KEY（x-api-key）
+	ClaudeAuthHelper = "helper"     // apiKeyHelper：由脚本输出密钥，适合会轮换的 token
+)
+
 // Claude Code effort level constants
 const (
 	DefaultClaudeEffortLevel    = "auto"
@@ -61,24 +84,51 @@ func init() {
 }
 
 type ServiceConfig struct {
-	Name                     string `json:"name"`
-	Provider                 string `json:"provider"`
-	BaseURL                  string `json:"base_url"`
-	APIKey                   string `json:"api_key"`
-	Model                    string `json:"model,omitempty"`
-	WireAPI                  string `json:"wire_api,omitempty"`
-	AuthMethod               string `json:"auth_method,omitempty"`
-	EnvKey                   string `json:"env_key,omitempty"`
-	ModelReasoningEffort     string `json:"model_reasoning_effort,omitempty"`
-	ClaudeDefaultModel       string `json:"claude_default_model,omitempty"`
-	ClaudeDefaultHaikuModel  string `json:"claude_default_haiku_model,omitempty"`
-	ClaudeDefaultOpusModel   string `json:"claude_default_opus_model,omitempty"`
-	ClaudeDefaultSonnetModel string `json:"claude_default_sonnet_model,omitempty"`
-	EffortLevel              string `json:"effort_level,omitempty"`
-	AutocompactPctOverride   string `json:"autocompact_pct_override,omitempty"`
-	HTTPProxy                string `json:"http_proxy,omitempty"`
-	HTTPSProxy               string `json:"https_proxy,omitempty"`
-	NOProxy                  string `json:"no_proxy,omitempty"`
+	Name                     string            `json:"name"`
+	Provider                 string            `json:"provider"`
+	BaseURL                  string            `json:"base_url"`
+	APIKey                   string            `json:"api_key"`
+	Model                    string            `json:"model,omitempty"`
+	WireAPI                  string            `json:"wire_api,omitempty"`
+	AuthMethod               string            `json:"auth_method,omitempty"`
+	EnvKey                   string            `json:"env_key,omitempty"`
+	ModelReasoningEffort     string            `json:"model_reasoning_effort,omitempty"`
+	ProviderMode             string            `json:"provider_mode,omitempty"`
+	ClaudeDefaultModel       string            `json:"claude_default_model,omitempty"`
+	ClaudeDefaultHaikuModel  string            `json:"claude_default_haiku_model,omitempty"`
+	ClaudeDefaultOpusModel   string            `json:"claude_default_opus_model,omitempty"`
+	ClaudeDefaultSonnetModel string            `json:"claude_default_sonnet_model,omitempty"`
+	ClaudeMainModel          string            `json:"claude_main_model,omitempty"`
+	ClaudeSmallFastModel     string            `json:"claude_small_fast_model,omitempty"`
+	ClaudeSubagentModel      string            `json:"claude_subagent_model,omitempty"`
+	EffortLevel              string            `json:"effort_level,omitempty"`
+	AutocompactPctOverride   string            `json:"autocompact_pct_override,omitempty"`
+	HTTPProxy                string            `json:"http_proxy,omitempty"`
+	HTTPSProxy               string            `json:"https_proxy,omitempty"`
+	NOProxy                  string            `json:"no_proxy,omitempty"`
+	ApprovalPolicy           string            `json:"approval_policy,omitempty"`
+	SandboxMode              string            `json:"sandbox_mode,omitempty"`
+	ModelVerbosity           string            `json:"model_verbosity,omitempty"`
+	ModelReasoningSummary    string            `json:"model_reasoning_summary,omitempty"`
+	ModelContextWindow       string            `json:"model_context_window,omitempty"`
+	ModelMaxOutputTokens     string            `json:"model_max_output_tokens,omitempty"`
+	MCPServers               []MCPServer       `json:"mcp_servers,omitempty"`
+	ExtraEnv                 map[string]string `json:"extra_env,omitempty"`
+	ClaudeAuthMode           string            `json:"claude_auth_mode,omitempty"`
+	APIKeyHelper             string            `json:"api_key_helper,omitempty"`
+	PermissionPreset         string            `json:"permission_preset,omitempty"`
+	// Claude Code 隐私/遥测开关：on/off/unmanaged，空字符串表示继承全局默认值
+	DisableNonessentialTraffic string `json:"disable_nonessential_traffic,omitempty"`
+	DisableTelemetry           string `json:"disable_telemetry,omitempty"`
+	DoNotTrack                 string `json:"do_not_track,omitempty"`
+	MaintainProjectWorkingDir  string `json:"maintain_project_working_dir,omitempty"`
+	// Claude Code 提供商类型（anthropic/bedrock/vertex）及 Bedrock/Vertex 所需的区域、AWS profile 和 GCP 项目
+	ClaudeProviderType string `json:"claude_provider_type,omitempty"`
+	CloudRegion        string `json:"cloud_region,omitempty"`
+	AWSProfile         string `json:"aws_profile,omitempty"`
+	VertexProjectID    string `json:"vertex_project_id,omitempty"`
+	// TargetDir: Claude Code 用户作用域写入的配置目录，留空使用默认目录（~/.claude 或 CLAUDE_CONFIG_DIR）
+	TargetDir string `json:"target_dir,omitempty"`
 }
 
 type DroidConfig struct {
@@ -86,7 +136,12 @@ type DroidConfig struct {
 	Model            string `json:"model"`
 	BaseURL          string `json:"base_url"`
 	APIKey           string `json:"api_key"`
-	Provider         string `json:"provider"`
+	// Provider: Factory BYOK 的 provider 类型（anthropic / openai / generic-chat-completion-api）
+	Provider string `json:"provider"`
+	// MaxTokens: 单次请求的最大输出 token 数，0 表示使用 Factory 的默认值
+	MaxTokens int `json:"max_tokens,omitempty"`
+	// ExtraHeaders: 随每个请求发送的额外 HTTP 请求头
+	ExtraHeaders map[string]string `json:"extra_headers,omitempty"`
 }
 
 type FactoryConfig struct {
@@ -99,6 +154,19 @@ type Config struct {
 	Droid      []DroidConfig   `json:"droid"`
 	Active     ActiveConfig    `json:"active"`
 	Language   string          `json:"language,omitempty"`
+	// PermissionPresets: 可复用的 Claude Code 权限预设
+	PermissionPresets []PermissionPreset `json:"permission_presets"`
+	// ClaudeProjects: 项目作用域的 settings 文件路径 -> 该项目当前使用的 Claude Code 配置名
+	ClaudeProjects map[string]string `json:"claude_projects,omitempty"`
+	// ClaudePrivacyDefaults: 隐私/遥测开关的全局默认值（开关名 -> on/off/unmanaged），未设置时为 on
+	ClaudePrivacyDefaults map[string]string `json:"claude_privacy_defaults,omitempty"`
+	// DroidPublishAll: 切换 Droid 时把全部 Droid 配置都写入 custom_models，便于在 Droid 中用 /model 选择
+	DroidPublishAll bool `json:"droid_publish_all,omitempty"`
+	// CodexPruneComment: 删除或重命名 Codex 配置时把过期的 provider 段注释掉，而不是删除
+	CodexPruneComment bool         `json:"codex_prune_comment,omitempty"`
+	Managed           ManagedState `json:"managed"`
+	// Tools: 通过 ToolAdapter 接入的其他工具（工具名 -> 配置列表和活动配置）
+	Tools map[string]*ToolState `json:"tools,omitempty"`
 }
 
 type ActiveConfig struct {
@@ -107,13 +175,49 @@ type ActiveConfig struct {
 	Droid      int `json:"droid"`
 }
 
+// ManagedState 记录 switcher 写入到各工具配置文件中的内容，
+// 以便后续清理时只移除 switcher 自己创建的部分，不影响用户手动添加的内容。
+type ManagedState struct {
+	// CodexProviders: config.toml 中由 switcher 创建的 [model_providers.X] 段名 -> 创建它的配置名
+	CodexProviders map[string]string `json:"codex_providers,omitempty"`
+	// CodexMCPServers / ClaudeMCPServers: 上一次切换时由 switcher 写入的 MCP 服务器名称
+	CodexMCPServers  []string `json:"codex_mcp_servers,omitempty"`
+	ClaudeMCPServers []string `json:"claude_mcp_servers,omitempty"`
+	// ClaudeTargetMCPServers: 配置了目标目录时，该目录的 .claude.json 路径 -> 由 switcher 写入的 MCP 服务器名称
+	ClaudeTargetMCPServers map[string][]string `json:"claude_target_mcp_servers,omitempty"`
+	// CodexEnvVars: 上一次切换 Codex 时通过 shell 集成设置的环境变量名
+	CodexEnvVars []string `json:"codex_env_vars,omitempty"`
+	// ClaudeEnvKeys: Claude settings 文件路径 -> 上一次切换时由 switcher 写入的额外 env key
+	ClaudeEnvKeys map[string][]string `json:"claude_env_keys,omitempty"`
+	// ClaudeEnvOverrides: Claude settings 文件路径 -> 被额外 env 覆盖的用户 key 及其原值，切走时恢复
+	ClaudeEnvOverrides map[string]map[string]interface{} `json:"claude_env_overrides,omitempty"`
+	// ClaudeKeyHelpers: 由 switcher 写入了 apiKeyHelper 的 Claude settings 文件路径
+	ClaudeKeyHelpers map[string]bool `json:"claude_key_helpers,omitempty"`
+	// ClaudePermissions: Claude settings 文件路径 -> 由 switcher 根据权限预设写入的规则
+	ClaudePermissions map[string]ManagedRules `json:"claude_permissions,omitempty"`
+	// DroidModels: Factory config.json 的 custom_models 中由 switcher 写入的模型名称（model_display_name）
+	DroidModels []string `json:"droid_models,omitempty"`
+}
+
+// UsesBuiltinOpenAI 表示该 Codex 配置使用内置的 openai provider，而不是自定义 provider 段
+func (s ServiceConfig) UsesBuiltinOpenAI() bool {
+	return s.ProviderMode == CodexProviderModeOpenAI
+}
+
+// UsesShellEnv 表示切换到该 Codex 配置时会通过 shell 集成设置环境变量，
+// 需要新开 shell（或 source env 文件）才能在当前会话中生效
+func (s ServiceConfig) UsesShellEnv() bool {
+	return len(codexEnvVars(&s)) > 0
+}
+
 type ClaudeSettings struct {
 	Env         map[string]string `json:"env"`
 	Permissions struct {
 		Allow []string `json:"allow"`
 		Deny  []string `json:"deny"`
 	} `json:"permissions"`
-	AlwaysThinkingEnabled bool `json:"alwaysThinkingEnabled"`
+	AlwaysThinkingEnabled bool   `json:"alwaysThinkingEnabled"`
+	APIKeyHelper          string `json:"apiKeyHelper,omitempty"`
 }
 
 type CodexAuth struct {
@@ -146,6 +250,7 @@ func (c *Config) Load() error {
 			c.Active = ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}
 			c.Language = "zh"
 			SetLanguage(c.Language)
+			c.PermissionPresets = defaultPermissionPresets()
 
 			// Import existing configurations
 			c.importExistingConfigs()
@@ -159,9 +264,15 @@ func (c *Config) Load() error {
 		return fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	// 旧版本配置中没有权限预设，提供示例预设；用户删除全部预设后保存为空数组，不会再次填充
+	if c.PermissionPresets == nil {
+		c.PermissionPresets = defaultPermissionPresets()
+	}
+
 	// Migrate old configurations
 	c.migrateCodexConfigs()
 	c.migrateClaudeConfigs()
+	c.migrateDroidConfigs()
 
 	// Validate active indices
 	if c.Active.ClaudeCode >= len(c.ClaudeCode) {
@@ -170,6 +281,11 @@ func (c *Config) Load() error {
 	if c.Active.Codex >= len(c.Codex) {
 		c.Active.Codex = -1
 	}
+	for _, st := range c.Tools {
+		if st != nil && st.Active >= len(st.Configs) {
+			st.Active = -1
+		}
+	}
 
 	// Initialize language setting
 	if c.Language == "" {
@@ -187,33 +303,8 @@ func (c *Config) importExistingConfigs() {
 	}
 
 	// Import Claude Code configuration
-	claudeSettingsPath := filepath.Join(platformPaths.GetClaudeConfigDir(), "settings.json")
-	if data, err := os.ReadFile(claudeSettingsPath); err == nil {
-		var settings ClaudeSettings
-		if err := json.Unmarshal(data, &settings); err == nil {
-			if authToken, exists := settings.Env["ANTHROPIC_AUTH_TOKEN"]; exists {
-				if baseURL, exists := settings.Env["ANTHROPIC_BASE_URL"]; exists {
-					claudeConfig := ServiceConfig{
-						Name:     "Current Claude",
-						Provider: "Current",
-						BaseURL:  baseURL,
-						APIKey:   authToken,
-					}
-					// Import default model settings if they exist
-					if haikuModel, exists := settings.Env["ANTHROPIC_DEFAULT_HAIKU_MODEL"]; exists {
-						claudeConfig.ClaudeDefaultHaikuModel = haikuModel
-					}
-					if opusModel, exists := settings.Env["ANTHROPIC_DEFAULT_OPUS_MODEL"]; exists {
-						claudeConfig.ClaudeDefaultOpusModel = opusModel
-					}
-					if sonnetModel, exists := settings.Env["ANTHROPIC_DEFAULT_SONNET_MODEL"]; exists {
-						claudeConfig.ClaudeDefaultSonnetModel = sonnetModel
-					}
-					c.ClaudeCode = append(c.ClaudeCode, claudeConfig)
-					c.Active.ClaudeCode = 0
-				}
-			}
-		}
+	if path, err := ClaudeSettingsPath(ClaudeScopeUser); err == nil {
+		_, _ = c.importClaudeSettingsFile(path, ClaudeScopeUser)
 	}
 
 	// Import Codex configuration
@@ -256,23 +347,9 @@ func (c *Config) importExistingConfigs() {
 		}
 	}
 
-	// Import Droid configuration from Factory
-	factoryConfigPath := filepath.Join(platformPaths.GetDroidConfigDir(), "config.json")
-	if factoryData, err := os.ReadFile(factoryConfigPath); err == nil {
-		var factoryConfig FactoryConfig
-		if err := json.Unmarshal(factoryData, &factoryConfig); err == nil && len(factoryConfig.CustomModels) > 0 {
-			// Import the first custom model as current Droid configuration
-			model := factoryConfig.CustomModels[0]
-			droidConfig := DroidConfig{
-				ModelDisplayName: model.ModelDisplayName,
-				Model:            model.Model,
-				BaseURL:          model.BaseURL,
-				APIKey:           model.APIKey,
-				Provider:         model.Provider,
-			}
-			c.Droid = append(c.Droid, droidConfig)
-			c.Active.Droid = 0
-		}
+	// Import Droid configuration from Factory: all custom models, the one selected in settings.json becomes active
+	if results, err := c.importDroidModels(); err == nil && len(results) > 0 && c.Active.Droid < 0 {
+		c.Active.Droid = 0
 	}
 }
 
@@ -311,6 +388,26 @@ func (c *Config) migrateCodexConfigs() {
 			c.Codex[i].ModelReasoningEffort = DefaultModelReasoningEffort
 			migrated = true
 		}
+		if c.Codex[i].ProviderMode == "" {
+			c.Codex[i].ProviderMode = CodexProviderModeCustom
+			migrated = true
+		}
+		// 旧版本的所有配置共用 [model_providers.switcher]：改为各自的段名。
+		// 活动配置的段仍被 config.toml 使用，保留原名，到重命名或删除时再清理。
+		if c.Codex[i].Provider == codexProviderPrefix || c.Codex[i].Provider == "" {
+			if i == c.Active.Codex {
+				if _, tracked := c.Managed.CodexProviders[codexProviderPrefix]; !tracked {
+					if c.Managed.CodexProviders == nil {
+						c.Managed.CodexProviders = map[string]string{}
+					}
+					c.Managed.CodexProviders[codexProviderPrefix] = c.Codex[i].Name
+					migrated = true
+				}
+			} else {
+				c.assignCodexProvider(i, &c.Codex[i])
+				migrated = true
+			}
+		}
 	}
 
 	// Save if any migrations were applied
@@ -338,6 +435,10 @@ func (c *Config) migrateClaudeConfigs() {
 			c.ClaudeCode[i].EffortLevel = DefaultClaudeEffortLevel
 			migrated = true
 		}
+		if c.ClaudeCode[i].ClaudeAuthMode == "" {
+			c.ClaudeCode[i].ClaudeAuthMode = ClaudeAuthToken
+			migrated = true
+		}
 	}
 
 	// Save if any migrations were applied
@@ -353,7 +454,7 @@ func (c *Config) AddClaudeCodeConfig(config ServiceConfig) error {
 }
 
 func (c *Config) AddCodexConfig(config ServiceConfig) error {
-	config.Provider = "switcher"
+	c.assignCodexProvider(-1, &config)
 	c.Codex = append(c.Codex, config)
 	return c.Save()
 }
@@ -363,6 +464,13 @@ func (c *Config) DeleteClaudeCodeConfig(index int) error {
 		return fmt.Errorf("invalid Claude Code index")
 	}
 
+	// 清除项目中对该配置的引用
+	for path, name := range c.ClaudeProjects {
+		if name == c.ClaudeCode[index].Name {
+			delete(c.ClaudeProjects, path)
+		}
+	}
+
 	// Remove the config at index
 	c.ClaudeCode = append(c.ClaudeCode[:index], c.ClaudeCode[index+1:]...)
 
@@ -391,6 +499,133 @@ func (c *Config) DeleteCodexConfig(index int) error {
 		c.Active.Codex--
 	}
 
+	if err := c.Save(); err != nil {
+		return err
+	}
+
+	// 清理不再被任何配置使用的 provider 段
+	if _, err := c.PruneCodexProviderSections(c.CodexPruneComment); err != nil {
+		return fmt.Errorf("config deleted, but failed to prune config.toml: %w", err)
+	}
+	return nil
+}
+
+// UpdateCodexConfig 替换 index 处的 Codex 配置并保存；
+// 若修改导致某个 switcher 创建的 provider 段不再被使用，会一并清理。
+// 重命名活动配置会改变它的 provider 段名，此时重新应用该配置，使 config.toml 指向新的段。
+func (c *Config) UpdateCodexConfig(index int, config ServiceConfig) error {
+	if index < 0 || index >= len(c.Codex) {
+		return fmt.Errorf("invalid Codex index")
+	}
+
+	c.assignCodexProvider(index, &config)
+	oldName := c.Codex[index].Name
+	oldProvider := c.Codex[index].Provider
+	c.Codex[index] = config
+
+	// 配置被重命名时同步更新 provider 段的归属
+	if oldName != config.Name {
+		for section, owner := range c.Managed.CodexProviders {
+			if owner == oldName {
+				c.Managed.CodexProviders[section] = config.Name
+			}
+		}
+	}
+
+	if index == c.Active.Codex && oldProvider != config.Provider && !config.UsesBuiltinOpenAI() {
+		if err := c.SwitchCodex(&c.Codex[index]); err != nil {
+			return fmt.Errorf("config updated, but failed to apply it: %w", err)
+		}
+	}
+	if err := c.Save(); err != nil {
+		return err
+	}
+
+	if _, err := c.PruneCodexProviderSections(c.CodexPruneComment); err != nil {
+		return fmt.Errorf("config updated, but failed to prune config.toml: %w", err)
+	}
+	return nil
+}
+
+// codexProviderPrefix 是 switcher 生成的 provider 段名前缀；旧版本的所有配置都使用这个段名
+const codexProviderPrefix = "switcher"
+
+// codexProviderName 根据配置名生成 provider 段名：字母、数字、_ 和 - 以外的字符替换为 -，
+// 没有可用字符时（例如中文配置名）使用配置名的哈希
+func codexProviderName(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimRight(b.String(), "-")
+	if slug == "" {
+		h := fnv.New32a()
+		h.Write([]byte(name))
+		slug = fmt.Sprintf("%08x", h.Sum32())
+	}
+	return codexProviderPrefix + "-" + slug
+}
+
+// assignCodexProvider 为 index 处（新配置为 -1）的配置设置由配置名生成的 provider 段名，
+// 与其他配置重名时加上数字后缀。导入的、用户自己定义的 provider 名保持不变。
+func (c *Config) assignCodexProvider(index int, config *ServiceConfig) {
+	if config.Provider != "" && config.Provider != codexProviderPrefix && !strings.HasPrefix(config.Provider, codexProviderPrefix+"-") {
+		return
+	}
+	taken := func(provider string) bool {
+		for i := range c.Codex {
+			if i != index && c.Codex[i].Provider == provider {
+				return true
+			}
+		}
+		return false
+	}
+	base := codexProviderName(config.Name)
+	// 已经是该名称（或带后缀的该名称）时保持不变，避免无关的修改改变段名
+	if suffix, ok := strings.CutPrefix(config.Provider, base); ok && !taken(config.Provider) {
+		if _, err := strconv.Atoi(strings.TrimPrefix(suffix, "-")); suffix == "" || (strings.HasPrefix(suffix, "-") && err == nil) {
+			return
+		}
+	}
+	config.Provider = uniqueName(base, taken)
+}
+
+// uniqueName 在 name 已被占用时追加 -2、-3 等后缀
+func uniqueName(name string, taken func(string) bool) string {
+	if !taken(name) {
+		return name
+	}
+	for i := 2; ; i++ {
+		if candidate := fmt.Sprintf("%s-%d", name, i); !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+// UpdateClaudeCodeConfig 替换 index 处的 Claude Code 配置并保存；
+// 配置被重命名时同步更新各项目记录的当前配置名。
+func (c *Config) UpdateClaudeCodeConfig(index int, config ServiceConfig) error {
+	if index < 0 || index >= len(c.ClaudeCode) {
+		return fmt.Errorf("invalid Claude Code index")
+	}
+
+	config.Provider = "switcher"
+	oldName := c.ClaudeCode[index].Name
+	c.ClaudeCode[index] = config
+	if oldName != config.Name {
+		for path, name := range c.ClaudeProjects {
+			if name == oldName {
+				c.ClaudeProjects[path] = config.Name
+			}
+		}
+	}
 	return c.Save()
 }
 
@@ -429,11 +664,24 @@ func (c *Config) GetActiveCodex() *ServiceConfig {
 // 避免上一个配置的残留值泄漏到新配置中。env 中其他 key（用户手动添加的）保持不变。
 var claudeSwitcherEnvKeys = []string{
 	"ANTHROPIC_AUTH_TOKEN",
+	"ANTHROPIC_API_KEY",
 	"ANTHROPIC_BASE_URL",
 	"ANTHROPIC_DEFAULT_HAIKU_MODEL",
 	"ANTHROPIC_DEFAULT_OPUS_MODEL",
 	"ANTHROPIC_DEFAULT_SONNET_MODEL",
+	"ANTHROPIC_MODEL",
+	"ANTHROPIC_SMALL_FAST_MODEL",
+	"CLAUDE_CODE_SUBAGENT_MODEL",
 	"ANTHROPIC_EFFORT_LEVEL",
+	"CLAUDE_CODE_USE_BEDROCK",
+	"AWS_REGION",
+	"AWS_PROFILE",
+	"AWS_BEARER_TOKEN_BEDROCK",
+	"ANTHROPIC_BEDROCK_BASE_URL",
+	"CLAUDE_CODE_USE_VERTEX",
+	"CLOUD_ML_REGION",
+	"ANTHROPIC_VERTEX_PROJECT_ID",
+	"ANTHROPIC_VERTEX_BASE_URL",
 	"CLAUDE_CODE_DISABLE_NONESSENTIAL_TRAFFIC",
 	"CLAUDE_BASH_MAINTAIN_PROJECT_WORKING_DIR",
 	"DISABLE_TELEMETRY",
@@ -444,39 +692,73 @@ var claudeSwitcherEnvKeys = []string{
 	"NO_PROXY",
 }
 
-func (c *Config) SwitchClaudeCode(config *ServiceConfig) error {
-	if config == nil {
-		return fmt.Errorf("config cannot be nil")
+// claudeModelEnv 返回模型相关 env 变量到配置字段的映射，切换、导入和漂移检查共用
+func claudeModelEnv(config *ServiceConfig) map[string]*string {
+	return map[string]*string{
+		"ANTHROPIC_DEFAULT_HAIKU_MODEL":  &config.ClaudeDefaultHaikuModel,
+		"ANTHROPIC_DEFAULT_OPUS_MODEL":   &config.ClaudeDefaultOpusModel,
+		"ANTHROPIC_DEFAULT_SONNET_MODEL": &config.ClaudeDefaultSonnetModel,
+		"ANTHROPIC_MODEL":                &config.ClaudeMainModel,
+		"ANTHROPIC_SMALL_FAST_MODEL":     &config.ClaudeSmallFastModel,
+		"CLAUDE_CODE_SUBAGENT_MODEL":     &config.ClaudeSubagentModel,
 	}
+}
 
-	settingsPath := filepath.Join(platformPaths.GetClaudeConfigDir(), "settings.json")
-
-	// 读取现有 settings.json，保留 enabledPlugins / extraKnownMarketplaces 等
-	// switcher 不管理的字段。读不到或解析失败时从空 settings 开始。
-	settings := make(map[string]interface{})
-	if data, err := os.ReadFile(settingsPath); err == nil {
-		if err := json.Unmarshal(data, &settings); err != nil {
-			settings = make(map[string]interface{})
-		}
+// claudeAuthMode 返回配置的认证方式，未设置时为 auth token
+func claudeAuthMode(config *ServiceConfig) string {
+	if config.ClaudeAuthMode == "" {
+		return ClaudeAuthToken
 	}
+	return config.ClaudeAuthMode
+}
 
-	// 准备本次切换需要写入的 env 值
-	newEnv := map[string]string{
-		"ANTHROPIC_AUTH_TOKEN":                     config.APIKey,
-		"ANTHROPIC_BASE_URL":                       config.BaseURL,
-		"CLAUDE_CODE_DISABLE_NONESSENTIAL_TRAFFIC": "1",
-		"CLAUDE_BASH_MAINTAIN_PROJECT_WORKING_DIR": "true",
-		"DISABLE_TELEMETRY":                        "1",
-		"DO_NOT_TRACK":                             "1",
+// claudeAuthEnvKey 返回认证方式对应的 env 变量名；apiKeyHelper 方式不写 env，返回空字符串
+func claudeAuthEnvKey(config *ServiceConfig) string {
+	switch claudeAuthMode(config) {
+	case ClaudeAuthAPIKey:
+		return "ANTHROPIC_API_KEY"
+	case ClaudeAuthHelper:
+		return ""
+	default:
+		return "ANTHROPIC_AUTH_TOKEN"
 	}
-	if config.ClaudeDefaultHaikuModel != "" {
-		newEnv["ANTHROPIC_DEFAULT_HAIKU_MODEL"] = config.ClaudeDefaultHaikuModel
+}
+
+// validateClaudeExtraEnv 校验配置中的额外环境变量：变量名必须合法，
+// 且不能与 switcher 已通过表单字段管理的 key 冲突
+func validateClaudeExtraEnv(env map[string]string) error {
+	for key, value := range env {
+		if err := validateEnvVar(key, value); err != nil {
+			return err
+		}
+		for _, managed := range claudeSwitcherEnvKeys {
+			if key == managed {
+				return fmt.Errorf("%s is managed by switcher; set it with the corresponding field instead", key)
+			}
+		}
 	}
-	if config.ClaudeDefaultOpusModel != "" {
-		newEnv["ANTHROPIC_DEFAULT_OPUS_MODEL"] = config.ClaudeDefaultOpusModel
+	return nil
+}
+
+// claudeEnv 返回切换到该配置时 switcher 写入 settings.json env 的值（不含额外 env），
+// 以及用户选择不由 switcher 管理、需要保持原样的隐私开关变量
+func (c *Config) claudeEnv(config *ServiceConfig) (map[string]string, map[string]bool) {
+	newEnv := claudeProviderEnv(config)
+	// 隐私/遥测开关：on 写入固定值，off 从 env 中移除，unmanaged 保持文件中的现有值不变
+	unmanagedEnv := map[string]bool{}
+	for _, f := range claudePrivacyFlags {
+		switch c.claudePrivacyMode(config, f) {
+		case PrivacyOn:
+			newEnv[f.envKey] = f.onValue
+		case PrivacyUnmanaged:
+			unmanagedEnv[f.envKey] = true
+		}
 	}
-	if config.ClaudeDefaultSonnetModel != "" {
-		newEnv["ANTHROPIC_DEFAULT_SONNET_MODEL"] = config.ClaudeDefaultSonnetModel
+	// 模型设置：仅在配置了值时写入
+	for key, value := range claudeModelEnv(config) {
+		if *value != "" {
+			newEnv[key] = *value
+		}
 	}
 	effortLevel := config.EffortLevel
 	if effortLevel == "" {
@@ -503,6 +785,77 @@ func (c *Config) SwitchClaudeCode(config *ServiceConfig) error {
 	if config.NOProxy != "" {
 		newEnv["NO_PROXY"] = config.NOProxy
 	}
+	return newEnv, unmanagedEnv
+}
+
+// SwitchClaudeCode 把配置写入用户级 ~/.claude/settings.json
+func (c *Config) SwitchClaudeCode(config *ServiceConfig) error {
+	return c.SwitchClaudeCodeInScope(config, ClaudeScopeUser)
+}
+
+// SwitchClaudeCodeInScope 把配置写入指定作用域的 settings 文件，env 合并规则与用户级相同。
+// MCP 服务器属于用户级 ~/.claude.json，只在用户作用域切换时更新。
+func (c *Config) SwitchClaudeCodeInScope(config *ServiceConfig, scope string) error {
+	if config == nil {
+		return fmt.Errorf("config cannot be nil")
+	}
+	if err := validateMCPServers(config.MCPServers); err != nil {
+		return err
+	}
+	if err := validateClaudeExtraEnv(config.ExtraEnv); err != nil {
+		return err
+	}
+	if err := c.validateClaudePrivacy(config); err != nil {
+		return err
+	}
+	if err := validateClaudeProvider(config); err != nil {
+		return err
+	}
+	// Bedrock/Vertex 不使用 Anthropic 凭据，认证方式不适用
+	authMode := ""
+	if !usesClaudeCloud(config) {
+		authMode = claudeAuthMode(config)
+	}
+	switch authMode {
+	case "", ClaudeAuthToken, ClaudeAuthAPIKey:
+	case ClaudeAuthHelper:
+		if strings.TrimSpace(config.APIKeyHelper) == "" {
+			return fmt.Errorf("apiKeyHelper auth mode requires a helper command")
+		}
+	default:
+		return fmt.Errorf("unknown Claude auth mode %q", config.ClaudeAuthMode)
+	}
+	var preset *PermissionPreset
+	if config.PermissionPreset != "" {
+		if preset = c.FindPermissionPreset(config.PermissionPreset); preset == nil {
+			return fmt.Errorf("permission preset %q not found", config.PermissionPreset)
+		}
+	}
+
+	settingsPath, err := ClaudeSettingsPathFor(config, scope)
+	if err != nil {
+		return err
+	}
+	// MCP 服务器只在用户作用域切换时更新；与用户的同名服务器冲突时在写入 settings.json 之前拒绝
+	userScope := scope == "" || scope == ClaudeScopeUser
+	statePath, previousMCP := c.claudeMCPState(config, settingsPath)
+	if userScope {
+		if err := checkClaudeMCPServers(statePath, previousMCP, config.MCPServers); err != nil {
+			return fmt.Errorf("failed to update Claude MCP servers: %w", err)
+		}
+	}
+
+	// 读取现有 settings.json，保留 enabledPlugins / extraKnownMarketplaces 等
+	// switcher 不管理的字段。读不到或解析失败时从空 settings 开始。
+	settings := make(map[string]interface{})
+	if data, err := os.ReadFile(settingsPath); err == nil {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			settings = make(map[string]interface{})
+		}
+	}
+
+	// 准备本次切换需要写入的 env 值
+	newEnv, unmanagedEnv := c.claudeEnv(config)
 
 	// 合并到现有 env：清掉 switcher 管理的旧 key，再写入新值，保留用户其他 key
 	mergedEnv := map[string]interface{}{}
@@ -512,13 +865,53 @@ func (c *Config) SwitchClaudeCode(config *ServiceConfig) error {
 		}
 	}
 	for _, k := range claudeSwitcherEnvKeys {
+		if !unmanagedEnv[k] {
+			delete(mergedEnv, k)
+		}
+	}
+	// 额外 env：先移除上一次切换写入该文件的 key 并恢复被覆盖的用户原值，再写入当前配置的 key。
+	// 与用户已有 key 同名的条目不记为 switcher 所有，而是记下原值，切走时恢复。
+	for _, k := range c.Managed.ClaudeEnvKeys[settingsPath] {
 		delete(mergedEnv, k)
 	}
+	for k, v := range c.Managed.ClaudeEnvOverrides[settingsPath] {
+		mergedEnv[k] = v
+	}
+	var ownedEnv []string
+	overriddenEnv := map[string]interface{}{}
+	for k, v := range config.ExtraEnv {
+		if old, exists := mergedEnv[k]; exists {
+			overriddenEnv[k] = old
+		} else {
+			ownedEnv = append(ownedEnv, k)
+		}
+		mergedEnv[k] = v
+	}
+	sort.Strings(ownedEnv)
 	for k, v := range newEnv {
 		mergedEnv[k] = v
 	}
 	settings["env"] = mergedEnv
 
+	// apiKeyHelper：helper 方式写入命令；其他方式只移除 switcher 之前写入的条目。
+	// 覆盖用户原有的 apiKeyHelper 时不记为 switcher 所有，切走时保持不变。
+	_, hadHelper := settings["apiKeyHelper"]
+	helperTracked := c.Managed.ClaudeKeyHelpers[settingsPath]
+	trackHelper := false
+	if authMode == ClaudeAuthHelper {
+		settings["apiKeyHelper"] = config.APIKeyHelper
+		trackHelper = helperTracked || !hadHelper
+	} else if helperTracked {
+		delete(settings, "apiKeyHelper")
+	}
+
+	// 权限预设：移除上一次写入该文件的规则，再合并当前预设的规则，用户自己的规则保持不变
+	previousRules := c.Managed.ClaudePermissions[settingsPath]
+	var ownedRules ManagedRules
+	if preset != nil || len(previousRules.Allow) > 0 || len(previousRules.Deny) > 0 {
+		ownedRules = applyClaudePermissions(settings, previousRules, preset)
+	}
+
 	// 确保 permissions 至少存在一个合法结构（首次创建时需要）
 	if _, ok := settings["permissions"]; !ok {
 		settings["permissions"] = map[string]interface{}{
@@ -537,7 +930,112 @@ func (c *Config) SwitchClaudeCode(config *ServiceConfig) error {
 		return fmt.Errorf("failed to create .claude directory: %w", err)
 	}
 
-	return writeFileWithPerms(settingsPath, data, 0644)
+	if err := writeFileWithPerms(settingsPath, data, 0644); err != nil {
+		return err
+	}
+	if len(ownedRules.Allow) > 0 || len(ownedRules.Deny) > 0 {
+		if c.Managed.ClaudePermissions == nil {
+			c.Managed.ClaudePermissions = map[string]ManagedRules{}
+		}
+		c.Managed.ClaudePermissions[settingsPath] = ownedRules
+	} else {
+		delete(c.Managed.ClaudePermissions, settingsPath)
+	}
+	if trackHelper {
+		if c.Managed.ClaudeKeyHelpers == nil {
+			c.Managed.ClaudeKeyHelpers = map[string]bool{}
+		}
+		c.Managed.ClaudeKeyHelpers[settingsPath] = true
+	} else {
+		delete(c.Managed.ClaudeKeyHelpers, settingsPath)
+	}
+	if len(ownedEnv) > 0 {
+		if c.Managed.ClaudeEnvKeys == nil {
+			c.Managed.ClaudeEnvKeys = map[string][]string{}
+		}
+		c.Managed.ClaudeEnvKeys[settingsPath] = ownedEnv
+	} else {
+		delete(c.Managed.ClaudeEnvKeys, settingsPath)
+	}
+	if len(overriddenEnv) > 0 {
+		if c.Managed.ClaudeEnvOverrides == nil {
+			c.Managed.ClaudeEnvOverrides = map[string]map[string]interface{}{}
+		}
+		c.Managed.ClaudeEnvOverrides[settingsPath] = overriddenEnv
+	} else {
+		delete(c.Managed.ClaudeEnvOverrides, settingsPath)
+	}
+
+	if !userScope {
+		return nil
+	}
+
+	owned, err := applyClaudeMCPServers(statePath, previousMCP, config.MCPServers)
+	if err != nil {
+		return fmt.Errorf("failed to update Claude MCP servers: %w", err)
+	}
+	if config.TargetDir != "" {
+		if len(owned) > 0 {
+			if c.Managed.ClaudeTargetMCPServers == nil {
+				c.Managed.ClaudeTargetMCPServers = map[string][]string{}
+			}
+			c.Managed.ClaudeTargetMCPServers[statePath] = owned
+		} else {
+			delete(c.Managed.ClaudeTargetMCPServers, statePath)
+		}
+		return nil
+	}
+	c.Managed.ClaudeMCPServers = owned
+	return nil
+}
+
+// claudeMCPState 返回切换时要更新 MCP 服务器的状态文件及上一个配置在其中写入的服务器：
+// 默认为用户级 ~/.claude.json；配置了目标目录时为该目录中的 .claude.json，按文件分别记录
+func (c *Config) claudeMCPState(config *ServiceConfig, settingsPath string) (string, []string) {
+	if config.TargetDir != "" {
+		statePath := filepath.Join(filepath.Dir(settingsPath), ".claude.json")
+		return statePath, c.Managed.ClaudeTargetMCPServers[statePath]
+	}
+	return platformPaths.GetClaudeStatePath(), c.Managed.ClaudeMCPServers
+}
+
+// codexBehaviorKeys 是 switcher 按配置管理的 config.toml 顶层行为设置。
+// 切换时配置中设置了的 key 会被写入；未设置的 key 会从 config.toml 中移除，
+// 避免上一个配置的设置残留到新配置中。
+var codexBehaviorKeys = []string{
+	"approval_policy",
+	"sandbox_mode",
+	"model_verbosity",
+	"model_reasoning_summary",
+	"model_context_window",
+	"model_max_output_tokens",
+}
+
+// codexBehaviorValues 返回配置中已设置的行为 key 及其 TOML 字面值。
+// 数值类 key 只有在是正整数时才会写入。
+func codexBehaviorValues(config *ServiceConfig) map[string]string {
+	values := map[string]string{}
+	quoted := map[string]string{
+		"approval_policy":         config.ApprovalPolicy,
+		"sandbox_mode":            config.SandboxMode,
+		"model_verbosity":         config.ModelVerbosity,
+		"model_reasoning_summary": config.ModelReasoningSummary,
+	}
+	for k, v := range quoted {
+		if v = strings.TrimSpace(v); v != "" {
+			values[k] = fmt.Sprintf(`"%s"`, escapeTomlString(v))
+		}
+	}
+	numeric := map[string]string{
+		"model_context_window":    config.ModelContextWindow,
+		"model_max_output_tokens": config.ModelMaxOutputTokens,
+	}
+	for k, v := range numeric {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && n > 0 {
+			values[k] = strconv.Itoa(n)
+		}
+	}
+	return values
 }
 
 func (c *Config) SwitchCodex(config *ServiceConfig) error {
@@ -545,10 +1043,23 @@ func (c *Config) SwitchCodex(config *ServiceConfig) error {
 		return fmt.Errorf("config cannot be nil")
 	}
 
+	// 内置 openai provider 不需要写 provider 段，因此不受段名限制
+	builtin := config.UsesBuiltinOpenAI()
 	providerName := config.Provider
-	if !isValidTomlSectionName(providerName) {
+	if builtin {
+		providerName = CodexProviderModeOpenAI
+	} else if !isValidTomlSectionName(providerName) {
 		return fmt.Errorf("invalid provider name %q: must contain only letters, digits, underscores, and hyphens", providerName)
 	}
+	envVars := codexEnvVars(config)
+	for key, value := range envVars {
+		if err := validateEnvVar(key, value); err != nil {
+			return err
+		}
+	}
+	if err := validateMCPServers(config.MCPServers); err != nil {
+		return err
+	}
 
 	codexDir := platformPaths.GetCodexConfigDir()
 	if err := mkdirWithPerms(codexDir, 0755); err != nil {
@@ -605,43 +1116,109 @@ func (c *Config) SwitchCodex(config *ServiceConfig) error {
 		wireAPI = DefaultWireAPI
 	}
 
-	envKey := ""
-	if config.AuthMethod == "env" {
-		envKey = config.EnvKey
-		if envKey == "" {
-			envKey = DefaultEnvKey
-		}
-	}
-
 	// Surgically update only the keys switcher manages
 	content = updateTomlKey(content, "model_provider", fmt.Sprintf(`"%s"`, escapeTomlString(providerName)))
 	content = updateTomlKey(content, "model", fmt.Sprintf(`"%s"`, escapeTomlString(model)))
 	content = updateTomlKey(content, "model_reasoning_effort", fmt.Sprintf(`"%s"`, escapeTomlString(modelReasoningEffort)))
+	behavior := codexBehaviorValues(config)
+	for _, key := range codexBehaviorKeys {
+		if v, ok := behavior[key]; ok {
+			content = updateTomlKey(content, key, v)
+		} else {
+			content = removeTomlKey(content, key)
+		}
+	}
 
 	// Update or add the provider section
-	sectionBody := fmt.Sprintf(`name = "%s"
+	track := false
+	if !builtin {
+		sectionBody := fmt.Sprintf(`name = "%s"
 base_url = "%s"
 wire_api = "%s"`, escapeTomlString(providerName), escapeTomlString(config.BaseURL), escapeTomlString(wireAPI))
-	if envKey != "" {
-		sectionBody += fmt.Sprintf("\nenv_key = \"%s\"", escapeTomlString(envKey))
+		if envKey := codexEnvKey(config); envKey != "" {
+			sectionBody += fmt.Sprintf("\nenv_key = \"%s\"", escapeTomlString(envKey))
+		}
+		sectionBody += fmt.Sprintf("\nrequires_openai_auth = %t", true)
+		sectionName := fmt.Sprintf("model_providers.%s", providerName)
+		// 只记录 switcher 自己创建的段，用户原有的段即使被覆盖也不会被 prune 删除
+		_, tracked := c.Managed.CodexProviders[providerName]
+		track = tracked || !hasTomlSection(content, sectionName)
+		content = updateOrAddTomlSection(content, sectionName, sectionBody)
+	}
+
+	content, ownedMCP, err := applyCodexMCPServers(content, c.Managed.CodexMCPServers, config.MCPServers)
+	if err != nil {
+		return err
 	}
-	sectionBody += fmt.Sprintf("\nrequires_openai_auth = %t", true)
-	content = updateOrAddTomlSection(content, fmt.Sprintf("model_providers.%s", providerName), sectionBody)
 
 	// Write back
 	if err := writeFileWithPerms(configPath, []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to write config.toml: %w", err)
 	}
+	if track {
+		if c.Managed.CodexProviders == nil {
+			c.Managed.CodexProviders = map[string]string{}
+		}
+		c.Managed.CodexProviders[providerName] = config.Name
+	}
+	c.Managed.CodexMCPServers = ownedMCP
+
+	return c.applyCodexEnvVars(envVars)
+}
+
+// codexEnvKey 返回 env 认证方式下保存 API Key 的环境变量名；auth.json 认证返回空字符串。
+// 内置 openai provider 固定读取 OPENAI_API_KEY。
+func codexEnvKey(config *ServiceConfig) string {
+	if config.AuthMethod != "env" {
+		return ""
+	}
+	if config.UsesBuiltinOpenAI() {
+		return codexOpenAIAPIKeyEnv
+	}
+	if config.EnvKey != "" {
+		return config.EnvKey
+	}
+	return DefaultEnvKey
+}
 
-	// Set environment variable for env auth method
-	authMethod := config.AuthMethod
-	if authMethod == "" {
-		authMethod = "auth.json"
+// codexEnvVars 返回切换到该配置时需要通过 shell 集成设置的环境变量
+func codexEnvVars(config *ServiceConfig) map[string]string {
+	vars := map[string]string{}
+	if envKey := codexEnvKey(config); envKey != "" {
+		vars[envKey] = config.APIKey
 	}
-	if authMethod == "env" {
-		return shellManager.SetEnvVar(envKey, config.APIKey)
+	if config.UsesBuiltinOpenAI() {
+		if baseURL := strings.TrimSpace(config.BaseURL); baseURL != "" {
+			vars[codexOpenAIBaseURLEnv] = baseURL
+		}
 	}
+	return vars
+}
 
+// applyCodexEnvVars 设置新配置需要的环境变量，并移除上一个配置设置、新配置不再需要的变量，
+// 避免例如切回自定义 provider 后 OPENAI_BASE_URL 仍然生效。
+func (c *Config) applyCodexEnvVars(vars map[string]string) error {
+	for _, key := range c.Managed.CodexEnvVars {
+		if _, keep := vars[key]; keep {
+			continue
+		}
+		if err := shellManager.UnsetEnvVar(key); err != nil {
+			return err
+		}
+	}
+	c.Managed.CodexEnvVars = nil
+
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := shellManager.SetEnvVar(key, vars[key]); err != nil {
+			return err
+		}
+		c.Managed.CodexEnvVars = append(c.Managed.CodexEnvVars, key)
+	}
 	return nil
 }
 
@@ -671,6 +1248,26 @@ func readTomlKey(content, key string) (string, bool) {
 	return "", false
 }
 
+// readTomlSectionKey reads a string value from the given section (sub-tables are not searched).
+// Returns the value and true if found, or ("", false) if not found.
+func readTomlSectionKey(content, sectionName, key string) (string, bool) {
+	header := "[" + sectionName + "]"
+	inSection := false
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			inSection = trimmed == header
+			continue
+		}
+		if inSection {
+			if v, ok := readTomlKey(trimmed, key); ok {
+				return v, true
+			}
+		}
+	}
+	return "", false
+}
+
 // updateTomlKey replaces or adds a top-level key in TOML content.
 func updateTomlKey(content, key, value string) string {
 	lines := strings.Split(content, "\n")
@@ -714,6 +1311,27 @@ func updateTomlKey(content, key, value string) string {
 	return strings.Join(result, "\n")
 }
 
+// removeTomlKey removes a top-level key from TOML content if present.
+func removeTomlKey(content, key string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") {
+			break
+		}
+		if strings.HasPrefix(trimmed, key) {
+			afterKey := strings.TrimSpace(trimmed[len(key):])
+			if strings.HasPrefix(afterKey, "=") {
+				return strings.Join(append(lines[:i:i], lines[i+1:]...), "\n")
+			}
+		}
+	}
+	return content
+}
+
 // updateOrAddTomlSection replaces or appends a TOML section.
 func updateOrAddTomlSection(content, sectionName, sectionBody string) string {
 	header := "[" + sectionName + "]"
@@ -752,6 +1370,137 @@ func updateOrAddTomlSection(content, sectionName, sectionBody string) string {
 	return content
 }
 
+// hasTomlSection reports whether the content contains the given section header.
+func hasTomlSection(content, sectionName string) bool {
+	header := "[" + sectionName + "]"
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == header {
+			return true
+		}
+	}
+	return false
+}
+
+// removeTomlSection removes a TOML section together with its sub-tables
+// (e.g. [model_providers.X] and [model_providers.X.http_headers]).
+// Returns the new content and whether anything was removed.
+func removeTomlSection(content, sectionName string) (string, bool) {
+	lines := strings.Split(content, "\n")
+	result := make([]string, 0, len(lines))
+	removed := false
+	skipping := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			name := strings.TrimSpace(strings.Trim(trimmed, "[]"))
+			skipping = name == sectionName || strings.HasPrefix(name, sectionName+".")
+			if skipping {
+				removed = true
+			}
+		}
+		if !skipping {
+			result = append(result, line)
+		}
+	}
+	if !removed {
+		return content, false
+	}
+	// 合并删除段后留下的连续空行
+	out := strings.Join(result, "\n")
+	for strings.Contains(out, "\n\n\n") {
+		out = strings.ReplaceAll(out, "\n\n\n", "\n\n")
+	}
+	return strings.TrimRight(out, "\n") + "\n", true
+}
+
+// commentTomlSection 把段（包括其子表）的每一行注释掉，保留内容供用户参考
+func commentTomlSection(content, sectionName string) (string, bool) {
+	lines := strings.Split(content, "\n")
+	commented := false
+	inSection := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			name := strings.TrimSpace(strings.Trim(trimmed, "[]"))
+			inSection = name == sectionName || strings.HasPrefix(name, sectionName+".")
+			if inSection {
+				commented = true
+			}
+		}
+		if inSection && trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			lines[i] = "# " + line
+		}
+	}
+	if !commented {
+		return content, false
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// StaleCodexProviderSections 返回 switcher 创建过、但已没有任何 Codex 配置使用的 provider 名称。
+// 当前 config.toml 中 model_provider 仍指向的 provider 不会被视为过期。
+func (c *Config) StaleCodexProviderSections() []string {
+	inUse := map[string]bool{}
+	for _, sc := range c.Codex {
+		if !sc.UsesBuiltinOpenAI() {
+			inUse[sc.Provider] = true
+		}
+	}
+	configPath := filepath.Join(platformPaths.GetCodexConfigDir(), "config.toml")
+	if data, err := os.ReadFile(configPath); err == nil {
+		if current, ok := readTomlKey(strings.ReplaceAll(string(data), "\r\n", "\n"), "model_provider"); ok {
+			inUse[current] = true
+		}
+	}
+
+	var stale []string
+	for provider := range c.Managed.CodexProviders {
+		if !inUse[provider] {
+			stale = append(stale, provider)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// PruneCodexProviderSections 从 config.toml 中删除过期的 provider 段（comment 为 true 时改为注释掉），
+// 并返回被处理的 provider 名称。
+func (c *Config) PruneCodexProviderSections(comment bool) ([]string, error) {
+	stale := c.StaleCodexProviderSections()
+	if len(stale) == 0 {
+		return nil, nil
+	}
+
+	configPath := filepath.Join(platformPaths.GetCodexConfigDir(), "config.toml")
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read config.toml: %w", err)
+	}
+	content := strings.ReplaceAll(string(data), "\r\n", "\n")
+
+	changed := false
+	for _, provider := range stale {
+		var removed bool
+		if comment {
+			content, removed = commentTomlSection(content, "model_providers."+provider)
+		} else {
+			content, removed = removeTomlSection(content, "model_providers."+provider)
+		}
+		changed = changed || removed
+		delete(c.Managed.CodexProviders, provider)
+	}
+
+	if changed {
+		if err := writeFileWithPerms(configPath, []byte(content), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write config.toml: %w", err)
+		}
+	}
+	if err := c.Save(); err != nil {
+		return nil, err
+	}
+	return stale, nil
+}
+
 // escapeTomlString escapes special characters in a TOML string value.
 func escapeTomlString(s string) string {
 	s = strings.ReplaceAll(s, `\`, `\\`)
@@ -786,11 +1535,24 @@ disable_response_storage = false
 
 // Droid configuration management methods
 func (c *Config) AddDroidConfig(config DroidConfig) error {
-	config.Provider = "switcher"
+	if err := validateDroidConfig(config); err != nil {
+		return err
+	}
 	c.Droid = append(c.Droid, config)
 	return c.Save()
 }
 
+func (c *Config) UpdateDroidConfig(index int, config DroidConfig) error {
+	if index < 0 || index >= len(c.Droid) {
+		return fmt.Errorf("invalid Droid index")
+	}
+	if err := validateDroidConfig(config); err != nil {
+		return err
+	}
+	c.Droid[index] = config
+	return c.Save()
+}
+
 func (c *Config) DeleteDroidConfig(index int) error {
 	if index < 0 || index >= len(c.Droid) {
 		return fmt.Errorf("invalid Droid index")
@@ -830,70 +1592,34 @@ func (c *Config) SwitchDroid(config *DroidConfig) error {
 	}
 
 	factoryDir := platformPaths.GetDroidConfigDir()
-	if err := mkdirWithPerms(factoryDir, 0755); err != nil {
-		return fmt.Errorf("failed to create .factory directory: %w", err)
-	}
-
-	// Create FactoryConfig with the custom model
-	factoryConfig := FactoryConfig{
-		CustomModels: []DroidConfig{*config},
-	}
-
-	data, err := json.MarshalIndent(factoryConfig, "", "  ")
+	err := mkdirWithPerms(factoryDir, 0755)
 	if err != nil {
-		return fmt.Errorf("failed to marshal Factory config: %w", err)
-	}
-
-	configPath := filepath.Join(factoryDir, "config.json")
-	if err := writeFileWithPerms(configPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write config.json: %w", err)
+		return fmt.Errorf("failed to create .factory directory: %w", err)
 	}
 
-	// Update settings.json with the custom model
+	// 先准备 settings.json 的新内容：文件无法解析时直接报错，不改动任何文件
 	settingsPath := filepath.Join(factoryDir, "settings.json")
-
-	// Read existing settings.json to preserve other settings
-	existingSettings := make(map[string]interface{})
-	if data, err := os.ReadFile(settingsPath); err == nil {
-		// Parse JSON with comments support by stripping comments first
-		content := string(data)
-		lines := strings.Split(content, "\n")
-		var jsonLines []string
-		for _, line := range lines {
-			trimmed := strings.TrimSpace(line)
-			if !strings.HasPrefix(trimmed, "//") && trimmed != "" {
-				jsonLines = append(jsonLines, line)
-			}
-		}
-		jsonContent := strings.Join(jsonLines, "\n")
-		if jsonContent != "" {
-			if err := json.Unmarshal([]byte(jsonContent), &existingSettings); err != nil {
-				// If parsing fails, start with empty settings
-				existingSettings = make(map[string]interface{})
-			}
+	var settingsData []byte
+	if config.Model != "" {
+		settingsData, err = factorySettingsWithModel(settingsPath, "custom:"+config.Model)
+		if err != nil {
+			return err
 		}
 	}
 
-	// Update the model field with custom: prefix
-	modelName := config.Model
-	if modelName != "" {
-		existingSettings["model"] = "custom:" + modelName
-	}
-
-	// Marshal back to JSON
-	updatedData, err := json.MarshalIndent(existingSettings, "", "  ")
+	// 合并到 custom_models：只替换 switcher 写入的模型，保留用户在 Factory 中定义的模型
+	configPath := filepath.Join(factoryDir, "config.json")
+	owned, err := mergeDroidCustomModels(configPath, c.Managed.DroidModels, c.droidPublishedModels(config))
 	if err != nil {
-		return fmt.Errorf("failed to marshal updated settings: %w", err)
+		return err
 	}
+	c.Managed.DroidModels = owned
 
-	// Write the updated settings with comments header
-	var finalContent strings.Builder
-	finalContent.WriteString("// Factory CLI Settings\n")
-	finalContent.WriteString("// This file contains your Factory CLI configuration.\n")
-	finalContent.WriteString(string(updatedData))
-	finalContent.WriteString("\n")
-
-	return writeFileWithPerms(settingsPath, []byte(finalContent.String()), 0644)
+	// 只更新 settings.json 中的 model，保留用户的注释、顺序和格式
+	if settingsData == nil {
+		return nil
+	}
+	return writeFileWithPerms(settingsPath, settingsData, 0644)
 }
 
 // findConfigIndex 查找配置在原始列表中的索引
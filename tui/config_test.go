@@ -0,0 +1,1358 @@
+package tui
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type testPlatformPaths struct {
+	dir string
+}
+
+func (p *testPlatformPaths) GetAppConfigPath() string {
+	return filepath.Join(p.dir, "switcher", "config.json")
+}
+
+func (p *testPlatformPaths) GetClaudeConfigDir() string {
+	return filepath.Join(p.dir, ".claude")
+}
+
+func (p *testPlatformPaths) GetClaudeStatePath() string {
+	return filepath.Join(p.dir, ".claude.json")
+}
+
+func (p *testPlatformPaths) GetCodexConfigDir() string {
+	return filepath.Join(p.dir, ".codex")
+}
+
+func (p *testPlatformPaths) GetDroidConfigDir() string {
+	return filepath.Join(p.dir, ".factory")
+}
+
+func (p *testPlatformPaths) GetHomeDir() string {
+	return p.dir
+}
+
+// useTempPlatformPaths points every tool directory at a fresh temp dir for the duration of the test.
+func useTempPlatformPaths(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	old := platformPaths
+	platformPaths = &testPlatformPaths{dir: dir}
+	t.Cleanup(func() { platformPaths = old })
+	return dir
+}
+
+// fakeShellManager keeps environment variables in memory instead of touching rc files.
+type fakeShellManager struct {
+	vars map[string]string
+}
+
+func (f *fakeShellManager) SetEnvVar(key, value string) error {
+	f.vars[key] = value
+	return nil
+}
+
+func (f *fakeShellManager) UnsetEnvVar(key string) error {
+	delete(f.vars, key)
+	return nil
+}
+
+func (f *fakeShellManager) GetEnvVar(key string) (string, bool) {
+	v, ok := f.vars[key]
+	return v, ok
+}
+
+func (f *fakeShellManager) Uninstall(legacyKeys []string) error {
+	f.vars = map[string]string{}
+	return nil
+}
+
+func (f *fakeShellManager) ActivationHint() string { return "" }
+
+func useFakeShellManager(t *testing.T) *fakeShellManager {
+	t.Helper()
+	fake := &fakeShellManager{vars: map[string]string{}}
+	old := shellManager
+	shellManager = fake
+	t.Cleanup(func() { shellManager = old })
+	return fake
+}
+
+func TestRemoveTomlSectionRemovesSubTables(t *testing.T) {
+	content := `model_provider = "a"
+
+[model_providers.a]
+name = "a"
+
+[model_providers.old]
+name = "old"
+base_url = "https://old.example.com"
+
+[model_providers.old.http_headers]
+X = "1"
+
+[profiles.dev]
+model = "x"
+`
+	got, removed := removeTomlSection(content, "model_providers.old")
+	if !removed {
+		t.Fatalf("expected section to be removed")
+	}
+	if strings.Contains(got, "old") {
+		t.Fatalf("stale section still present:\n%s", got)
+	}
+	if !strings.Contains(got, "[model_providers.a]") || !strings.Contains(got, "[profiles.dev]") {
+		t.Fatalf("unrelated sections were removed:\n%s", got)
+	}
+
+	if _, removed := removeTomlSection(content, "model_providers.missing"); removed {
+		t.Fatalf("removing a missing section should report false")
+	}
+}
+
+func TestPruneCodexProviderSectionsOnlyRemovesTrackedSections(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	codexDir := filepath.Join(dir, ".codex")
+	if err := os.MkdirAll(codexDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// 用户自己维护的 [model_providers.mine] 不应被 prune 触碰
+	initial := `model_provider = "mine"
+
+[model_providers.mine]
+name = "mine"
+`
+	configPath := filepath.Join(codexDir, "config.toml")
+	if err := os.WriteFile(configPath, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+	readConfig := func() string {
+		t.Helper()
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	switchTo := func(c *Config, index int) {
+		t.Helper()
+		if err := c.SwitchCodex(&c.Codex[index]); err != nil {
+			t.Fatalf("SwitchCodex(%s): %v", c.Codex[index].Name, err)
+		}
+		if err := c.SetActiveCodex(index); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	for _, name := range []string{"First", "Second"} {
+		if err := c.AddCodexConfig(ServiceConfig{Name: name, BaseURL: "https://" + name + ".example.com", APIKey: "k"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c.Codex[0].Provider != "switcher-first" || c.Codex[1].Provider != "switcher-second" {
+		t.Fatalf("providers = %q, %q", c.Codex[0].Provider, c.Codex[1].Provider)
+	}
+	switchTo(c, 0)
+	switchTo(c, 1)
+	if got := c.Managed.CodexProviders["switcher-first"]; got != "First" {
+		t.Fatalf("provider 'switcher-first' owner = %q, want First", got)
+	}
+	if _, ok := c.Managed.CodexProviders["mine"]; ok {
+		t.Fatalf("user section 'mine' must not be tracked")
+	}
+
+	// 重命名不活动的配置：旧段被清理，新段在下次切换时写入
+	renamed := c.Codex[0]
+	renamed.Name = "Renamed"
+	if err := c.UpdateCodexConfig(0, renamed); err != nil {
+		t.Fatalf("UpdateCodexConfig: %v", err)
+	}
+	if content := readConfig(); strings.Contains(content, "[model_providers.switcher-first]") || c.Codex[0].Provider != "switcher-renamed" {
+		t.Fatalf("rename did not prune the old section (provider %q):\n%s", c.Codex[0].Provider, content)
+	}
+
+	// 重命名活动配置：重新应用到新段，旧段被清理
+	active := c.Codex[1]
+	active.Name = "Second v2"
+	if err := c.UpdateCodexConfig(1, active); err != nil {
+		t.Fatalf("UpdateCodexConfig(active): %v", err)
+	}
+	content := readConfig()
+	if strings.Contains(content, "[model_providers.switcher-second]") || !strings.Contains(content, "[model_providers.switcher-second-v2]") ||
+		!strings.Contains(content, `model_provider = "switcher-second-v2"`) {
+		t.Fatalf("renaming the active config did not move its section:\n%s", content)
+	}
+
+	// 删除配置：它创建的段被清理；设置 CodexPruneComment 时改为注释掉
+	switchTo(c, 0)
+	c.CodexPruneComment = true
+	if err := c.DeleteCodexConfig(1); err != nil {
+		t.Fatalf("DeleteCodexConfig: %v", err)
+	}
+	content = readConfig()
+	if !strings.Contains(content, "# [model_providers.switcher-second-v2]") || !strings.Contains(content, `# base_url = "https://Second.example.com"`) {
+		t.Fatalf("stale section was not commented out:\n%s", content)
+	}
+	for _, want := range []string{"\n[model_providers.switcher-renamed]", "\n[model_providers.mine]"} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("%s should be kept:\n%s", want, content)
+		}
+	}
+	if len(c.StaleCodexProviderSections()) != 0 {
+		t.Fatalf("expected no stale sections after prune, got %v", c.StaleCodexProviderSections())
+	}
+}
+
+func TestCodexProviderNames(t *testing.T) {
+	for name, want := range map[string]string{
+		"OpenRouter":    "switcher-openrouter",
+		"my relay (v2)": "switcher-my-relay-v2",
+		"snake_case-ok": "switcher-snake_case-ok",
+	} {
+		if got := codexProviderName(name); got != want || !isValidTomlSectionName(got) {
+			t.Fatalf("codexProviderName(%q) = %q, want %q", name, got, want)
+		}
+	}
+	if a := codexProviderName("官方中转"); !isValidTomlSectionName(a) || a == codexProviderName("备用中转") {
+		t.Fatalf("names without ASCII characters should not share a section")
+	}
+
+	// 旧版本的配置共用 "switcher"：活动配置保留并被记录为 switcher 创建，其余配置改用各自的段名；
+	// 同名段加数字后缀，导入的用户 provider 名保持不变
+	c := &Config{
+		Active: ActiveConfig{ClaudeCode: -1, Codex: 1, Droid: -1},
+		Codex: []ServiceConfig{
+			{Name: "a b", Provider: "switcher"},
+			{Name: "live", Provider: "switcher"},
+			{Name: "a-b", Provider: "switcher"},
+			{Name: "Current Codex", Provider: "openrouter"},
+		},
+	}
+	useTempPlatformPaths(t)
+	c.migrateCodexConfigs()
+	got := []string{c.Codex[0].Provider, c.Codex[1].Provider, c.Codex[2].Provider, c.Codex[3].Provider}
+	if want := []string{"switcher-a-b", "switcher", "switcher-a-b-2", "openrouter"}; !slices.Equal(got, want) {
+		t.Fatalf("migrated providers = %v, want %v", got, want)
+	}
+	if c.Managed.CodexProviders["switcher"] != "live" {
+		t.Fatalf("legacy section should be tracked, got %v", c.Managed.CodexProviders)
+	}
+}
+
+func TestSwitchCodexManagesBehaviorKeys(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	configPath := filepath.Join(dir, ".codex", "config.toml")
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	tuned := ServiceConfig{
+		Name: "tuned", Provider: "switcher", BaseURL: "https://a.example.com", APIKey: "k",
+		ApprovalPolicy: "never", SandboxMode: "workspace-write", ModelContextWindow: "200000", ModelMaxOutputTokens: "abc",
+	}
+	if err := c.SwitchCodex(&tuned); err != nil {
+		t.Fatalf("SwitchCodex(tuned): %v", err)
+	}
+	data, _ := os.ReadFile(configPath)
+	content := string(data)
+	for key, want := range map[string]string{"approval_policy": "never", "sandbox_mode": "workspace-write", "model_context_window": "200000"} {
+		if got, ok := readTomlKey(content, key); !ok || got != want {
+			t.Fatalf("%s = %q (found=%v), want %q", key, got, ok, want)
+		}
+	}
+	if _, ok := readTomlKey(content, "model_max_output_tokens"); ok {
+		t.Fatalf("non-numeric model_max_output_tokens must not be written:\n%s", content)
+	}
+
+	plain := ServiceConfig{Name: "plain", Provider: "switcher", BaseURL: "https://b.example.com", APIKey: "k", SandboxMode: "read-only"}
+	if err := c.SwitchCodex(&plain); err != nil {
+		t.Fatalf("SwitchCodex(plain): %v", err)
+	}
+	data, _ = os.ReadFile(configPath)
+	content = string(data)
+	for _, key := range []string{"approval_policy", "model_context_window"} {
+		if _, ok := readTomlKey(content, key); ok {
+			t.Fatalf("%s should be removed when the next config doesn't set it:\n%s", key, content)
+		}
+	}
+	if got, _ := readTomlKey(content, "sandbox_mode"); got != "read-only" {
+		t.Fatalf("sandbox_mode = %q, want %q", got, "read-only")
+	}
+}
+
+func TestSwitchReplacesOnlySwitcherOwnedMCPServers(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	if err := os.MkdirAll(filepath.Join(dir, ".codex"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	codexPath := filepath.Join(dir, ".codex", "config.toml")
+	if err := os.WriteFile(codexPath, []byte("model = \"x\"\n\n[mcp_servers.user]\ncommand = \"mine\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	statePath := filepath.Join(dir, ".claude.json")
+	if err := os.WriteFile(statePath, []byte(`{"numStartups": 3, "mcpServers": {"user": {"type": "stdio", "command": "mine"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	gatewayA := ServiceConfig{Name: "a", Provider: "switcher", BaseURL: "https://a", APIKey: "k", MCPServers: []MCPServer{
+		{Name: "docs", Command: "npx", Args: []string{"-y", "docs-mcp"}, Env: map[string]string{"TOKEN": "t"}},
+	}}
+	gatewayB := ServiceConfig{Name: "b", Provider: "switcher", BaseURL: "https://b", APIKey: "k", MCPServers: []MCPServer{
+		{Name: "search", URL: "https://mcp.internal/search"},
+	}}
+
+	for _, sc := range []*ServiceConfig{&gatewayA, &gatewayB} {
+		if err := c.SwitchCodex(sc); err != nil {
+			t.Fatalf("SwitchCodex(%s): %v", sc.Name, err)
+		}
+		if err := c.SwitchClaudeCode(sc); err != nil {
+			t.Fatalf("SwitchClaudeCode(%s): %v", sc.Name, err)
+		}
+	}
+
+	data, _ := os.ReadFile(codexPath)
+	toml := string(data)
+	if strings.Contains(toml, "[mcp_servers.docs]") {
+		t.Fatalf("previous config's MCP server should be removed:\n%s", toml)
+	}
+	for _, want := range []string{"[mcp_servers.user]", "[mcp_servers.search]", `url = "https://mcp.internal/search"`} {
+		if !strings.Contains(toml, want) {
+			t.Fatalf("config.toml missing %q:\n%s", want, toml)
+		}
+	}
+
+	data, _ = os.ReadFile(statePath)
+	var state map[string]interface{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatal(err)
+	}
+	servers := state["mcpServers"].(map[string]interface{})
+	if _, ok := servers["docs"]; ok {
+		t.Fatalf("previous Claude MCP server should be removed: %v", servers)
+	}
+	if _, ok := servers["user"]; !ok {
+		t.Fatalf("user-added Claude MCP server must be kept: %v", servers)
+	}
+	if _, ok := servers["search"]; !ok {
+		t.Fatalf("new Claude MCP server missing: %v", servers)
+	}
+	if state["numStartups"] != float64(3) {
+		t.Fatalf("unrelated Claude state was lost: %v", state)
+	}
+}
+
+func TestSwitchRefusesToOverwriteUserMCPServer(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	if err := os.MkdirAll(filepath.Join(dir, ".codex"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	codexPath := filepath.Join(dir, ".codex", "config.toml")
+	initialToml := "model = \"x\"\n\n[mcp_servers.docs]\ncommand = \"mine\"\n"
+	if err := os.WriteFile(codexPath, []byte(initialToml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	statePath := filepath.Join(dir, ".claude.json")
+	initialState := `{"mcpServers": {"docs": {"type": "stdio", "command": "mine"}}}`
+	if err := os.WriteFile(statePath, []byte(initialState), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 配置中的 docs 与用户手动添加的服务器同名：拒绝切换，用户的定义保持不变
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	sc := ServiceConfig{Name: "a", Provider: "switcher", BaseURL: "https://a", APIKey: "k", MCPServers: []MCPServer{
+		{Name: "docs", URL: "https://mcp.internal/docs"},
+	}}
+	if err := c.SwitchCodex(&sc); err == nil || !strings.Contains(err.Error(), `"docs"`) {
+		t.Fatalf("SwitchCodex should refuse the colliding server, got %v", err)
+	}
+	if data, _ := os.ReadFile(codexPath); string(data) != initialToml {
+		t.Fatalf("config.toml must not change:\n%s", data)
+	}
+	if err := c.SwitchClaudeCode(&sc); err == nil || !strings.Contains(err.Error(), `"docs"`) {
+		t.Fatalf("SwitchClaudeCode should refuse the colliding server, got %v", err)
+	}
+	if data, _ := os.ReadFile(statePath); string(data) != initialState {
+		t.Fatalf("~/.claude.json must not change:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".claude", "settings.json")); !os.IsNotExist(err) {
+		t.Fatalf("settings.json must not be written when the switch is refused: %v", err)
+	}
+	if len(c.Managed.CodexMCPServers) != 0 || len(c.Managed.ClaudeMCPServers) != 0 {
+		t.Fatalf("nothing should be tracked: %+v", c.Managed)
+	}
+}
+
+func TestSwitchCodexBuiltinOpenAIProvider(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	shell := useFakeShellManager(t)
+	configPath := filepath.Join(dir, ".codex", "config.toml")
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	c.Codex = []ServiceConfig{
+		{Name: "stock", Provider: "switcher", ProviderMode: CodexProviderModeOpenAI, BaseURL: "https://proxy.example.com/v1", APIKey: "k1"},
+		{Name: "custom", Provider: "switcher", ProviderMode: CodexProviderModeCustom, BaseURL: "https://custom.example.com", APIKey: "k2"},
+	}
+
+	c.Active.Codex = 0
+	if err := c.SwitchCodex(&c.Codex[0]); err != nil {
+		t.Fatalf("SwitchCodex(stock): %v", err)
+	}
+	data, _ := os.ReadFile(configPath)
+	content := string(data)
+	if got, _ := readTomlKey(content, "model_provider"); got != "openai" {
+		t.Fatalf("model_provider = %q, want %q", got, "openai")
+	}
+	if strings.Contains(content, "[model_providers.") {
+		t.Fatalf("built-in provider mode must not write a provider section:\n%s", content)
+	}
+	if got := shell.vars["OPENAI_BASE_URL"]; got != "https://proxy.example.com/v1" {
+		t.Fatalf("OPENAI_BASE_URL = %q", got)
+	}
+	// 当前 shell 还没有加载 switcher 设置的 OPENAI_BASE_URL 时，Codex 看不到新地址
+	t.Setenv("OPENAI_BASE_URL", "")
+	if ok, _, _ := checkAppliedCodexLocal(c); ok {
+		t.Fatalf("drift check should report a shell that has not loaded OPENAI_BASE_URL")
+	}
+	if report, err := c.CodexDrift(); err != nil || len(report.diffs) != 1 || report.diffs[0].key != "OPENAI_BASE_URL (current shell)" {
+		t.Fatalf("CodexDrift = %+v (err=%v)", report, err)
+	}
+	t.Setenv("OPENAI_BASE_URL", "https://proxy.example.com/v1")
+	if ok, actual, err := checkAppliedCodexLocal(c); !ok || err != nil {
+		t.Fatalf("drift check after built-in switch: ok=%v actual=%q err=%v", ok, actual, err)
+	}
+
+	// 切回自定义 provider 时 OPENAI_BASE_URL 不应残留
+	c.Active.Codex = 1
+	if err := c.SwitchCodex(&c.Codex[1]); err != nil {
+		t.Fatalf("SwitchCodex(custom): %v", err)
+	}
+	if _, ok := shell.vars["OPENAI_BASE_URL"]; ok {
+		t.Fatalf("OPENAI_BASE_URL should be removed after switching to a custom provider")
+	}
+	if ok, actual, err := checkAppliedCodexLocal(c); !ok || err != nil {
+		t.Fatalf("drift check after custom switch: ok=%v actual=%q err=%v", ok, actual, err)
+	}
+
+	// 选中的配置与实际生效的不一致时，报告实际生效的地址
+	c.Active.Codex = 0
+	ok, actual, _ := checkAppliedCodexLocal(c)
+	if ok || actual != "https://custom.example.com" {
+		t.Fatalf("drift check = (%v, %q), want mismatch reporting the custom base URL", ok, actual)
+	}
+}
+
+func TestSwitchClaudeCodeRemovesOnlyOwnedExtraEnv(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	settingsPath := filepath.Join(dir, ".claude", "settings.json")
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// 用户手动添加的 MY_FLAG 与配置 a 中的 key 同名：切走时恢复为用户的原值，而不是被删除
+	if err := os.WriteFile(settingsPath, []byte(`{"env": {"MY_FLAG": "user"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	a := ServiceConfig{Name: "a", BaseURL: "https://a", APIKey: "k", ExtraEnv: map[string]string{"API_TIMEOUT_MS": "600000", "MY_FLAG": "a"}}
+	b := ServiceConfig{Name: "b", BaseURL: "https://b", APIKey: "k", ExtraEnv: map[string]string{"CLAUDE_CODE_MAX_OUTPUT_TOKENS": "64000"}}
+	for _, sc := range []*ServiceConfig{&a, &b} {
+		if err := c.SwitchClaudeCode(sc); err != nil {
+			t.Fatalf("SwitchClaudeCode(%s): %v", sc.Name, err)
+		}
+	}
+
+	data, _ := os.ReadFile(settingsPath)
+	var settings ClaudeSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := settings.Env["API_TIMEOUT_MS"]; ok {
+		t.Fatalf("previous config's extra env should be removed: %v", settings.Env)
+	}
+	if got := settings.Env["MY_FLAG"]; got != "user" {
+		t.Fatalf("MY_FLAG = %q, want the user's value %q restored", got, "user")
+	}
+	if got := settings.Env["CLAUDE_CODE_MAX_OUTPUT_TOKENS"]; got != "64000" {
+		t.Fatalf("CLAUDE_CODE_MAX_OUTPUT_TOKENS = %q", got)
+	}
+	if got := c.Managed.ClaudeEnvKeys[settingsPath]; len(got) != 1 || got[0] != "CLAUDE_CODE_MAX_OUTPUT_TOKENS" {
+		t.Fatalf("tracked keys = %v", got)
+	}
+
+	bad := ServiceConfig{Name: "bad", BaseURL: "https://c", APIKey: "k", ExtraEnv: map[string]string{"ANTHROPIC_BASE_URL": "x"}}
+	if err := c.SwitchClaudeCode(&bad); err == nil {
+		t.Fatalf("extra env must not override keys switcher manages")
+	}
+}
+
+func TestSwitchClaudeCodeRestoresUserEnvOverriddenByExtraEnv(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	settingsPath := filepath.Join(dir, ".claude", "settings.json")
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(settingsPath, []byte(`{"env": {"API_TIMEOUT_MS": "1000"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	timeout := func() string {
+		t.Helper()
+		data, err := os.ReadFile(settingsPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var settings ClaudeSettings
+		if err := json.Unmarshal(data, &settings); err != nil {
+			t.Fatal(err)
+		}
+		return settings.Env["API_TIMEOUT_MS"]
+	}
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	a := ServiceConfig{Name: "a", BaseURL: "https://a", APIKey: "k", ExtraEnv: map[string]string{"API_TIMEOUT_MS": "5000"}}
+	b := ServiceConfig{Name: "b", BaseURL: "https://b", APIKey: "k"}
+	if err := c.SwitchClaudeCode(&a); err != nil {
+		t.Fatalf("SwitchClaudeCode(a): %v", err)
+	}
+	if got := timeout(); got != "5000" {
+		t.Fatalf("after a: API_TIMEOUT_MS = %q, want 5000", got)
+	}
+	if err := c.SwitchClaudeCode(&b); err != nil {
+		t.Fatalf("SwitchClaudeCode(b): %v", err)
+	}
+	if got := timeout(); got != "1000" {
+		t.Fatalf("after a -> b: API_TIMEOUT_MS = %q, want the user's 1000 back", got)
+	}
+	if len(c.Managed.ClaudeEnvKeys[settingsPath]) != 0 || len(c.Managed.ClaudeEnvOverrides[settingsPath]) != 0 {
+		t.Fatalf("nothing should be tracked after switching to b: %+v", c.Managed)
+	}
+
+	// 连续两个配置都覆盖同一个 key：记录的仍是用户的原值
+	a2 := ServiceConfig{Name: "a2", BaseURL: "https://a2", APIKey: "k", ExtraEnv: map[string]string{"API_TIMEOUT_MS": "9000"}}
+	for _, sc := range []*ServiceConfig{&a, &a2, &b} {
+		if err := c.SwitchClaudeCode(sc); err != nil {
+			t.Fatalf("SwitchClaudeCode(%s): %v", sc.Name, err)
+		}
+	}
+	if got := timeout(); got != "1000" {
+		t.Fatalf("after a -> a2 -> b: API_TIMEOUT_MS = %q, want 1000", got)
+	}
+}
+
+func TestSwitchClaudeCodeInProjectScope(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	project := filepath.Join(dir, "project")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(project)
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	c.ClaudeCode = []ServiceConfig{{Name: "team", BaseURL: "https://team.example.com", APIKey: "k"}}
+	if err := c.SwitchClaudeCodeInScope(&c.ClaudeCode[0], ClaudeScopeProjectLocal); err != nil {
+		t.Fatalf("SwitchClaudeCodeInScope: %v", err)
+	}
+	if err := c.SetActiveClaudeCodeInScope(0, ClaudeScopeProjectLocal); err != nil {
+		t.Fatalf("SetActiveClaudeCodeInScope: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(project, ".claude", "settings.local.json"))
+	if err != nil {
+		t.Fatalf("project-local settings not written: %v", err)
+	}
+	var settings ClaudeSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		t.Fatal(err)
+	}
+	if settings.Env["ANTHROPIC_BASE_URL"] != "https://team.example.com" {
+		t.Fatalf("project env = %v", settings.Env)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".claude", "settings.json")); !os.IsNotExist(err) {
+		t.Fatalf("user settings must not be touched by a project switch")
+	}
+
+	if got := c.ActiveClaudeCodeIndexInScope(ClaudeScopeProjectLocal); got != 0 {
+		t.Fatalf("project-local active index = %d, want 0", got)
+	}
+	if got := c.ActiveClaudeCodeIndexInScope(ClaudeScopeProject); got != -1 {
+		t.Fatalf("project scope should have no active config, got %d", got)
+	}
+	if c.Active.ClaudeCode != -1 {
+		t.Fatalf("user scope active index changed to %d", c.Active.ClaudeCode)
+	}
+}
+
+func TestSwitchClaudeCodeAuthModes(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	settingsPath := filepath.Join(dir, ".claude", "settings.json")
+	readSettings := func() ClaudeSettings {
+		t.Helper()
+		data, err := os.ReadFile(settingsPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var se ClaudeSettings
+		if err := json.Unmarshal(data, &se); err != nil {
+			t.Fatal(err)
+		}
+		return se
+	}
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	c.ClaudeCode = []ServiceConfig{
+		{Name: "token", BaseURL: "https://a", APIKey: "t1"},
+		{Name: "key", BaseURL: "https://b", APIKey: "k1", ClaudeAuthMode: ClaudeAuthAPIKey},
+		{Name: "helper", BaseURL: "https://c", ClaudeAuthMode: ClaudeAuthHelper, APIKeyHelper: "~/bin/get-token.sh"},
+	}
+
+	c.Active.ClaudeCode = 1
+	if err := c.SwitchClaudeCode(&c.ClaudeCode[1]); err != nil {
+		t.Fatalf("SwitchClaudeCode(key): %v", err)
+	}
+	se := readSettings()
+	if se.Env["ANTHROPIC_API_KEY"] != "k1" || se.Env["ANTHROPIC_AUTH_TOKEN"] != "" {
+		t.Fatalf("api_key mode env = %v", se.Env)
+	}
+	if ok, _, _ := checkAppliedClaudeLocal(c); !ok {
+		t.Fatalf("drift check should pass for api_key mode")
+	}
+
+	c.Active.ClaudeCode = 2
+	if err := c.SwitchClaudeCode(&c.ClaudeCode[2]); err != nil {
+		t.Fatalf("SwitchClaudeCode(helper): %v", err)
+	}
+	se = readSettings()
+	if se.APIKeyHelper != "~/bin/get-token.sh" {
+		t.Fatalf("apiKeyHelper = %q", se.APIKeyHelper)
+	}
+	if _, ok := se.Env["ANTHROPIC_API_KEY"]; ok {
+		t.Fatalf("ANTHROPIC_API_KEY should be removed in helper mode: %v", se.Env)
+	}
+	if ok, _, _ := checkAppliedClaudeLocal(c); !ok {
+		t.Fatalf("drift check should pass for helper mode")
+	}
+
+	c.Active.ClaudeCode = 0
+	if ok, _, _ := checkAppliedClaudeLocal(c); ok {
+		t.Fatalf("drift check should fail before switching to the token config")
+	}
+	if err := c.SwitchClaudeCode(&c.ClaudeCode[0]); err != nil {
+		t.Fatalf("SwitchClaudeCode(token): %v", err)
+	}
+	se = readSettings()
+	if se.APIKeyHelper != "" || se.Env["ANTHROPIC_AUTH_TOKEN"] != "t1" {
+		t.Fatalf("token mode settings: helper=%q env=%v", se.APIKeyHelper, se.Env)
+	}
+}
+
+func TestSwitchClaudeCodeMergesPermissionPreset(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	settingsPath := filepath.Join(dir, ".claude", "settings.json")
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// 用户自己的 Read 规则与预设重叠，不应在切走时被删除
+	if err := os.WriteFile(settingsPath, []byte(`{"permissions": {"allow": ["Read", "Bash(make:*)"], "defaultMode": "plan"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{
+		Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1},
+		PermissionPresets: []PermissionPreset{
+			{Name: "strict", Allow: []string{"Read", "Grep"}, Deny: []string{"WebFetch"}},
+		},
+	}
+	a := ServiceConfig{Name: "a", BaseURL: "https://a", APIKey: "k", PermissionPreset: "strict"}
+	if err := c.SwitchClaudeCode(&a); err != nil {
+		t.Fatal(err)
+	}
+	owned := c.Managed.ClaudePermissions[settingsPath]
+	if len(owned.Allow) != 1 || owned.Allow[0] != "Grep" || len(owned.Deny) != 1 {
+		t.Fatalf("owned rules = %+v", owned)
+	}
+
+	b := ServiceConfig{Name: "b", BaseURL: "https://b", APIKey: "k"}
+	if err := c.SwitchClaudeCode(&b); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(settingsPath)
+	var settings map[string]interface{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		t.Fatal(err)
+	}
+	permissions := settings["permissions"].(map[string]interface{})
+	if got := toStringSlice(permissions["allow"]); len(got) != 2 || got[0] != "Read" || got[1] != "Bash(make:*)" {
+		t.Fatalf("allow = %v, want only the user's rules", got)
+	}
+	if got := toStringSlice(permissions["deny"]); len(got) != 0 {
+		t.Fatalf("deny = %v, want preset rules removed", got)
+	}
+	if permissions["defaultMode"] != "plan" {
+		t.Fatalf("defaultMode should be kept: %v", permissions)
+	}
+	if _, ok := c.Managed.ClaudePermissions[settingsPath]; ok {
+		t.Fatalf("tracking should be cleared once no rules are owned")
+	}
+
+	bad := ServiceConfig{Name: "bad", BaseURL: "https://c", APIKey: "k", PermissionPreset: "missing"}
+	if err := c.SwitchClaudeCode(&bad); err == nil {
+		t.Fatalf("unknown preset should be rejected")
+	}
+}
+
+func TestSwitchClaudeCodePrivacyFlags(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	settingsPath := filepath.Join(dir, ".claude", "settings.json")
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(settingsPath, []byte(`{"env": {"DO_NOT_TRACK": "user", "DISABLE_TELEMETRY": "1"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{
+		Active:                ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1},
+		ClaudePrivacyDefaults: map[string]string{"disable_telemetry": PrivacyOff},
+	}
+	sc := ServiceConfig{Name: "a", BaseURL: "https://a", APIKey: "k", DoNotTrack: PrivacyUnmanaged}
+	if err := c.SwitchClaudeCode(&sc); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(settingsPath)
+	var settings ClaudeSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		t.Fatal(err)
+	}
+	if got := settings.Env["DO_NOT_TRACK"]; got != "user" {
+		t.Fatalf("unmanaged DO_NOT_TRACK = %q, want user value kept", got)
+	}
+	if _, ok := settings.Env["DISABLE_TELEMETRY"]; ok {
+		t.Fatalf("DISABLE_TELEMETRY should be removed by the global off default: %v", settings.Env)
+	}
+	if got := settings.Env["CLAUDE_CODE_DISABLE_NONESSENTIAL_TRAFFIC"]; got != "1" {
+		t.Fatalf("unset flags should default to on, got %q", got)
+	}
+
+	sc.MaintainProjectWorkingDir = "sometimes"
+	if err := c.SwitchClaudeCode(&sc); err == nil {
+		t.Fatalf("invalid privacy value should be rejected")
+	}
+}
+
+func TestSwitchClaudeCodeMainAndSubagentModels(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	settingsPath := filepath.Join(dir, ".claude", "settings.json")
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	c.ClaudeCode = []ServiceConfig{
+		{Name: "a", BaseURL: "https://a", APIKey: "k", ClaudeMainModel: "glm-4.6", ClaudeSmallFastModel: "glm-4.5-air", ClaudeSubagentModel: "glm-4.6"},
+		{Name: "b", BaseURL: "https://b", APIKey: "k"},
+	}
+	if err := c.SwitchClaudeCode(&c.ClaudeCode[0]); err != nil {
+		t.Fatal(err)
+	}
+	c.Active.ClaudeCode = 0
+	if ok, _, err := checkAppliedClaudeLocal(c); err != nil || !ok {
+		t.Fatalf("freshly applied config should not drift (err=%v)", err)
+	}
+
+	if err := c.SwitchClaudeCode(&c.ClaudeCode[1]); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(settingsPath)
+	var settings ClaudeSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"ANTHROPIC_MODEL", "ANTHROPIC_SMALL_FAST_MODEL", "CLAUDE_CODE_SUBAGENT_MODEL"} {
+		if _, ok := settings.Env[key]; ok {
+			t.Fatalf("%s should be removed when the new config does not set it", key)
+		}
+	}
+
+	// 只有模型设置被手动改掉时也应报告漂移
+	if err := c.SwitchClaudeCode(&c.ClaudeCode[0]); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(settingsPath)
+	edited := strings.Replace(string(data), `"glm-4.5-air"`, `"other"`, 1)
+	if err := os.WriteFile(settingsPath, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _, _ := checkAppliedClaudeLocal(c); ok {
+		t.Fatalf("changed model settings should be reported as drift")
+	}
+}
+
+func TestImportClaudeCodeCapturesSettingsAndDedupes(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	settingsPath := filepath.Join(dir, ".claude", "settings.json")
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	settings := `{
+  "env": {
+    "ANTHROPIC_BASE_URL": "https://gateway.example.com/anthropic",
+    "ANTHROPIC_API_KEY": "sk-1",
+    "ANTHROPIC_EFFORT_LEVEL": "high",
+    "ANTHROPIC_MODEL": "glm-4.6",
+    "CLAUDE_AUTOCOMPACT_PCT_OVERRIDE": "80",
+    "HTTPS_PROXY": "http://127.0.0.1:7890",
+    "DISABLE_TELEMETRY": "1",
+    "DO_NOT_TRACK": "yes",
+    "API_TIMEOUT_MS": "600000"
+  },
+  "permissions": {"allow": ["Read"], "deny": []}
+}`
+	if err := os.WriteFile(settingsPath, []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	results, err := c.ImportClaudeCode([]string{ClaudeScopeUser})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Existing || results[0].Name != "gateway.example.com" {
+		t.Fatalf("results = %+v", results)
+	}
+	sc := c.ClaudeCode[0]
+	if sc.ClaudeAuthMode != ClaudeAuthAPIKey || sc.APIKey != "sk-1" || sc.EffortLevel != "high" ||
+		sc.ClaudeMainModel != "glm-4.6" || sc.AutocompactPctOverride != "80" || sc.HTTPSProxy != "http://127.0.0.1:7890" {
+		t.Fatalf("imported config = %+v", sc)
+	}
+	if sc.DisableTelemetry != PrivacyOn || sc.DoNotTrack != PrivacyUnmanaged || sc.DisableNonessentialTraffic != PrivacyOff {
+		t.Fatalf("privacy flags = %q %q %q", sc.DisableTelemetry, sc.DoNotTrack, sc.DisableNonessentialTraffic)
+	}
+	if sc.ExtraEnv["API_TIMEOUT_MS"] != "600000" || len(sc.ExtraEnv) != 1 {
+		t.Fatalf("extra env = %v", sc.ExtraEnv)
+	}
+	if p := c.FindPermissionPreset(sc.PermissionPreset); p == nil || len(p.Allow) != 1 {
+		t.Fatalf("permission rules should be imported as a preset, got %q", sc.PermissionPreset)
+	}
+	if c.Active.ClaudeCode != 0 {
+		t.Fatalf("imported user settings should become active")
+	}
+
+	results, err = c.ImportClaudeCode([]string{ClaudeScopeUser})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !results[0].Existing || len(c.ClaudeCode) != 1 {
+		t.Fatalf("second import should match the existing config: %+v", results)
+	}
+}
+
+func TestPlatformPathsHonorToolHomeOverrides(t *testing.T) {
+	home := t.TempDir()
+	p := &linuxPaths{home: home}
+	if got := p.GetClaudeConfigDir(); got != filepath.Join(home, ".claude") {
+		t.Fatalf("default Claude dir = %s", got)
+	}
+
+	claudeDir := filepath.Join(home, "work-claude")
+	codexDir := filepath.Join(home, "work-codex")
+	t.Setenv("CLAUDE_CONFIG_DIR", claudeDir)
+	t.Setenv("CODEX_HOME", codexDir)
+	if got := p.GetClaudeConfigDir(); got != claudeDir {
+		t.Fatalf("Claude dir = %s, want %s", got, claudeDir)
+	}
+	if got := p.GetClaudeStatePath(); got != filepath.Join(claudeDir, ".claude.json") {
+		t.Fatalf("Claude state path = %s", got)
+	}
+	if got := p.GetCodexConfigDir(); got != codexDir {
+		t.Fatalf("Codex dir = %s, want %s", got, codexDir)
+	}
+}
+
+func TestSwitchClaudeCodeWritesToTargetDir(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	target := filepath.Join(dir, "accounts", "work")
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	sc := ServiceConfig{
+		Name: "work", BaseURL: "https://work", APIKey: "k", TargetDir: target,
+		MCPServers: []MCPServer{{Name: "docs", Command: "npx"}},
+	}
+	if err := c.SwitchClaudeCode(&sc); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(target, "settings.json")); err != nil {
+		t.Fatalf("settings.json should be written to the target dir: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".claude", "settings.json")); !os.IsNotExist(err) {
+		t.Fatalf("default settings.json should not be touched (err=%v)", err)
+	}
+	statePath := filepath.Join(target, ".claude.json")
+	if got := c.Managed.ClaudeTargetMCPServers[statePath]; len(got) != 1 || got[0] != "docs" {
+		t.Fatalf("target MCP servers = %v", got)
+	}
+	if len(c.Managed.ClaudeMCPServers) != 0 {
+		t.Fatalf("default MCP tracking should be untouched: %v", c.Managed.ClaudeMCPServers)
+	}
+}
+
+func TestSwitchClaudeCodeBedrockAndBack(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	settingsPath := filepath.Join(dir, ".claude", "settings.json")
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	bedrock := ServiceConfig{Name: "bedrock", ClaudeProviderType: ClaudeProviderBedrock, CloudRegion: "us-east-1", AWSProfile: "work"}
+	if err := c.SwitchClaudeCode(&bedrock); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(settingsPath)
+	var settings ClaudeSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		t.Fatal(err)
+	}
+	if settings.Env["CLAUDE_CODE_USE_BEDROCK"] != "1" || settings.Env["AWS_REGION"] != "us-east-1" || settings.Env["AWS_PROFILE"] != "work" {
+		t.Fatalf("bedrock env = %v", settings.Env)
+	}
+	for _, key := range []string{"ANTHROPIC_BASE_URL", "ANTHROPIC_AUTH_TOKEN"} {
+		if _, ok := settings.Env[key]; ok {
+			t.Fatalf("%s should not be written for bedrock", key)
+		}
+	}
+
+	anthropic := ServiceConfig{Name: "gw", BaseURL: "https://gw", APIKey: "k"}
+	if err := c.SwitchClaudeCode(&anthropic); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(settingsPath)
+	settings = ClaudeSettings{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"CLAUDE_CODE_USE_BEDROCK", "AWS_REGION", "AWS_PROFILE"} {
+		if _, ok := settings.Env[key]; ok {
+			t.Fatalf("%s should be cleared when switching back to an Anthropic endpoint", key)
+		}
+	}
+
+	vertex := ServiceConfig{Name: "vertex", ClaudeProviderType: ClaudeProviderVertex, CloudRegion: "us-east5"}
+	if err := c.SwitchClaudeCode(&vertex); err == nil {
+		t.Fatalf("vertex without a project ID should be rejected")
+	}
+}
+
+func TestClaudeDriftReportsAndAdoptsHandEdits(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	settingsPath := filepath.Join(dir, ".claude", "settings.json")
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: 0, Codex: -1, Droid: -1}}
+	c.ClaudeCode = []ServiceConfig{
+		{Name: "a", Provider: "switcher", BaseURL: "https://a", APIKey: "k", ClaudeMainModel: "m1", ExtraEnv: map[string]string{"FOO": "1"}},
+	}
+	if err := c.SwitchClaudeCode(&c.ClaudeCode[0]); err != nil {
+		t.Fatal(err)
+	}
+	report, err := c.ClaudeDrift()
+	if err != nil || report == nil || len(report.diffs) != 0 {
+		t.Fatalf("freshly applied config should have no diffs: %+v (err=%v)", report, err)
+	}
+
+	data, _ := os.ReadFile(settingsPath)
+	edited := strings.NewReplacer(`"https://a"`, `"https://b"`, `"m1"`, `"m2"`, `"FOO": "1"`, `"FOO": "2"`).Replace(string(data))
+	if err := os.WriteFile(settingsPath, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	report, err = c.ClaudeDrift()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, d := range report.diffs {
+		got[d.key] = d.stored + "→" + d.actual
+	}
+	want := map[string]string{"ANTHROPIC_BASE_URL": "https://a→https://b", "ANTHROPIC_MODEL": "m1→m2", "FOO": "1→2"}
+	if !maps.Equal(got, want) {
+		t.Fatalf("diffs = %v, want %v", got, want)
+	}
+
+	if _, err := c.AdoptDrift(report, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.ClaudeCode) != 2 || c.ClaudeCode[1].Name != "a-2" || c.Active.ClaudeCode != 1 || c.ClaudeCode[0].BaseURL != "https://a" {
+		t.Fatalf("adopting as new should keep the original config: %+v", c.ClaudeCode)
+	}
+	adopted := c.ClaudeCode[1]
+	if adopted.BaseURL != "https://b" || adopted.ClaudeMainModel != "m2" || adopted.ExtraEnv["FOO"] != "2" || adopted.APIKey != "k" {
+		t.Fatalf("adopted config = %+v", adopted)
+	}
+	if report, _ := c.ClaudeDrift(); report == nil || len(report.diffs) != 0 {
+		t.Fatalf("adopted config should match the file: %+v", report)
+	}
+}
+
+func TestSwitchDroidMergesCustomModels(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	configPath := filepath.Join(dir, ".factory", "config.json")
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	existing := `{"custom_models": [
+  {"model_display_name": "mine", "model": "m", "base_url": "https://mine", "api_key": "k", "provider": "anthropic", "max_tokens": 8192},
+  {"model_display_name": "old", "model": "o", "base_url": "https://old", "api_key": "k", "provider": "switcher"}
+], "other": true}`
+	if err := os.WriteFile(configPath, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	readNames := func() []string {
+		data, _ := os.ReadFile(configPath)
+		var fc struct {
+			CustomModels []map[string]interface{} `json:"custom_models"`
+			Other        bool                     `json:"other"`
+		}
+		if err := json.Unmarshal(data, &fc); err != nil {
+			t.Fatal(err)
+		}
+		if !fc.Other {
+			t.Fatalf("other top-level settings should be preserved")
+		}
+		var names []string
+		for _, m := range fc.CustomModels {
+			names = append(names, m["model_display_name"].(string))
+			if m["model_display_name"] == "mine" && m["max_tokens"] != float64(8192) {
+				t.Fatalf("foreign model fields should be preserved: %v", m)
+			}
+		}
+		return names
+	}
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	c.Droid = []DroidConfig{
+		{ModelDisplayName: "a", Model: "ma", BaseURL: "https://a", APIKey: "k"},
+		{ModelDisplayName: "b", Model: "mb", BaseURL: "https://b", APIKey: "k"},
+	}
+	if err := c.SwitchDroid(&c.Droid[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got := readNames(); !slices.Equal(got, []string{"mine", "a"}) {
+		t.Fatalf("custom_models = %v, want [mine a]", got)
+	}
+	if err := c.SwitchDroid(&c.Droid[1]); err != nil {
+		t.Fatal(err)
+	}
+	if got := readNames(); !slices.Equal(got, []string{"mine", "b"}) {
+		t.Fatalf("custom_models = %v, want [mine b]", got)
+	}
+
+	c.Active.Droid = 1
+	if err := c.ToggleDroidPublishAll(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readNames(); !slices.Equal(got, []string{"mine", "b", "a"}) {
+		t.Fatalf("custom_models = %v, want [mine b a]", got)
+	}
+}
+
+func TestSwitchDroidKeepsUserModelWithSameName(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	configPath := filepath.Join(dir, ".factory", "config.json")
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	existing := `{"custom_models": [{"model_display_name": "gw", "model": "m", "base_url": "https://mine", "api_key": "k", "provider": "anthropic", "max_tokens": 8192}]}`
+	if err := os.WriteFile(configPath, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	c.Droid = []DroidConfig{
+		{ModelDisplayName: "gw", Model: "other", BaseURL: "https://gw", APIKey: "k2", Provider: DroidProviderGeneric},
+		{ModelDisplayName: "gw", Model: "m", BaseURL: "https://mine", APIKey: "k", Provider: DroidProviderAnthropic, MaxTokens: 8192},
+	}
+	if err := c.SwitchDroid(&c.Droid[0]); err == nil {
+		t.Fatalf("switching must not overwrite a user-defined model with the same name")
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != existing {
+		t.Fatalf("refused switch modified config.json:\n%s", data)
+	}
+
+	// 与用户模型相同的配置（例如导入的配置）直接使用该模型，不记为 switcher 所有
+	if err := c.SwitchDroid(&c.Droid[1]); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Managed.DroidModels) != 0 {
+		t.Fatalf("user-defined model recorded as switcher-owned: %v", c.Managed.DroidModels)
+	}
+	data, _ := os.ReadFile(configPath)
+	var fc FactoryConfig
+	if err := json.Unmarshal(data, &fc); err != nil {
+		t.Fatal(err)
+	}
+	if len(fc.CustomModels) != 1 || fc.CustomModels[0].BaseURL != "https://mine" {
+		t.Fatalf("custom_models = %+v", fc.CustomModels)
+	}
+}
+
+func TestLoadMigratesLegacyDroidProvider(t *testing.T) {
+	useTempPlatformPaths(t)
+	path := platformPaths.GetAppConfigPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	legacy := `{"droid": [{"model_display_name": "gw", "model": "m", "base_url": "https://gw", "api_key": "k", "provider": "switcher"}],
+"active": {"claude_code": -1, "codex": -1, "droid": 0}}`
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{}
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Droid) != 1 || c.Droid[0].Provider != DroidProviderGeneric {
+		t.Fatalf("legacy Droid config = %+v, want provider %q", c.Droid, DroidProviderGeneric)
+	}
+	// 迁移后的配置可以直接编辑保存，不会因 provider 无效被拒绝
+	if err := c.UpdateDroidConfig(0, c.Droid[0]); err != nil {
+		t.Fatalf("UpdateDroidConfig after migration: %v", err)
+	}
+
+	reloaded := &Config{}
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Droid[0].Provider != DroidProviderGeneric {
+		t.Fatalf("migration was not saved: provider = %q", reloaded.Droid[0].Provider)
+	}
+}
+
+func TestDroidModelDefinitionRoundTrip(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	configPath := filepath.Join(dir, ".factory", "config.json")
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	if err := c.AddDroidConfig(DroidConfig{ModelDisplayName: "bad", Model: "m", BaseURL: "https://a", APIKey: "k", Provider: "switcher"}); err == nil {
+		t.Fatalf("unknown provider type should be rejected")
+	}
+	want := DroidConfig{
+		ModelDisplayName: "gw", Model: "claude-sonnet", BaseURL: "https://gw", APIKey: "k",
+		Provider: DroidProviderAnthropic, MaxTokens: 16384, ExtraHeaders: map[string]string{"X-Team": "infra"},
+	}
+	if err := c.AddDroidConfig(want); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SwitchDroid(&c.Droid[0]); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(configPath)
+	var fc FactoryConfig
+	if err := json.Unmarshal(data, &fc); err != nil {
+		t.Fatal(err)
+	}
+	if len(fc.CustomModels) != 1 {
+		t.Fatalf("custom_models = %+v", fc.CustomModels)
+	}
+	got := fc.CustomModels[0]
+	if got.Provider != want.Provider || got.MaxTokens != want.MaxTokens || got.ExtraHeaders["X-Team"] != "infra" {
+		t.Fatalf("written model = %+v, want %+v", got, want)
+	}
+
+	c.Active.Droid = 0
+	if report, err := c.DroidDrift(); err != nil || report == nil || len(report.diffs) != 0 {
+		t.Fatalf("freshly switched model should have no diffs: %+v (err=%v)", report, err)
+	}
+}
+
+func TestCheckAppliedDroidLocal(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	settingsPath := filepath.Join(dir, ".factory", "settings.json")
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: 0}}
+	c.Droid = []DroidConfig{
+		{ModelDisplayName: "a", Model: "ma", BaseURL: "https://a", APIKey: "k", Provider: DroidProviderGeneric},
+		{ModelDisplayName: "b", Model: "mb", BaseURL: "https://b", APIKey: "k", Provider: DroidProviderGeneric},
+	}
+	if err := c.SwitchDroid(&c.Droid[0]); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _, err := checkAppliedDroidLocal(c); err != nil || !ok {
+		t.Fatalf("freshly applied config should not drift (err=%v)", err)
+	}
+
+	// 在 Droid 中用 /model 选了另一个模型
+	c.DroidPublishAll = true
+	if err := c.SwitchDroid(&c.Droid[0]); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(settingsPath)
+	edited := strings.Replace(string(data), `"custom:ma"`, `"custom:mb"`, 1)
+	if err := os.WriteFile(settingsPath, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ok, actual, err := checkAppliedDroidLocal(c)
+	if err != nil || ok || actual != "https://b" {
+		t.Fatalf("checkAppliedDroidLocal = %v, %q, %v; want drift pointing at https://b", ok, actual, err)
+	}
+}
+
+func TestAdoptRenamedDroidModelKeepsOwnership(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	configPath := filepath.Join(dir, ".factory", "config.json")
+
+	readNames := func() []string {
+		data, _ := os.ReadFile(configPath)
+		var fc FactoryConfig
+		if err := json.Unmarshal(data, &fc); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, m := range fc.CustomModels {
+			names = append(names, m.ModelDisplayName)
+		}
+		return names
+	}
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: 0}}
+	c.Droid = []DroidConfig{
+		{ModelDisplayName: "a", Model: "ma", BaseURL: "https://a", APIKey: "k", Provider: DroidProviderGeneric},
+		{ModelDisplayName: "b", Model: "mb", BaseURL: "https://b", APIKey: "k", Provider: DroidProviderGeneric},
+	}
+	if err := c.SwitchDroid(&c.Droid[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	// 在 Factory 中把 switcher 写入的模型改名
+	data, _ := os.ReadFile(configPath)
+	edited := strings.Replace(string(data), `"model_display_name": "a"`, `"model_display_name": "a-renamed"`, 1)
+	if err := os.WriteFile(configPath, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	report, err := c.DroidDrift()
+	if err != nil || report == nil || report.droid == nil {
+		t.Fatalf("DroidDrift = %+v, %v", report, err)
+	}
+	if _, err := c.AdoptDrift(report, false); err != nil {
+		t.Fatal(err)
+	}
+	if c.Droid[0].ModelDisplayName != "a-renamed" || !slices.Equal(c.Managed.DroidModels, []string{"a-renamed"}) {
+		t.Fatalf("adopted config = %+v, managed = %v", c.Droid[0], c.Managed.DroidModels)
+	}
+
+	// 切走时改名后的条目仍由 switcher 移除，切回时不会被当作用户的同名模型而拒绝
+	if err := c.SwitchDroid(&c.Droid[1]); err != nil {
+		t.Fatal(err)
+	}
+	if got := readNames(); !slices.Equal(got, []string{"b"}) {
+		t.Fatalf("custom_models after switching away = %v, want [b]", got)
+	}
+	if err := c.SwitchDroid(&c.Droid[0]); err != nil {
+		t.Fatalf("switching back to the adopted config: %v", err)
+	}
+	if got := readNames(); !slices.Equal(got, []string{"a-renamed"}) {
+		t.Fatalf("custom_models after switching back = %v, want [a-renamed]", got)
+	}
+	if !slices.Equal(c.Managed.DroidModels, []string{"a-renamed"}) {
+		t.Fatalf("managed models = %v, want [a-renamed]", c.Managed.DroidModels)
+	}
+}
+
+func TestDroidDriftReportsMissingActiveModel(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	configPath := filepath.Join(dir, ".factory", "config.json")
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: 0}}
+	c.Droid = []DroidConfig{{ModelDisplayName: "a", Model: "ma", BaseURL: "https://a", APIKey: "k", Provider: DroidProviderGeneric}}
+	if err := c.SwitchDroid(&c.Droid[0]); err != nil {
+		t.Fatal(err)
+	}
+	other := `{"custom_models": [{"model_display_name": "mine", "model": "m", "base_url": "https://mine", "api_key": "k", "provider": "anthropic"}]}`
+	if err := os.WriteFile(configPath, []byte(other), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := c.DroidDrift()
+	if err != nil || report == nil {
+		t.Fatalf("DroidDrift = %+v, %v", report, err)
+	}
+	if len(report.diffs) != 1 || report.diffs[0].key != "custom_models" || report.diffs[0].stored != "a" || report.diffs[0].actual != "" {
+		t.Fatalf("diffs = %+v, want the active model reported missing", report.diffs)
+	}
+	if _, err := c.AdoptDrift(report, false); err == nil {
+		t.Fatalf("adopting a missing model should fail")
+	}
+	if c.Droid[0].BaseURL != "https://a" {
+		t.Fatalf("stored config changed: %+v", c.Droid[0])
+	}
+}
+
+func TestCheckAppliedDroidLocalAcceptsReusedUserModel(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	configPath := filepath.Join(dir, ".factory", "config.json")
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// 用户模型与配置的模型、base URL 和 API Key 相同，但 provider 和 max_tokens 不同
+	existing := `{"custom_models": [{"model_display_name": "gw", "model": "m", "base_url": "https://gw", "api_key": "k", "provider": "anthropic", "max_tokens": 8192}]}`
+	if err := os.WriteFile(configPath, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: 0}}
+	c.Droid = []DroidConfig{{ModelDisplayName: "gw", Model: "m", BaseURL: "https://gw", APIKey: "k", Provider: DroidProviderGeneric}}
+	if err := c.SwitchDroid(&c.Droid[0]); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Managed.DroidModels) != 0 {
+		t.Fatalf("user model should be reused, not owned: %v", c.Managed.DroidModels)
+	}
+	if ok, _, err := checkAppliedDroidLocal(c); err != nil || !ok {
+		t.Fatalf("reused user model should not be reported as drift (err=%v)", err)
+	}
+}
+
+func TestImportDroidImportsAllModelsAndDedupes(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	factoryDir := filepath.Join(dir, ".factory")
+	if err := os.MkdirAll(factoryDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	models := `{"custom_models": [
+  {"model_display_name": "a", "model": "ma", "base_url": "https://a", "api_key": "k", "provider": "anthropic"},
+  {"model_display_name": "b", "model": "mb", "base_url": "https://b", "api_key": "k", "provider": "openai", "max_tokens": 4096}
+]}`
+	if err := os.WriteFile(filepath.Join(factoryDir, "config.json"), []byte(models), 0644); err != nil {
+		t.Fatal(err)
+	}
+	settings := "// Factory CLI Settings\n{\n  \"model\": \"custom:mb\"\n}\n"
+	if err := os.WriteFile(filepath.Join(factoryDir, "settings.json"), []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	// 同名但内容不同的已有配置：导入的模型需要改名
+	c.Droid = []DroidConfig{{ModelDisplayName: "a", Model: "other", BaseURL: "https://x", APIKey: "k", Provider: DroidProviderGeneric}}
+	results, err := c.ImportDroid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Name != "a-2" || results[0].Existing || results[1].Name != "b" || !results[1].Active {
+		t.Fatalf("results = %+v", results)
+	}
+	if len(c.Droid) != 3 || c.Active.Droid != 2 || c.Droid[2].MaxTokens != 4096 {
+		t.Fatalf("droid configs = %+v, active = %d", c.Droid, c.Active.Droid)
+	}
+
+	results, err = c.ImportDroid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if !r.Existing {
+			t.Fatalf("second import should not add %q again", r.Name)
+		}
+	}
+	if len(c.Droid) != 3 {
+		t.Fatalf("second import added configs: %+v", c.Droid)
+	}
+}
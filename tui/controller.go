@@ -13,6 +13,29 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.windowHeight = msg.Height
 		return m, nil
 	case tea.KeyMsg:
+		// 独立的子界面自行处理按键（Ctrl+C 仍回到主菜单）
+		if msg.Type != tea.KeyCtrlC {
+			switch m.state {
+			case editClaudeEnv:
+				return m.handleEnvEditorKey(msg)
+			case permissionPresetList:
+				return m.handlePresetListKey(msg)
+			case editPermissionPreset:
+				return m.handlePresetEditorKey(msg)
+			case editPresetRules:
+				return m.handleRuleEditorKey(msg)
+			case driftView:
+				return m.handleDriftKey(msg)
+			case toolList:
+				return m.handleToolListKey(msg)
+			case editTool:
+				return m.handleToolFormKey(msg)
+			case confirmDeleteTool:
+				return m.handleToolDeleteKey(msg)
+			case confirmProjectSecrets:
+				return m.handleProjectSecretsKey(msg)
+			}
+		}
 		switch msg.Type {
 		case tea.KeyCtrlC:
 			if m.state != mainMenu {
@@ -80,7 +103,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case tea.KeyUp:
 			if m.state == addClaudeCode || m.state == editClaudeCode {
-				// Claude Code: 7个字段，在字段之间导航
+				// Claude Code: 在 ClaudeCodeFieldCount 个字段之间循环导航
 				if m.cursor > ClaudeCodeFieldCount-1 {
 					m.cursor = ClaudeCodeFieldCount - 1
 					m.formField = m.cursor
@@ -94,7 +117,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.formField = m.cursor
 				}
 			} else if m.state == addDroid || m.state == editDroid {
-				// Droid: 4个字段，在字段之间导航
+				// Droid: 在 DroidFieldCount 个字段之间循环导航
 				if m.cursor > DroidFieldCount-1 {
 					m.cursor = DroidFieldCount - 1
 					m.formField = m.cursor
@@ -109,7 +132,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 
 			} else if m.state == addCodex || m.state == editCodex {
-				// Codex: 6个字段，在字段和按钮之间导航
+				// Codex: 在 CodexFieldCount 个字段之间循环导航
 				if m.cursor > CodexFieldCount-1 {
 					m.cursor = CodexFieldCount - 1
 					m.formField = m.cursor
@@ -129,7 +152,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case tea.KeyDown:
 			if m.state == addClaudeCode || m.state == editClaudeCode {
-				// Claude Code: 7个字段，只在字段之间导航
+				// Claude Code: 只在字段之间导航
 				if m.cursor < ClaudeCodeFieldCount-1 {
 					// 在字段之间切换时，更新formField为当前位置
 					m.formField = m.cursor + 1
@@ -140,7 +163,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.formField = 0
 				}
 			} else if m.state == addDroid || m.state == editDroid {
-				// Droid: 4个字段，只在字段之间导航
+				// Droid: 只在字段之间导航
 				if m.cursor < DroidFieldCount-1 {
 					// 在字段之间切换时，更新formField为当前位置
 					m.formField = m.cursor + 1
@@ -152,7 +175,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 
 			} else if m.state == addCodex || m.state == editCodex {
-				// Codex: 6个字段，只在字段之间导航
+				// Codex: 只在字段之间导航
 				if m.cursor < CodexFieldCount-1 {
 					// 在字段之间切换时，更新formField为当前位置
 					m.formField = m.cursor + 1
@@ -191,10 +214,38 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.config.Language = GetLanguage()
 				m.config.Save()
 				m.error = t("success_lang_switch")
+			case 'p', 'P':
+				// 管理 Claude Code 权限预设；在 Droid 列表中切换是否发布全部配置
+				if m.state == claudeCodeList {
+					m.openPresetList()
+				} else if m.state == droidList {
+					if err := m.config.ToggleDroidPublishAll(); err != nil {
+						m.error = err.Error()
+					} else if m.config.DroidPublishAll {
+						m.error = t("success_droid_publish_all")
+					} else {
+						m.error = t("success_droid_publish_active")
+					}
+				}
+			case 'i', 'I':
+				// 导入 Factory 中定义的全部 Droid 自定义模型
+				if m.state == droidList {
+					m.importDroid()
+				}
+			case 'd', 'D':
+				// 查看活动配置与磁盘上实际设置的差异
+				m.openDriftView()
+			case 's', 'S':
+				// 切换 Claude Code 写入的作用域：用户 → 项目 → 项目本地
+				if m.state == claudeCodeList {
+					m.claudeScope = nextClaudeScope(m.claudeScope)
+					m.cursor = 0
+					m.error = ""
+				}
 			case 'a', 'A':
 				if m.state == claudeCodeList {
 					m.state = addClaudeCode
-					m.formData = ServiceConfig{}
+					m.formData = newClaudeFormData()
 					m.formField = 0
 					m.cursor = 0
 					m.error = ""
@@ -205,15 +256,16 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						WireAPI:              DefaultWireAPI,
 						AuthMethod:           "auth.json",
 						ModelReasoningEffort: DefaultModelReasoningEffort,
+						ProviderMode:         CodexProviderModeCustom,
 					}
 					m.formField = 0
 					m.cursor = 0
 					m.error = ""
 				} else if m.state == droidList {
 					m.state = addDroid
-					m.droidFormData = DroidConfig{}
+					m.newDroidForm()
 					m.formField = 0
-					m.cursor = 4
+					m.cursor = 0
 					m.error = ""
 				}
 			}
@@ -230,45 +282,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			} else if m.state == confirmDeleteClaudeCode || m.state == confirmDeleteCodex || m.state == confirmDeleteDroid || m.state == confirmExitAddClaudeCode || m.state == confirmExitAddCodex || m.state == confirmExitAddDroid {
 				m.cursor = 0 // 选择"确认删除"或"确认退出"
-			} else if (m.state == addCodex || m.state == editCodex) && m.formField == FieldWireAPI {
-				// Wire API字段：在chat和responses之间切换
-				if m.formData.WireAPI == DefaultWireAPI {
-					m.formData.WireAPI = "chat"
-				} else {
-					m.formData.WireAPI = DefaultWireAPI
+			} else if m.state == addCodex || m.state == editCodex || m.state == addClaudeCode || m.state == editClaudeCode {
+				// 选择字段（Wire API、认证方式、推理强度等）：在选项之间切换
+				if fields := m.serviceFormFields(); m.formField < len(fields) {
+					fields[m.formField].cycle(-1)
 				}
-			} else if (m.state == addCodex || m.state == editCodex) && m.formField == FieldAuthMethod {
-				// 认证方式字段：在auth.json和env之间切换
-				if m.formData.AuthMethod == "auth.json" {
-					m.formData.AuthMethod = "env"
-				} else {
-					m.formData.AuthMethod = "auth.json"
-				}
-			} else if (m.state == addCodex || m.state == editCodex) && m.formField == FieldModelReasoningEffort {
-				// 推理强度字段：在low、medium、high、xhigh之间切换
-				if m.formData.ModelReasoningEffort == ModelReasoningEffortLow {
-					m.formData.ModelReasoningEffort = ModelReasoningEffortMedium
-				} else if m.formData.ModelReasoningEffort == ModelReasoningEffortMedium {
-					m.formData.ModelReasoningEffort = ModelReasoningEffortHigh
-				} else if m.formData.ModelReasoningEffort == ModelReasoningEffortHigh {
-					m.formData.ModelReasoningEffort = ModelReasoningEffortXHigh
-				} else {
-					m.formData.ModelReasoningEffort = ModelReasoningEffortLow
-				}
-			} else if (m.state == addClaudeCode || m.state == editClaudeCode) && m.formField == 3 {
-				// Claude Code推理强度字段：在auto、low、medium、high、xhigh、max之间切换
-				if m.formData.EffortLevel == ModelReasoningEffortAuto {
-					m.formData.EffortLevel = ModelReasoningEffortLow
-				} else if m.formData.EffortLevel == ModelReasoningEffortLow {
-					m.formData.EffortLevel = ModelReasoningEffortMedium
-				} else if m.formData.EffortLevel == ModelReasoningEffortMedium {
-					m.formData.EffortLevel = ModelReasoningEffortHigh
-				} else if m.formData.EffortLevel == ModelReasoningEffortHigh {
-					m.formData.EffortLevel = ModelReasoningEffortXHigh
-				} else if m.formData.EffortLevel == ModelReasoningEffortXHigh {
-					m.formData.EffortLevel = ModelReasoningEffortMax
-				} else {
-					m.formData.EffortLevel = ModelReasoningEffortAuto
+			} else if m.state == addDroid || m.state == editDroid {
+				// Droid 的 provider 类型选择字段
+				if fields := m.droidFormFields(); m.formField < len(fields) {
+					fields[m.formField].cycle(-1)
 				}
 			}
 
@@ -285,51 +307,26 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			} else if m.state == confirmDeleteClaudeCode || m.state == confirmDeleteCodex || m.state == confirmDeleteDroid || m.state == confirmExitAddClaudeCode || m.state == confirmExitAddCodex || m.state == confirmExitAddDroid {
 				m.cursor = 1 // 选择"取消"
-			} else if (m.state == addCodex || m.state == editCodex) && m.formField == FieldWireAPI {
-				// Wire API字段：在chat和responses之间切换
-				if m.formData.WireAPI == DefaultWireAPI {
-					m.formData.WireAPI = "chat"
-				} else {
-					m.formData.WireAPI = DefaultWireAPI
-				}
-			} else if (m.state == addCodex || m.state == editCodex) && m.formField == FieldAuthMethod {
-				// 认证方式字段：在auth.json和env之间切换
-				if m.formData.AuthMethod == "auth.json" {
-					m.formData.AuthMethod = "env"
-				} else {
-					m.formData.AuthMethod = "auth.json"
-				}
-			} else if (m.state == addCodex || m.state == editCodex) && m.formField == FieldModelReasoningEffort {
-				// 推理强度字段：在low、medium、high、xhigh之间切换
-				if m.formData.ModelReasoningEffort == ModelReasoningEffortLow {
-					m.formData.ModelReasoningEffort = ModelReasoningEffortMedium
-				} else if m.formData.ModelReasoningEffort == ModelReasoningEffortMedium {
-					m.formData.ModelReasoningEffort = ModelReasoningEffortHigh
-				} else if m.formData.ModelReasoningEffort == ModelReasoningEffortHigh {
-					m.formData.ModelReasoningEffort = ModelReasoningEffortXHigh
-				} else {
-					m.formData.ModelReasoningEffort = ModelReasoningEffortLow
+			} else if m.state == addCodex || m.state == editCodex || m.state == addClaudeCode || m.state == editClaudeCode {
+				// 选择字段（Wire API、认证方式、推理强度等）：在选项之间切换
+				if fields := m.serviceFormFields(); m.formField < len(fields) {
+					fields[m.formField].cycle(1)
 				}
-			} else if (m.state == addClaudeCode || m.state == editClaudeCode) && m.formField == 3 {
-				// Claude Code推理强度字段：在auto、low、medium、high、xhigh、max之间切换
-				if m.formData.EffortLevel == ModelReasoningEffortAuto {
-					m.formData.EffortLevel = ModelReasoningEffortLow
-				} else if m.formData.EffortLevel == ModelReasoningEffortLow {
-					m.formData.EffortLevel = ModelReasoningEffortMedium
-				} else if m.formData.EffortLevel == ModelReasoningEffortMedium {
-					m.formData.EffortLevel = ModelReasoningEffortHigh
-				} else if m.formData.EffortLevel == ModelReasoningEffortHigh {
-					m.formData.EffortLevel = ModelReasoningEffortXHigh
-				} else if m.formData.EffortLevel == ModelReasoningEffortXHigh {
-					m.formData.EffortLevel = ModelReasoningEffortMax
-				} else {
-					m.formData.EffortLevel = ModelReasoningEffortAuto
+			} else if m.state == addDroid || m.state == editDroid {
+				// Droid 的 provider 类型选择字段
+				if fields := m.droidFormFields(); m.formField < len(fields) {
+					fields[m.formField].cycle(1)
 				}
 			}
 
 		case tea.KeyEnter:
-			// 在添加/编辑状态下，Enter直接保存
-			if m.state == addClaudeCode {
+			// 在添加/编辑状态下，Enter直接保存；光标在编辑器字段上时打开对应的编辑界面
+			if (m.state == addClaudeCode || m.state == editClaudeCode) && m.formField < len(m.claudeFormFields()) && m.claudeFormFields()[m.formField].kind == fieldEditor {
+				m.openEnvEditor()
+			} else if (m.state == addDroid || m.state == editDroid) && m.formField == DroidFieldCount-1 {
+				// 最后一个字段为额外请求头编辑器
+				m.openEnvEditor()
+			} else if m.state == addClaudeCode {
 				if m.hasRequiredServiceFields() {
 					err := m.config.AddClaudeCodeConfig(m.formData)
 					if err != nil {
@@ -372,22 +369,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.error = t("error_fill_all")
 				}
 			} else if m.state == addDroid {
-				if m.droidFormData.ModelDisplayName != "" && m.droidFormData.Model != "" && m.droidFormData.BaseURL != "" && m.droidFormData.APIKey != "" {
-					if err := m.config.AddDroidConfig(m.droidFormData); err != nil {
-						m.error = err.Error()
-					} else {
-						m.error = t("success_add_droid")
-						m.state = droidList
-						m.cursor = 0
-					}
-				} else {
-					m.error = t("error_fill_all")
-				}
+				m.saveDroidForm()
 			} else if m.state == editClaudeCode {
 				if m.hasRequiredServiceFields() {
-					m.formData.Provider = "switcher"
-					m.config.ClaudeCode[m.editIndex] = m.formData
-					err := m.config.Save()
+					err := m.config.UpdateClaudeCodeConfig(m.editIndex, m.formData)
 					if err != nil {
 						m.error = err.Error()
 					} else {
@@ -417,9 +402,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.formData.EnvKey = ""
 					}
 
-					m.formData.Provider = "switcher"
-					m.config.Codex[m.editIndex] = m.formData
-					err := m.config.Save()
+					err := m.config.UpdateCodexConfig(m.editIndex, m.formData)
 					if err != nil {
 						m.error = err.Error()
 					} else {
@@ -431,20 +414,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.error = t("error_fill_all")
 				}
 			} else if m.state == editDroid {
-				if m.droidFormData.ModelDisplayName != "" && m.droidFormData.Model != "" && m.droidFormData.BaseURL != "" && m.droidFormData.APIKey != "" {
-					m.droidFormData.Provider = "switcher"
-					m.config.Droid[m.editIndex] = m.droidFormData
-					err := m.config.Save()
-					if err != nil {
-						m.error = err.Error()
-					} else {
-						m.error = t("success_update_droid")
-						m.state = droidList
-						m.cursor = 0
-					}
-				} else {
-					m.error = t("error_fill_all")
-				}
+				m.saveDroidForm()
 			} else {
 				var cmd tea.Cmd
 				mm, cmd := m.handleSelect()
@@ -490,7 +460,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.editIndex = originalIndex
 					m.formData = m.config.Codex[m.editIndex]
 					m.state = editCodex
-					m.cursor = 0 // Codex有7个字段，从第一个字段开始
+					m.cursor = 0 // 从第一个字段开始
 					m.formField = 0
 					m.error = ""
 				}
@@ -502,29 +472,27 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						break
 					}
 					m.editIndex = originalIndex
-					m.droidFormData = m.config.Droid[m.editIndex]
+					m.setDroidForm(m.config.Droid[m.editIndex])
 					m.state = editDroid
-					m.cursor = 4
+					m.cursor = 0
 					m.formField = 0
 					m.error = ""
 				}
 			} else if m.state == addClaudeCode || m.state == editClaudeCode {
-				// Claude Code有7个字段
+				// 在 ClaudeCodeFieldCount 个字段之间循环
 				m.formField = (m.formField + 1) % ClaudeCodeFieldCount
 			} else if m.state == addCodex || m.state == editCodex {
-				// Codex有7个字段
+				// 在 CodexFieldCount 个字段之间循环
 				m.formField = (m.formField + 1) % CodexFieldCount
 			} else if m.state == addDroid || m.state == editDroid {
-				// Droid有4个字段
+				// 在 DroidFieldCount 个字段之间循环
 				m.formField = (m.formField + 1) % DroidFieldCount
 			}
 		case tea.KeyCtrlS:
 			// Ctrl+S 直接保存编辑/新增
 			if m.state == editClaudeCode {
 				if m.hasRequiredServiceFields() {
-					m.formData.Provider = "switcher"
-					m.config.ClaudeCode[m.editIndex] = m.formData
-					if err := m.config.Save(); err != nil {
+					if err := m.config.UpdateClaudeCodeConfig(m.editIndex, m.formData); err != nil {
 						m.error = err.Error()
 					} else {
 						m.error = t("success_update_claude")
@@ -565,9 +533,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.formData.EnvKey = ""
 					}
 
-					m.formData.Provider = "switcher"
-					m.config.Codex[m.editIndex] = m.formData
-					if err := m.config.Save(); err != nil {
+					if err := m.config.UpdateCodexConfig(m.editIndex, m.formData); err != nil {
 						m.error = err.Error()
 					} else {
 						m.error = t("success_update_codex")
@@ -607,31 +573,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.error = t("error_fill_all")
 				}
 			} else if m.state == editDroid {
-				if m.droidFormData.ModelDisplayName != "" && m.droidFormData.Model != "" && m.droidFormData.BaseURL != "" && m.droidFormData.APIKey != "" {
-					m.droidFormData.Provider = "switcher"
-					m.config.Droid[m.editIndex] = m.droidFormData
-					if err := m.config.Save(); err != nil {
-						m.error = err.Error()
-					} else {
-						m.error = t("success_update_droid")
-						m.state = droidList
-						m.cursor = 0
-					}
-				} else {
-					m.error = t("error_fill_all")
-				}
+				m.saveDroidForm()
 			} else if m.state == addDroid {
-				if m.droidFormData.ModelDisplayName != "" && m.droidFormData.Model != "" && m.droidFormData.BaseURL != "" && m.droidFormData.APIKey != "" {
-					if err := m.config.AddDroidConfig(m.droidFormData); err != nil {
-						m.error = err.Error()
-					} else {
-						m.error = t("success_add_droid")
-						m.state = droidList
-						m.cursor = 0
-					}
-				} else {
-					m.error = t("error_fill_all")
-				}
+				m.saveDroidForm()
 			}
 		case tea.KeyDelete:
 			// 在配置列表中，Delete键删除选中的配置
@@ -692,7 +636,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m model) getMaxCursor() int {
 	switch m.state {
 	case mainMenu:
-		return 4
+		return len(toolAdapters) + 1 // 各工具 + 切换语言 + 退出
 	case claudeCodeList:
 		return len(m.sortedClaudeCode) + 1 // 配置数量 + 新增按钮
 	case codexList:
@@ -701,11 +645,11 @@ func (m model) getMaxCursor() int {
 		return len(m.sortedDroid) + 1 // 配置数量 + 新增按钮
 	// 操作菜单已移除，保存/取消按钮已移除
 	case addClaudeCode, editClaudeCode:
-		return ClaudeCodeFieldCount - 1 // 字段 0..7
+		return ClaudeCodeFieldCount - 1 // 只有字段，没有按钮
 	case addDroid, editDroid:
-		return DroidFieldCount - 1 // 字段 0..3
+		return DroidFieldCount - 1
 	case addCodex, editCodex:
-		return CodexFieldCount - 1 // 字段 0..5
+		return CodexFieldCount - 1
 	case confirmDeleteClaudeCode, confirmDeleteCodex, confirmDeleteDroid, confirmExitAddClaudeCode, confirmExitAddCodex, confirmExitAddDroid:
 		return 1 // 0=确认操作，1=取消
 	default:
@@ -716,26 +660,16 @@ func (m model) getMaxCursor() int {
 func (m model) handleSelect() (tea.Model, tea.Cmd) {
 	switch m.state {
 	case mainMenu:
-		switch m.cursor {
-		case 0:
-			m.state = claudeCodeList
-			m.cursor = 0
-			m.sortClaudeCodeConfigs() // 进入时排序一次
-		case 1:
-			m.state = codexList
-			m.cursor = 0
-			m.sortCodexConfigs() // 进入时排序一次
-		case 2:
-			m.state = droidList
-			m.cursor = 0
-			m.sortDroidConfigs() // 进入时排序一次
-		case 3:
+		switch {
+		case m.cursor < len(toolAdapters):
+			m.openTool(toolAdapters[m.cursor])
+		case m.cursor == len(toolAdapters):
 			// 切换语言
 			ToggleLanguage()
 			m.config.Language = GetLanguage()
 			m.config.Save()
 			m.error = t("success_lang_switch")
-		case 4:
+		default:
 			return m, tea.Quit
 		}
 	case claudeCodeList:
@@ -747,7 +681,7 @@ func (m model) handleSelect() (tea.Model, tea.Cmd) {
 		} else if m.cursor == len(m.sortedClaudeCode)+1 {
 			// 新增配置
 			m.state = addClaudeCode
-			m.formData = ServiceConfig{}
+			m.formData = newClaudeFormData()
 			m.formField = 0
 			m.cursor = 0
 			m.error = ""
@@ -758,15 +692,15 @@ func (m model) handleSelect() (tea.Model, tea.Cmd) {
 				m.error = t("error_config_index")
 				break
 			}
-			config := &m.config.ClaudeCode[originalIndex]
-			if err := m.config.SwitchClaudeCode(config); err != nil {
-				m.error = fmt.Sprintf(t("error_switch_claude"), err)
-			} else if err := m.config.SetActiveClaudeCode(originalIndex); err != nil {
-				m.error = err.Error()
-			} else {
-				m.error = t("success_switch_claude")
-				m.cursor = 0
+			// 凭证会写入通常随项目提交的 settings.json 时先确认
+			if ClaudeScopeWritesSecrets(&m.config.ClaudeCode[originalIndex], m.claudeScope) {
+				m.deleteIndex = originalIndex
+				m.state = confirmProjectSecrets
+				m.cursor = 1
+				m.error = ""
+				break
 			}
+			m.switchClaudeCode(originalIndex)
 		}
 		// 操作菜单已移除
 	case codexList:
@@ -796,6 +730,10 @@ func (m model) handleSelect() (tea.Model, tea.Cmd) {
 				m.error = err.Error()
 			} else {
 				m.error = t("success_switch_codex")
+				if config.UsesShellEnv() {
+					// 环境变量只在新 shell 中生效，提示如何在当前 shell 立即加载
+					m.error += "\n" + fmt.Sprintf(t("hint_shell_activate"), ShellActivationHint())
+				}
 				m.cursor = 0
 			}
 		}
@@ -808,7 +746,7 @@ func (m model) handleSelect() (tea.Model, tea.Cmd) {
 		} else if m.cursor == len(m.sortedDroid)+1 {
 			// 新增配置
 			m.state = addDroid
-			m.droidFormData = DroidConfig{}
+			m.newDroidForm()
 			m.formField = 0
 			m.cursor = 0
 			m.error = ""
@@ -829,150 +767,7 @@ func (m model) handleSelect() (tea.Model, tea.Cmd) {
 				m.cursor = 0
 			}
 		}
-	// 操作菜单已移除
-	case addClaudeCode:
-		if m.cursor == ClaudeCodeFieldCount {
-			if m.hasRequiredServiceFields() {
-				err := m.config.AddClaudeCodeConfig(m.formData)
-				if err != nil {
-					m.error = err.Error()
-				} else {
-					m.error = "✅ Claude Code 配置添加成功！"
-					m.state = claudeCodeList
-					m.cursor = 0
-				}
-			} else {
-				m.error = "⚠️ 请填写所有字段"
-			}
-		} else if m.cursor == ClaudeCodeFieldCount+1 {
-			m.state = mainMenu
-			m.cursor = 0
-		}
-	case addCodex:
-		if m.cursor == 6 {
-			if m.hasRequiredServiceFields() {
-				// Set default values for Codex config
-				if m.formData.Model == "" {
-					m.formData.Model = DefaultCodexModel
-				}
-				if m.formData.WireAPI == "" {
-					m.formData.WireAPI = DefaultWireAPI
-				}
-				if m.formData.ModelReasoningEffort == "" {
-					m.formData.ModelReasoningEffort = DefaultModelReasoningEffort
-				}
-				m.formData.EnvKey = DefaultEnvKey // Always set to CODEX_KEY
-
-				err := m.config.AddCodexConfig(m.formData)
-				if err != nil {
-					m.error = err.Error()
-				} else {
-					m.error = "✅ Codex 配置添加成功！"
-					m.state = codexList
-					m.cursor = 0
-				}
-			} else {
-				m.error = "⚠️ 请填写所有字段"
-			}
-		} else if m.cursor == 7 {
-			m.state = mainMenu
-			m.cursor = 0
-		}
-	case addDroid:
-		if m.cursor == 4 {
-			if m.droidFormData.ModelDisplayName != "" && m.droidFormData.Model != "" && m.droidFormData.BaseURL != "" && m.droidFormData.APIKey != "" {
-				err := m.config.AddDroidConfig(m.droidFormData)
-				if err != nil {
-					m.error = err.Error()
-				} else {
-					m.error = "✅ Droid 配置添加成功！"
-					m.state = droidList
-					m.cursor = 0
-				}
-			} else {
-				m.error = "⚠️ 请填写所有字段"
-			}
-		} else if m.cursor == 5 {
-			m.state = mainMenu
-			m.cursor = 0
-		}
-	case editClaudeCode:
-		if m.cursor == 4 {
-			if m.hasRequiredServiceFields() {
-				m.formData.Provider = "switcher"
-				m.config.ClaudeCode[m.editIndex] = m.formData
-				err := m.config.Save()
-				if err != nil {
-					m.error = err.Error()
-				} else {
-					m.error = "✅ Claude Code 配置更新成功！"
-					m.state = claudeCodeList
-					m.cursor = 0
-				}
-			} else {
-				m.error = "⚠️ 请填写所有字段"
-			}
-		} else if m.cursor == 5 {
-			m.state = claudeCodeList
-			m.cursor = 0
-		}
-	case editCodex:
-		if m.cursor == 6 {
-			if m.hasRequiredServiceFields() {
-				// Set default values for Codex config
-				if m.formData.Model == "" {
-					m.formData.Model = DefaultCodexModel
-				}
-				if m.formData.WireAPI == "" {
-					m.formData.WireAPI = DefaultWireAPI
-				}
-				if m.formData.ModelReasoningEffort == "" {
-					m.formData.ModelReasoningEffort = DefaultModelReasoningEffort
-				}
-				// Only set EnvKey for env auth method
-				if m.formData.AuthMethod == "env" {
-					m.formData.EnvKey = DefaultEnvKey
-				} else {
-					m.formData.EnvKey = ""
-				}
-
-				m.formData.Provider = "switcher"
-				m.config.Codex[m.editIndex] = m.formData
-				err := m.config.Save()
-				if err != nil {
-					m.error = err.Error()
-				} else {
-					m.error = "✅ Codex 配置更新成功！"
-					m.state = codexList
-					m.cursor = 0
-				}
-			} else {
-				m.error = "⚠️ 请填写所有字段"
-			}
-		} else if m.cursor == 7 {
-			m.state = codexList
-			m.cursor = 0
-		}
-	case editDroid:
-		if m.cursor == 4 {
-			if m.droidFormData.ModelDisplayName != "" && m.droidFormData.Model != "" && m.droidFormData.BaseURL != "" && m.droidFormData.APIKey != "" {
-				m.droidFormData.Provider = "switcher"
-				m.config.Droid[m.editIndex] = m.droidFormData
-				err := m.config.Save()
-				if err != nil {
-					m.error = err.Error()
-				} else {
-					m.error = "✅ Droid 配置更新成功！"
-					m.state = droidList
-					m.cursor = 0
-				}
-			} else {
-				m.error = "⚠️ 请填写所有字段"
-			}
-		} else if m.cursor == 5 {
-			m.state = droidList
-			m.cursor = 0
-		}
+	// 新增/编辑表单的保存由 KeyEnter / Ctrl+S 直接处理，不经过 handleSelect
 	case confirmDeleteClaudeCode:
 		if m.cursor == 0 {
 			// 确认删除
@@ -1046,7 +841,7 @@ func (m model) handleSelect() (tea.Model, tea.Cmd) {
 		} else {
 			// 取消退出，返回到表单
 			m.state = addClaudeCode
-			m.cursor = ClaudeCodeFieldCount
+			m.cursor = m.formField
 		}
 	case confirmExitAddCodex:
 		if m.cursor == 0 {
@@ -1058,19 +853,19 @@ func (m model) handleSelect() (tea.Model, tea.Cmd) {
 		} else {
 			// 取消退出，返回到表单
 			m.state = addCodex
-			m.cursor = 4
+			m.cursor = m.formField
 		}
 	case confirmExitAddDroid:
 		if m.cursor == 0 {
 			// 确认退出，清空表单内容
 			m.state = droidList
 			m.cursor = 0
-			m.droidFormData = DroidConfig{}
+			m.setDroidForm(DroidConfig{})
 			m.error = ""
 		} else {
 			// 取消退出，返回到表单
 			m.state = addDroid
-			m.cursor = 4
+			m.cursor = m.formField
 		}
 	}
 	return m, nil
@@ -1084,69 +879,15 @@ func (m model) handleInput(char string) (tea.Model, tea.Cmd) {
 
 	// Handle Droid form inputs
 	if m.state == addDroid || m.state == editDroid {
-		switch m.formField {
-		case 0:
-			m.droidFormData.ModelDisplayName += s
-		case 1:
-			m.droidFormData.Model += s
-		case 2:
-			m.droidFormData.BaseURL += s
-		case 3:
-			m.droidFormData.APIKey += s
-		}
-		return m, nil
-	}
-
-	// Handle Claude Code form inputs
-	if m.state == addClaudeCode || m.state == editClaudeCode {
-		switch m.formField {
-		case 0:
-			m.formData.Name += s
-		case 1:
-			m.formData.BaseURL += s
-		case 2:
-			m.formData.APIKey += s
-		case 3:
-			// EffortLevel: 选择字段，不处理文本输入
-		case 4:
-			m.formData.ClaudeDefaultHaikuModel += s
-		case 5:
-			m.formData.ClaudeDefaultOpusModel += s
-		case 6:
-			m.formData.ClaudeDefaultSonnetModel += s
-		case 7:
-			// 自动压缩阈值：仅接受数字字符，1-100 的范围在写入 settings.json 时校验
-			for _, r := range s {
-				if r >= '0' && r <= '9' {
-					m.formData.AutocompactPctOverride += string(r)
-				}
-			}
-		case 8:
-			m.formData.HTTPProxy += s
-		case 9:
-			m.formData.HTTPSProxy += s
-		case 10:
-			m.formData.NOProxy += s
+		if fields := m.droidFormFields(); m.formField >= 0 && m.formField < len(fields) {
+			fields[m.formField].input(s)
 		}
 		return m, nil
 	}
 
-	// Handle Codex form inputs
-	switch m.formField {
-	case 0:
-		m.formData.Name += s
-	case 1:
-		m.formData.BaseURL += s
-	case 2:
-		m.formData.APIKey += s
-	case 3:
-		m.formData.Model += s
-	case 4:
-		// Wire API字段：不处理输入，使用左右键选择
-	case 5:
-		// AuthMethod字段：不处理输入，使用左右键选择
-	case 6:
-		// 推理强度字段：不处理输入，使用左右键选择
+	// Handle Claude Code / Codex form inputs
+	if fields := m.serviceFormFields(); m.formField >= 0 && m.formField < len(fields) {
+		fields[m.formField].input(s)
 	}
 	return m, nil
 }
@@ -1154,118 +895,15 @@ func (m model) handleInput(char string) (tea.Model, tea.Cmd) {
 func (m model) handleBackspace() (tea.Model, tea.Cmd) {
 	// Handle Droid form backspace
 	if m.state == addDroid || m.state == editDroid {
-		switch m.formField {
-		case 0:
-			if len(m.droidFormData.ModelDisplayName) > 0 {
-				r := []rune(m.droidFormData.ModelDisplayName)
-				m.droidFormData.ModelDisplayName = string(r[:len(r)-1])
-			}
-		case 1:
-			if len(m.droidFormData.Model) > 0 {
-				r := []rune(m.droidFormData.Model)
-				m.droidFormData.Model = string(r[:len(r)-1])
-			}
-		case 2:
-			if len(m.droidFormData.BaseURL) > 0 {
-				r := []rune(m.droidFormData.BaseURL)
-				m.droidFormData.BaseURL = string(r[:len(r)-1])
-			}
-		case 3:
-			if len(m.droidFormData.APIKey) > 0 {
-				r := []rune(m.droidFormData.APIKey)
-				m.droidFormData.APIKey = string(r[:len(r)-1])
-			}
-		}
-		return m, nil
-	}
-
-	// Handle Claude Code form backspace
-	if m.state == addClaudeCode || m.state == editClaudeCode {
-		switch m.formField {
-		case 0:
-			if len(m.formData.Name) > 0 {
-				r := []rune(m.formData.Name)
-				m.formData.Name = string(r[:len(r)-1])
-			}
-		case 1:
-			if len(m.formData.BaseURL) > 0 {
-				r := []rune(m.formData.BaseURL)
-				m.formData.BaseURL = string(r[:len(r)-1])
-			}
-		case 2:
-			if len(m.formData.APIKey) > 0 {
-				r := []rune(m.formData.APIKey)
-				m.formData.APIKey = string(r[:len(r)-1])
-			}
-		case 3:
-			// EffortLevel: 选择字段，不处理退格
-		case 4:
-			if len(m.formData.ClaudeDefaultHaikuModel) > 0 {
-				r := []rune(m.formData.ClaudeDefaultHaikuModel)
-				m.formData.ClaudeDefaultHaikuModel = string(r[:len(r)-1])
-			}
-		case 5:
-			if len(m.formData.ClaudeDefaultOpusModel) > 0 {
-				r := []rune(m.formData.ClaudeDefaultOpusModel)
-				m.formData.ClaudeDefaultOpusModel = string(r[:len(r)-1])
-			}
-		case 6:
-			if len(m.formData.ClaudeDefaultSonnetModel) > 0 {
-				r := []rune(m.formData.ClaudeDefaultSonnetModel)
-				m.formData.ClaudeDefaultSonnetModel = string(r[:len(r)-1])
-			}
-		case 7:
-			if len(m.formData.AutocompactPctOverride) > 0 {
-				r := []rune(m.formData.AutocompactPctOverride)
-				m.formData.AutocompactPctOverride = string(r[:len(r)-1])
-			}
-		case 8:
-			if len(m.formData.HTTPProxy) > 0 {
-				r := []rune(m.formData.HTTPProxy)
-				m.formData.HTTPProxy = string(r[:len(r)-1])
-			}
-		case 9:
-			if len(m.formData.HTTPSProxy) > 0 {
-				r := []rune(m.formData.HTTPSProxy)
-				m.formData.HTTPSProxy = string(r[:len(r)-1])
-			}
-		case 10:
-			if len(m.formData.NOProxy) > 0 {
-				r := []rune(m.formData.NOProxy)
-				m.formData.NOProxy = string(r[:len(r)-1])
-			}
+		if fields := m.droidFormFields(); m.formField >= 0 && m.formField < len(fields) {
+			fields[m.formField].backspace()
 		}
 		return m, nil
 	}
 
-	// Handle Codex form backspace
-	switch m.formField {
-	case 0:
-		if len(m.formData.Name) > 0 {
-			r := []rune(m.formData.Name)
-			m.formData.Name = string(r[:len(r)-1])
-		}
-	case 1:
-		if len(m.formData.BaseURL) > 0 {
-			r := []rune(m.formData.BaseURL)
-			m.formData.BaseURL = string(r[:len(r)-1])
-		}
-	case 2:
-		if len(m.formData.APIKey) > 0 {
-			r := []rune(m.formData.APIKey)
-			m.formData.APIKey = string(r[:len(r)-1])
-		}
-	case 3:
-		if len(m.formData.Model) > 0 {
-			r := []rune(m.formData.Model)
-			m.formData.Model = string(r[:len(r)-1])
-		}
-	case 4:
-		// Wire API字段：不处理退格，使用左右键选择
-	case 5:
-		// AuthMethod字段：不处理退格，使用左右键选择
-	case 6:
-		// 推理强度字段：不处理退格，使用左右键选择
+	// Handle Claude Code / Codex form backspace
+	if fields := m.serviceFormFields(); m.formField >= 0 && m.formField < len(fields) {
+		fields[m.formField].backspace()
 	}
 	return m, nil
 }
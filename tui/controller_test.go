@@ -1,6 +1,9 @@
 package tui
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -28,7 +31,7 @@ func TestHandleInputMapsClaudeFieldsToVisibleRows(t *testing.T) {
 	}
 
 	m = model{state: addClaudeCode}
-	m.formField = 5
+	m.formField = 6
 	updated, _ = m.handleInput("s")
 	got = updated.(model)
 	if got.formData.ClaudeDefaultSonnetModel != "s" {
@@ -103,3 +106,125 @@ func TestCodexArrowKeysUpdateSelectedChoiceField(t *testing.T) {
 		t.Fatalf("AuthMethod changed while editing reasoning: got %q", got.formData.AuthMethod)
 	}
 }
+
+func TestClaudeEnvEditorCommitsEntries(t *testing.T) {
+	m := model{state: editClaudeCode, formData: ServiceConfig{ExtraEnv: map[string]string{"OLD": "1"}}}
+	m.formField = ClaudeCodeFieldCount - 1
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if m.state != editClaudeEnv {
+		t.Fatalf("Enter on the extra env field should open the editor, state = %v", m.state)
+	}
+
+	// 删除 OLD，在新增行中输入 API_TIMEOUT_MS=600000
+	for _, msg := range []tea.KeyMsg{
+		{Type: tea.KeyDelete},
+		{Type: tea.KeyRunes, Runes: []rune("API_TIMEOUT_MS")},
+		{Type: tea.KeyTab},
+		{Type: tea.KeyRunes, Runes: []rune("600000")},
+		{Type: tea.KeyEnter},
+	} {
+		updated, _ = m.Update(msg)
+		m = updated.(model)
+	}
+	if m.state != editClaudeCode {
+		t.Fatalf("Enter should return to the form, state = %v (error %q)", m.state, m.error)
+	}
+	if len(m.formData.ExtraEnv) != 1 || m.formData.ExtraEnv["API_TIMEOUT_MS"] != "600000" {
+		t.Fatalf("ExtraEnv = %v", m.formData.ExtraEnv)
+	}
+}
+
+func TestPresetRuleEditorKeepsCommasInsideRules(t *testing.T) {
+	useTempPlatformPaths(t)
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	m := model{config: c, state: permissionPresetList}
+	press := func(msg tea.KeyMsg) {
+		t.Helper()
+		updated, _ := m.Update(msg)
+		m = updated.(model)
+	}
+
+	// 新增预设：输入名称，在允许规则上按 Enter 打开编辑器，每行输入一条包含逗号的规则
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("git")})
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.state != editPresetRules {
+		t.Fatalf("Enter on the allow rules field should open the rule editor, state = %v", m.state)
+	}
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Bash(git log --format=%h,%s)")})
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Read")})
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.state != editPermissionPreset {
+		t.Fatalf("Enter should return to the preset form, state = %v", m.state)
+	}
+	press(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if m.state != permissionPresetList {
+		t.Fatalf("Ctrl+S should save the preset, state = %v (error %q)", m.state, m.error)
+	}
+
+	if len(c.PermissionPresets) != 1 {
+		t.Fatalf("presets = %+v", c.PermissionPresets)
+	}
+	got := c.PermissionPresets[0]
+	if want := []string{"Bash(git log --format=%h,%s)", "Read"}; !slices.Equal(got.Allow, want) || len(got.Deny) != 0 {
+		t.Fatalf("preset = %+v, want allow %q", got, want)
+	}
+}
+
+func TestClaudeProjectScopeConfirmsBeforeWritingSecrets(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	project := filepath.Join(dir, "project")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(project)
+	settingsPath := filepath.Join(project, ".claude", "settings.json")
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	c.ClaudeCode = []ServiceConfig{{Name: "team", BaseURL: "https://team.example.com", APIKey: "k"}}
+	if ClaudeScopeWritesSecrets(&c.ClaudeCode[0], ClaudeScopeProjectLocal) {
+		t.Fatalf("project-local scope should not need confirmation")
+	}
+	helper := ServiceConfig{Name: "helper", BaseURL: "https://c", ClaudeAuthMode: ClaudeAuthHelper, APIKeyHelper: "~/bin/get-token.sh"}
+	if ClaudeScopeWritesSecrets(&helper, ClaudeScopeProject) {
+		t.Fatalf("apiKeyHelper configs write no credentials")
+	}
+
+	m := model{config: c, state: claudeCodeList, claudeScope: ClaudeScopeProject}
+	m.sortClaudeCodeConfigs()
+	press := func(key tea.KeyType) {
+		t.Helper()
+		updated, _ := m.Update(tea.KeyMsg{Type: key})
+		m = updated.(model)
+	}
+
+	press(tea.KeyEnter)
+	if m.state != confirmProjectSecrets {
+		t.Fatalf("switching with an API key in project scope should ask first, state = %v", m.state)
+	}
+	// 默认选中取消
+	press(tea.KeyEnter)
+	if m.state != claudeCodeList {
+		t.Fatalf("cancel should return to the list, state = %v", m.state)
+	}
+	if _, err := os.Stat(settingsPath); !os.IsNotExist(err) {
+		t.Fatalf("cancelled switch wrote %s", settingsPath)
+	}
+
+	press(tea.KeyEnter)
+	press(tea.KeyUp)
+	press(tea.KeyEnter)
+	if m.state != claudeCodeList {
+		t.Fatalf("confirmed switch should return to the list, state = %v", m.state)
+	}
+	if got := c.ActiveClaudeCodeIndexInScope(ClaudeScopeProject); got != 0 {
+		t.Fatalf("project active index = %d, want 0", got)
+	}
+	if _, err := os.Stat(settingsPath); err != nil {
+		t.Fatalf("confirmed switch did not write settings: %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package tui
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+)
+
+// .env 文件支持：Gemini CLI 等工具从 .env 读取 API Key 和端点。
+// 写入时只修改 switcher 管理的键，注释、空行和其他变量原样保留。
+
+// dotenvLine 解析 .env 中的一行，返回变量名和值；注释、空行或无法识别的行返回 ok=false
+func dotenvLine(line string) (key, value string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+	line = strings.TrimPrefix(line, "export ")
+	key, value, found := strings.Cut(line, "=")
+	key = strings.TrimSpace(key)
+	if !found || key == "" || strings.ContainsAny(key, " \t") {
+		return "", "", false
+	}
+	value = strings.TrimSpace(value)
+	switch {
+	case len(value) >= 2 && value[0] == '"':
+		if end := closingQuote(value); end > 0 {
+			r := strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n")
+			return key, r.Replace(value[1:end]), true
+		}
+	case len(value) >= 2 && value[0] == '\'':
+		if end := strings.IndexByte(value[1:], '\''); end >= 0 {
+			return key, value[1 : end+1], true
+		}
+	}
+	// 未加引号的值：空白后的 # 开始行内注释
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return key, value, true
+}
+
+// closingQuote 返回双引号字符串中结束引号的位置，找不到时返回 -1
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// parseDotenv 解析 .env 内容，重复的变量以最后一个为准
+func parseDotenv(data []byte) map[string]string {
+	env := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, value, ok := dotenvLine(line); ok {
+			env[key] = value
+		}
+	}
+	return env
+}
+
+// dotenvValue 在值包含空白、引号、# 等特殊字符时加上双引号
+func dotenvValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, " \t\n\"'#\\$`") {
+		return v
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(v) + `"`
+}
+
+// updateDotenv 把 set 中的变量写入 .env 内容：已有的行原地替换（重复的行只保留第一行），
+// 没有的按名称顺序追加到末尾；remove 中不在 set 里的变量整行删除。其余内容保持不变。
+func updateDotenv(data []byte, set map[string]string, remove []string) []byte {
+	lines := strings.Split(string(data), "\n")
+	// 末尾的换行不算作一行
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	written := map[string]bool{}
+	var out []string
+	for _, line := range lines {
+		key, _, ok := dotenvLine(line)
+		if !ok {
+			out = append(out, line)
+			continue
+		}
+		if v, managed := set[key]; managed {
+			if !written[key] {
+				prefix := ""
+				if strings.HasPrefix(strings.TrimSpace(line), "export ") {
+					prefix = "export "
+				}
+				out = append(out, prefix+key+"="+dotenvValue(v))
+				written[key] = true
+			}
+			continue
+		}
+		if slices.Contains(remove, key) {
+			continue
+		}
+		out = append(out, line)
+	}
+
+	var missing []string
+	for key := range set {
+		if !written[key] {
+			missing = append(missing, key)
+		}
+	}
+	slices.Sort(missing)
+	for _, key := range missing {
+		out = append(out, key+"="+dotenvValue(set[key]))
+	}
+
+	var buf bytes.Buffer
+	for _, line := range out {
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,542 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// 差异视图支持的工具，与命令行中的工具名一致
+const (
+	driftClaude = "claude"
+	driftCodex  = "codex"
+	driftDroid  = "droid"
+)
+
+// settingDiff 是存储的配置与磁盘上实际设置不一致的一项
+type settingDiff struct {
+	key    string // env 变量名或配置键
+	stored string // 切换时 switcher 会写入的值，空字符串表示不写入
+	actual string // 磁盘上的值，空字符串表示不存在
+}
+
+// driftReport 描述当前活动配置与磁盘上实际设置的差异，
+// 以及根据磁盘内容还原出的配置，供“采纳”时写回存储
+type driftReport struct {
+	tool    string
+	path    string // 读取的主要配置文件
+	index   int    // 活动配置的索引
+	diffs   []settingDiff
+	service *ServiceConfig // Claude Code / Codex 采纳后的配置
+	droid   *DroidConfig   // Droid 采纳后的配置
+	// droidOwned 表示对比的 custom_models 条目由 switcher 写入（可能在 Factory 中被改了名）
+	droidOwned bool
+}
+
+// appendDiff 在两个值不一致时记录一项差异
+func appendDiff(diffs []settingDiff, key, stored, actual string) []settingDiff {
+	stored, actual = strings.TrimSpace(stored), strings.TrimSpace(actual)
+	if stored == actual {
+		return diffs
+	}
+	return append(diffs, settingDiff{key: key, stored: stored, actual: actual})
+}
+
+// ClaudeDrift 对比活动的 Claude Code 配置与其用户作用域 settings 文件；没有活动配置或文件不存在时返回 nil
+func (c *Config) ClaudeDrift() (*driftReport, error) {
+	active := c.GetActiveClaudeCode()
+	if active == nil {
+		return nil, nil
+	}
+	path, err := ClaudeSettingsPathFor(active, ClaudeScopeUser)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	settings := map[string]interface{}{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	env := map[string]string{}
+	if raw, ok := settings["env"].(map[string]interface{}); ok {
+		for k, v := range raw {
+			if s, ok := v.(string); ok {
+				env[k] = s
+			}
+		}
+	}
+
+	report := &driftReport{tool: driftClaude, path: path, index: c.Active.ClaudeCode}
+	want, unmanaged := c.claudeEnv(active)
+	for _, key := range claudeSwitcherEnvKeys {
+		if !unmanaged[key] {
+			report.diffs = appendDiff(report.diffs, key, want[key], env[key])
+		}
+	}
+	extraKeys := slices.Sorted(maps.Keys(active.ExtraEnv))
+	for _, key := range extraKeys {
+		report.diffs = appendDiff(report.diffs, key, active.ExtraEnv[key], env[key])
+	}
+	helper, _ := settings["apiKeyHelper"].(string)
+	if !usesClaudeCloud(active) && claudeAuthMode(active) == ClaudeAuthHelper {
+		report.diffs = appendDiff(report.diffs, "apiKeyHelper", active.APIKeyHelper, helper)
+	}
+
+	// 采纳：switcher 管理的字段取磁盘上的值，名称、MCP 服务器、权限预设、目标目录等保持不变
+	adopted := *active
+	disk, _ := claudeConfigFromSettings(settings)
+	if disk == nil {
+		disk = &ServiceConfig{}
+	}
+	adopted.ClaudeProviderType = disk.ClaudeProviderType
+	adopted.BaseURL = disk.BaseURL
+	adopted.APIKey = disk.APIKey
+	adopted.ClaudeAuthMode = disk.ClaudeAuthMode
+	adopted.APIKeyHelper = disk.APIKeyHelper
+	adopted.CloudRegion = disk.CloudRegion
+	adopted.AWSProfile = disk.AWSProfile
+	adopted.VertexProjectID = disk.VertexProjectID
+	adopted.EffortLevel = disk.EffortLevel
+	adopted.AutocompactPctOverride = disk.AutocompactPctOverride
+	adopted.HTTPProxy = disk.HTTPProxy
+	adopted.HTTPSProxy = disk.HTTPSProxy
+	adopted.NOProxy = disk.NOProxy
+	diskModels := claudeModelEnv(disk)
+	for key, field := range claudeModelEnv(&adopted) {
+		*field = *diskModels[key]
+	}
+	// 隐私开关：与当前生效值一致时保留原设置（可能是继承），unmanaged 的开关不受影响
+	for _, f := range claudePrivacyFlags {
+		mode := c.claudePrivacyMode(active, f)
+		if mode != PrivacyUnmanaged && *f.field(disk) != mode {
+			*f.field(&adopted) = *f.field(disk)
+		}
+	}
+	// 额外 env：只采纳配置中已有的 key，文件中其他 key 可能是用户自己的全局设置
+	adopted.ExtraEnv = nil
+	for _, key := range extraKeys {
+		if v, ok := env[key]; ok {
+			if adopted.ExtraEnv == nil {
+				adopted.ExtraEnv = map[string]string{}
+			}
+			adopted.ExtraEnv[key] = v
+		}
+	}
+	report.service = &adopted
+	return report, nil
+}
+
+// codexBehaviorFields 返回 config.toml 行为 key 到配置字段的映射
+func codexBehaviorFields(config *ServiceConfig) map[string]*string {
+	return map[string]*string{
+		"approval_policy":         &config.ApprovalPolicy,
+		"sandbox_mode":            &config.SandboxMode,
+		"model_verbosity":         &config.ModelVerbosity,
+		"model_reasoning_summary": &config.ModelReasoningSummary,
+		"model_context_window":    &config.ModelContextWindow,
+		"model_max_output_tokens": &config.ModelMaxOutputTokens,
+	}
+}
+
+// codexEnvDiffs 对比 Codex 从 shell 读取的环境变量：switcher 写入 shell 集成的值，以及当前 shell 中的值。
+// 当前 shell 没有加载 switcher 的 env 文件（或切换后还未重新加载）时，Codex 看到的是后者。
+// 返回 switcher 写入的值，用于采纳。
+func codexEnvDiffs(diffs []settingDiff, key, want string) ([]settingDiff, string) {
+	written, _ := shellManager.GetEnvVar(key)
+	diffs = appendDiff(diffs, key, want, written)
+	diffs = appendDiff(diffs, key+" (current shell)", want, os.Getenv(key))
+	return diffs, written
+}
+
+// CodexDrift 对比活动的 Codex 配置与 config.toml / auth.json；没有活动配置或 config.toml 不存在时返回 nil
+func (c *Config) CodexDrift() (*driftReport, error) {
+	active := c.GetActiveCodex()
+	if active == nil {
+		return nil, nil
+	}
+	codexDir := platformPaths.GetCodexConfigDir()
+	path := filepath.Join(codexDir, "config.toml")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	content := strings.ReplaceAll(string(data), "\r\n", "\n")
+	report := &driftReport{tool: driftCodex, path: path, index: c.Active.Codex}
+	adopted := *active
+
+	wantProvider := active.Provider
+	if active.UsesBuiltinOpenAI() {
+		wantProvider = CodexProviderModeOpenAI
+	}
+	actualProvider, _ := readTomlKey(content, "model_provider")
+	report.diffs = appendDiff(report.diffs, "model_provider", wantProvider, actualProvider)
+	if actualProvider == CodexProviderModeOpenAI {
+		adopted.ProviderMode = CodexProviderModeOpenAI
+	} else {
+		adopted.ProviderMode = CodexProviderModeCustom
+	}
+
+	// model / model_reasoning_effort 为空时切换会保留文件中的值，因此只对比配置了的项
+	for _, item := range []struct {
+		key   string
+		field *string
+	}{{"model", &adopted.Model}, {"model_reasoning_effort", &adopted.ModelReasoningEffort}} {
+		actual, _ := readTomlKey(content, item.key)
+		if *item.field != "" {
+			report.diffs = appendDiff(report.diffs, item.key, *item.field, actual)
+			*item.field = actual
+		}
+	}
+	behavior := codexBehaviorValues(active)
+	adoptedBehavior := codexBehaviorFields(&adopted)
+	for _, key := range codexBehaviorKeys {
+		actual, _ := readTomlKey(content, key)
+		report.diffs = appendDiff(report.diffs, key, strings.Trim(behavior[key], `"`), actual)
+		*adoptedBehavior[key] = actual
+	}
+
+	// 端点：内置 openai provider 读取 shell 集成中的 OPENAI_BASE_URL，自定义 provider 读取对应段
+	if actualProvider == CodexProviderModeOpenAI {
+		var actualBase string
+		report.diffs, actualBase = codexEnvDiffs(report.diffs, codexOpenAIBaseURLEnv, codexEnvVars(active)[codexOpenAIBaseURLEnv])
+		adopted.BaseURL = actualBase
+	} else {
+		section := "model_providers." + actualProvider
+		actualBase, _ := readTomlSectionKey(content, section, "base_url")
+		actualWire, _ := readTomlSectionKey(content, section, "wire_api")
+		wantWire := active.WireAPI
+		if wantWire == "" {
+			wantWire = DefaultWireAPI
+		}
+		report.diffs = appendDiff(report.diffs, section+".base_url", active.BaseURL, actualBase)
+		report.diffs = appendDiff(report.diffs, section+".wire_api", wantWire, actualWire)
+		adopted.BaseURL = actualBase
+		if actualWire != "" {
+			adopted.WireAPI = actualWire
+		}
+	}
+
+	// 凭据：auth.json 或 shell 集成中的环境变量
+	if envKey := codexEnvKey(active); envKey != "" {
+		var actualKey string
+		report.diffs, actualKey = codexEnvDiffs(report.diffs, envKey, active.APIKey)
+		adopted.APIKey = actualKey
+	} else if a, err := os.ReadFile(filepath.Join(codexDir, "auth.json")); err == nil {
+		var auth CodexAuth
+		if err := json.Unmarshal(a, &auth); err == nil {
+			report.diffs = appendDiff(report.diffs, "auth.json OPENAI_API_KEY", active.APIKey, auth.OPENAI_API_KEY)
+			adopted.APIKey = auth.OPENAI_API_KEY
+		}
+	}
+
+	report.service = &adopted
+	return report, nil
+}
+
+// DroidDrift 对比活动的 Droid 配置与 Factory 的 config.json / settings.json；没有活动配置或文件不存在时返回 nil
+func (c *Config) DroidDrift() (*driftReport, error) {
+	if c.Active.Droid < 0 || c.Active.Droid >= len(c.Droid) {
+		return nil, nil
+	}
+	active := c.Droid[c.Active.Droid]
+	factoryDir := platformPaths.GetDroidConfigDir()
+	path := filepath.Join(factoryDir, "config.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var factoryConfig FactoryConfig
+	if err := json.Unmarshal(data, &factoryConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	selected := ""
+	if model, ok := readFactorySettings(filepath.Join(factoryDir, "settings.json"))["model"].(string); ok {
+		selected = model
+	}
+
+	// 优先对比 settings.json 中选中的模型，其次是与活动配置同名的模型；都找不到时报告活动模型缺失
+	var actual DroidConfig
+	found := false
+	for _, match := range []func(DroidConfig) bool{
+		func(m DroidConfig) bool { return selected != "" && "custom:"+m.Model == selected },
+		func(m DroidConfig) bool { return m.ModelDisplayName == active.ModelDisplayName },
+	} {
+		for _, m := range factoryConfig.CustomModels {
+			if match(m) {
+				actual, found = m, true
+				break
+			}
+		}
+		if found {
+			break
+		}
+	}
+
+	report := &driftReport{tool: driftDroid, path: path, index: c.Active.Droid}
+	if !found {
+		report.diffs = appendDiff(report.diffs, "custom_models", active.ModelDisplayName, "")
+		return report, nil
+	}
+	report.diffs = appendDiff(report.diffs, "model_display_name", active.ModelDisplayName, actual.ModelDisplayName)
+	report.diffs = appendDiff(report.diffs, "model", active.Model, actual.Model)
+	report.diffs = appendDiff(report.diffs, "base_url", active.BaseURL, actual.BaseURL)
+	report.diffs = appendDiff(report.diffs, "api_key", active.APIKey, actual.APIKey)
+	report.diffs = appendDiff(report.diffs, "provider", active.Provider, actual.Provider)
+	report.diffs = appendDiff(report.diffs, "max_tokens", droidMaxTokensText(active.MaxTokens), droidMaxTokensText(actual.MaxTokens))
+	headerNames := maps.Clone(active.ExtraHeaders)
+	if headerNames == nil {
+		headerNames = map[string]string{}
+	}
+	maps.Copy(headerNames, actual.ExtraHeaders)
+	for _, name := range slices.Sorted(maps.Keys(headerNames)) {
+		report.diffs = appendDiff(report.diffs, "extra_headers."+name, active.ExtraHeaders[name], actual.ExtraHeaders[name])
+	}
+	if active.Model != "" {
+		report.diffs = appendDiff(report.diffs, "settings.json model", "custom:"+active.Model, selected)
+	}
+	// 旧版本 switcher 写入的模型使用 legacyDroidProvider，采纳为同样的兼容 Chat Completions 类型
+	if actual.Provider == legacyDroidProvider || actual.Provider == "" {
+		actual.Provider = DroidProviderGeneric
+	}
+	report.droid = &actual
+	// 条目名称已记录为 switcher 所有，或活动配置的条目已不在文件中（被改名）时，视为 switcher 写入的条目
+	report.droidOwned = slices.Contains(c.Managed.DroidModels, actual.ModelDisplayName) ||
+		(slices.Contains(c.Managed.DroidModels, active.ModelDisplayName) && !slices.ContainsFunc(factoryConfig.CustomModels, func(m DroidConfig) bool {
+			return m.ModelDisplayName == active.ModelDisplayName
+		}))
+	return report, nil
+}
+
+// droidMaxTokensText 返回 max_tokens 的显示文本，0 表示未设置
+func droidMaxTokensText(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// AdoptDrift 用磁盘上的实际设置更新活动配置；asNew 为 true 时另存为新配置（名称重复时追加后缀）并设为活动配置。
+// 返回保存后的配置名。
+func (c *Config) AdoptDrift(report *driftReport, asNew bool) (string, error) {
+	switch report.tool {
+	case driftClaude, driftCodex:
+		if report.service == nil {
+			return "", fmt.Errorf("nothing to adopt")
+		}
+		configs := &c.ClaudeCode
+		if report.tool == driftCodex {
+			configs = &c.Codex
+		}
+		adopted := *report.service
+		if !asNew {
+			if report.tool == driftCodex {
+				return adopted.Name, c.UpdateCodexConfig(report.index, adopted)
+			}
+			return adopted.Name, c.UpdateClaudeCodeConfig(report.index, adopted)
+		}
+		adopted.Name = uniqueName(adopted.Name, func(n string) bool { return findConfigIndexByName(*configs, n) >= 0 })
+		adopted.Provider = "switcher"
+		if report.tool == driftCodex {
+			adopted.Provider = ""
+			c.assignCodexProvider(-1, &adopted)
+		}
+		*configs = append(*configs, adopted)
+		if report.tool == driftCodex {
+			// 新配置有自己的 provider 段名，重新应用使 config.toml 指向它
+			c.Active.Codex = len(c.Codex) - 1
+			if err := c.SwitchCodex(&c.Codex[c.Active.Codex]); err != nil {
+				return "", err
+			}
+		} else {
+			c.Active.ClaudeCode = len(c.ClaudeCode) - 1
+		}
+		return adopted.Name, c.Save()
+	case driftDroid:
+		if report.droid == nil {
+			return "", fmt.Errorf("the active model is missing from custom_models; switch to the config again to restore it")
+		}
+		adopted := *report.droid
+		if !asNew {
+			if report.index < 0 || report.index >= len(c.Droid) {
+				return "", fmt.Errorf("invalid Droid index")
+			}
+			for i, d := range c.Droid {
+				if i != report.index && d.ModelDisplayName == adopted.ModelDisplayName {
+					return "", fmt.Errorf("another Droid config is already named %q; adopt as a new config instead", adopted.ModelDisplayName)
+				}
+			}
+			oldName := c.Droid[report.index].ModelDisplayName
+			if err := c.UpdateDroidConfig(report.index, adopted); err != nil {
+				return "", err
+			}
+			c.trackAdoptedDroidModel(report, oldName)
+			return adopted.ModelDisplayName, c.Save()
+		}
+		adopted.ModelDisplayName = uniqueName(adopted.ModelDisplayName, func(n string) bool {
+			for _, d := range c.Droid {
+				if d.ModelDisplayName == n {
+					return true
+				}
+			}
+			return false
+		})
+		if err := c.AddDroidConfig(adopted); err != nil {
+			return "", err
+		}
+		c.Active.Droid = len(c.Droid) - 1
+		c.trackAdoptedDroidModel(report, "")
+		return adopted.ModelDisplayName, c.Save()
+	default:
+		return "", fmt.Errorf("unknown tool %q", report.tool)
+	}
+}
+
+// trackAdoptedDroidModel 在采纳的 custom_models 条目由 switcher 写入时，把它的当前名称记为 switcher 所有
+// （replaced 为被采纳覆盖的旧名称），这样在 Factory 中被改名的条目切走时仍会被移除，
+// 之后修改配置再切换也不会被当作用户的同名模型而拒绝
+func (c *Config) trackAdoptedDroidModel(report *driftReport, replaced string) {
+	name := report.droid.ModelDisplayName
+	if !report.droidOwned || slices.Contains(c.Managed.DroidModels, name) {
+		return
+	}
+	if i := slices.Index(c.Managed.DroidModels, replaced); replaced != "" && i >= 0 {
+		c.Managed.DroidModels[i] = name
+		return
+	}
+	c.Managed.DroidModels = append(c.Managed.DroidModels, name)
+}
+
+// driftValue 返回差异项在界面中的显示值：凭据遮蔽，空值显示为“未设置”
+func driftValue(key, value string) string {
+	if value == "" {
+		return t("value_unset")
+	}
+	upper := strings.ToUpper(key)
+	if strings.Contains(upper, "KEY") || strings.Contains(upper, "TOKEN") {
+		return maskAPIKey(value)
+	}
+	return value
+}
+
+// openDriftView 从列表进入差异视图，对比当前活动配置与磁盘上的实际设置
+func (m *model) openDriftView() {
+	var report *driftReport
+	var err error
+	switch m.state {
+	case claudeCodeList:
+		// 项目作用域的文件可能对应不同的配置，只对比用户作用域
+		if m.claudeScope != "" && m.claudeScope != ClaudeScopeUser {
+			m.error = t("error_drift_user_scope")
+			return
+		}
+		report, err = m.config.ClaudeDrift()
+	case codexList:
+		report, err = m.config.CodexDrift()
+	case droidList:
+		report, err = m.config.DroidDrift()
+	default:
+		return
+	}
+	if err != nil {
+		m.error = err.Error()
+		return
+	}
+	if report == nil {
+		m.error = t("error_drift_unavailable")
+		return
+	}
+	m.drift = report
+	m.driftReturnState = m.state
+	m.state = driftView
+	m.error = ""
+}
+
+// closeDriftView 回到进入差异视图前的列表
+func (m *model) closeDriftView() {
+	m.state = m.driftReturnState
+	m.drift = nil
+	m.cursor = 0
+}
+
+func (m model) handleDriftKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.closeDriftView()
+		m.error = ""
+	case tea.KeyRunes:
+		asNew := false
+		switch msg.Runes[0] {
+		case 'a', 'A':
+		case 'n', 'N':
+			asNew = true
+		default:
+			return m, nil
+		}
+		if len(m.drift.diffs) == 0 {
+			return m, nil
+		}
+		name, err := m.config.AdoptDrift(m.drift, asNew)
+		if err != nil {
+			m.error = err.Error()
+			return m, nil
+		}
+		switch m.drift.tool {
+		case driftClaude:
+			m.sortClaudeCodeConfigs()
+		case driftCodex:
+			m.sortCodexConfigs()
+		case driftDroid:
+			m.sortDroidConfigs()
+		}
+		m.closeDriftView()
+		if asNew {
+			m.error = fmt.Sprintf(t("success_adopt_new"), name)
+		} else {
+			m.error = fmt.Sprintf(t("success_adopt"), name)
+		}
+	}
+	return m, nil
+}
+
+func (m model) driftView() string {
+	title := headerView(t("header_drift"))
+
+	var inner strings.Builder
+	inner.WriteString(formRowStyle.Render(fmt.Sprintf(t("display_drift_file"), m.drift.path)) + "\n")
+	if len(m.drift.diffs) == 0 {
+		inner.WriteString(formRowStyle.Render(t("display_drift_none")) + "\n")
+	}
+	for _, d := range m.drift.diffs {
+		inner.WriteString(formRowStyle.Render(fmt.Sprintf("  %s: %s → %s", d.key,
+			helpStyle.Render(driftValue(d.key, d.stored)), driftValue(d.key, d.actual))) + "\n")
+	}
+
+	var content strings.Builder
+	content.WriteString(title)
+	content.WriteString("\n\n")
+	content.WriteString(boxStyle.Render(inner.String()))
+	content.WriteString("\n")
+	content.WriteString(helpStyle.Render(t("hint_drift")))
+	content.WriteString("\n")
+	content.WriteString(statusBarView(t("nav_drift_adopt"), t("nav_drift_new"), t("nav_back"), ""))
+	return content.String()
+}
@@ -1,22 +1,44 @@
 package tui
 
 import (
+	"encoding/json"
 	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 )
 
+// droidPublishHint 返回 Droid 列表中“发布全部”开关的按键提示
+func droidPublishHint(publishAll bool) string {
+	state := t("value_off")
+	if publishAll {
+		state = t("value_on")
+	}
+	return fmt.Sprintf(t("nav_droid_publish"), state)
+}
+
 func (m model) droidListView() string {
 	header := headerView(t("header_droid"))
 
 	var rows []string
+
+	// 检查是否有警告信息
 	hasWarning := false
+	if ok, actual, _ := checkAppliedDroidLocal(m.config); !ok && actual != "" {
+		warn := errorStyle.Render(fmt.Sprintf(t("warn_mismatch"), "Droid") + actual)
+		rows = append(rows, itemBoxStyle.Render(warn))
+		hasWarning = true
+	}
 
 	// Calculate viewport size based on window height
 	configCount := len(m.sortedDroid)
 	if configCount > 0 {
-		viewportSize := calculateListViewportHeight(m.windowHeight, hasWarning, m.compact)
+		viewportSize := calculateListViewportHeight(m.windowHeight, hasWarning, m.compact, 0)
 
 		// Calculate visible range based on cursor position
 		start, end := updateCursorViewport(m.cursor, configCount, viewportSize)
@@ -67,82 +89,74 @@ func (m model) droidListView() string {
 	content.WriteString("\n\n")
 	content.WriteString(body)
 	content.WriteString("\n")
-	content.WriteString(statusBarView(t("nav_select"), t("nav_confirm"), t("nav_edit"), t("nav_add")+"  "+t("nav_view")+"  "+t("nav_back")+"  "+t("nav_switch_buttons")))
+	content.WriteString(statusBarView(t("nav_select"), t("nav_confirm"), t("nav_edit"), t("nav_add")+"  "+droidPublishHint(m.config.DroidPublishAll)+"  "+t("nav_import")+"  "+t("nav_drift")+"  "+t("nav_view")+"  "+t("nav_back")+"  "+t("nav_switch_buttons")))
 	return content.String()
 }
 
-func (m model) addDroidConfigView() string {
-	title := headerView(fmt.Sprintf(t("form_add"), "Droid"))
-
-	var content strings.Builder
-	content.WriteString(title)
-	content.WriteString("\n\n")
-
-	fields := []struct {
-		label string
-		value string
-	}{
-		{t("field_display_name"), m.droidFormData.ModelDisplayName},
-		{t("field_model_name"), m.droidFormData.Model},
-		{t("field_base_url"), m.droidFormData.BaseURL},
-		{t("field_api_key"), m.droidFormData.APIKey},
+// setDroidForm 用 config 填充 Droid 表单
+func (m *model) setDroidForm(config DroidConfig) {
+	m.droidFormData = config
+	m.droidMaxTokens = ""
+	if config.MaxTokens > 0 {
+		m.droidMaxTokens = strconv.Itoa(config.MaxTokens)
 	}
+}
 
-	var inner strings.Builder
-	for i, field := range fields {
-		prefix := "  "
-		if m.formField == i {
-			prefix = cursorStyle.Render(">")
-		}
-
-		// 对于API密钥字段，如果正在编辑，显示完整内容，否则显示遮蔽内容
-		displayValue := field.value
-		if i == FieldAPIKey && m.formField != FieldAPIKey { // API密钥字段且不在编辑状态
-			displayValue = maskAPIKey(field.value)
-		} else if i == FieldAPIKey && m.formField == FieldAPIKey {
-			// 如果正在编辑API密钥字段，显示完整内容但添加提示
-			displayValue = field.value + " " + t("hint_editing")
-		}
-
-		highlight := ""
-		if m.formField == i {
-			highlight = fieldHighlightStyle.Render(" " + t("hint_input"))
-		}
+// newDroidForm 清空 Droid 表单，provider 类型使用默认值
+func (m *model) newDroidForm() {
+	m.setDroidForm(DroidConfig{Provider: droidProviderTypes[0]})
+}
 
-		inner.WriteString(formRowStyle.Render(fmt.Sprintf("%s %s:%s %s", prefix, field.label, highlight, displayValue)) + "\n")
+// saveDroidForm 校验并保存 Droid 表单（新增或更新），成功后回到列表
+func (m *model) saveDroidForm() {
+	d := m.droidFormData
+	if d.ModelDisplayName == "" || d.Model == "" || d.BaseURL == "" || d.APIKey == "" {
+		m.error = t("error_fill_all")
+		return
 	}
-
-	content.WriteString(boxStyle.Render(inner.String()))
-	content.WriteString("\n")
-	content.WriteString(statusBarView(t("form_nav_field"), t("form_nav_save"), t("form_nav_cancel"), ""))
-
-	// 添加当前编辑状态提示
-	if m.formField >= 0 && m.formField < DroidFieldCount {
-		content.WriteString("\n" + fieldHighlightStyle.Render(t("hint_current_edit")) + fields[m.formField].label)
-		if m.formField == FieldAPIKey {
-			content.WriteString("\n" + fieldHighlightStyle.Render("   "+t("hint_apikey_visible")))
+	d.MaxTokens = 0
+	if m.droidMaxTokens != "" {
+		n, err := strconv.Atoi(m.droidMaxTokens)
+		if err != nil {
+			m.error = fmt.Sprintf(t("error_droid_max_tokens"), m.droidMaxTokens)
+			return
 		}
+		d.MaxTokens = n
+	}
+	var err error
+	success := t("success_add_droid")
+	if m.state == editDroid {
+		err = m.config.UpdateDroidConfig(m.editIndex, d)
+		success = t("success_update_droid")
+	} else {
+		err = m.config.AddDroidConfig(d)
+	}
+	if err != nil {
+		m.error = err.Error()
+		return
 	}
+	m.error = success
+	m.state = droidList
+	m.cursor = 0
+}
 
-	return content.String()
+func (m model) addDroidConfigView() string {
+	return m.droidFormView(fmt.Sprintf(t("form_add"), "Droid"))
 }
 
 func (m model) editDroidConfigView() string {
-	title := headerView(fmt.Sprintf(t("form_edit"), "Droid"))
+	return m.droidFormView(fmt.Sprintf(t("form_edit"), "Droid"))
+}
+
+// droidFormView 渲染 Droid 新增/编辑表单
+func (m model) droidFormView(header string) string {
+	title := headerView(header)
 
 	var content strings.Builder
 	content.WriteString(title)
 	content.WriteString("\n\n")
 
-	fields := []struct {
-		label string
-		value string
-	}{
-		{t("field_display_name"), m.droidFormData.ModelDisplayName},
-		{t("field_model_name"), m.droidFormData.Model},
-		{t("field_base_url"), m.droidFormData.BaseURL},
-		{t("field_api_key"), m.droidFormData.APIKey},
-	}
+	fields := m.droidFormFields()
 
 	var inner strings.Builder
 	for i, field := range fields {
@@ -151,21 +165,18 @@ func (m model) editDroidConfigView() string {
 			prefix = cursorStyle.Render(">")
 		}
 
-		// 对于API密钥字段，如果正在编辑，显示完整内容，否则显示遮蔽内容
-		displayValue := field.value
-		if i == FieldAPIKey && m.formField != FieldAPIKey { // API密钥字段且不在编辑状态
-			displayValue = maskAPIKey(field.value)
-		} else if i == FieldAPIKey && m.formField == FieldAPIKey {
-			// 如果正在编辑API密钥字段，显示完整内容但添加提示
-			displayValue = field.value + " " + t("hint_editing")
-		}
-
 		highlight := ""
 		if m.formField == i {
-			highlight = fieldHighlightStyle.Render(" " + t("hint_input"))
+			switch field.kind {
+			case fieldChoice:
+				highlight = fieldHighlightStyle.Render(" " + t("hint_use_arrows"))
+			case fieldEditor: // 操作提示已包含在显示值中
+			default:
+				highlight = fieldHighlightStyle.Render(" " + t("hint_input"))
+			}
 		}
 
-		inner.WriteString(formRowStyle.Render(fmt.Sprintf("%s %s:%s %s", prefix, field.label, highlight, displayValue)) + "\n")
+		inner.WriteString(formRowStyle.Render(fmt.Sprintf("%s %s:%s %s", prefix, field.label, highlight, field.display(m.formField == i))) + "\n")
 	}
 
 	content.WriteString(boxStyle.Render(inner.String()))
@@ -173,9 +184,9 @@ func (m model) editDroidConfigView() string {
 	content.WriteString(statusBarView(t("form_nav_field"), t("form_nav_save"), t("form_nav_cancel"), ""))
 
 	// 添加当前编辑状态提示
-	if m.formField >= 0 && m.formField < DroidFieldCount {
+	if m.formField >= 0 && m.formField < len(fields) {
 		content.WriteString("\n" + fieldHighlightStyle.Render(t("hint_current_edit")) + fields[m.formField].label)
-		if m.formField == FieldAPIKey {
+		if fields[m.formField].kind == fieldSecret {
 			content.WriteString("\n" + fieldHighlightStyle.Render("   "+t("hint_apikey_visible")))
 		}
 	}
@@ -208,6 +219,70 @@ func droidListRowView(cfg DroidConfig, selected bool, active bool, compact bool)
 		status, name, cfg.Provider, cfg.BaseURL, key)
 }
 
+// importDroid 导入 Factory custom_models 中的全部模型，并在状态栏报告新增和已存在的数量
+func (m *model) importDroid() {
+	results, err := m.config.ImportDroid()
+	if err != nil {
+		m.error = err.Error()
+		return
+	}
+	if len(results) == 0 {
+		m.error = t("error_import_droid_none")
+		return
+	}
+	added := 0
+	for _, r := range results {
+		if !r.Existing {
+			added++
+		}
+	}
+	m.error = fmt.Sprintf(t("success_import_droid"), added, len(results)-added)
+	m.sortDroidConfigs()
+	m.cursor = 0
+}
+
+// checkAppliedDroidLocal 检查 Factory 的 config.json 与 settings.json 是否与当前选中的配置一致：
+// custom_models 中应有同名模型且各字段一致，settings.json 应选中该模型（custom:<model>）。
+// 不一致时返回实际选中模型的 base URL（找不到对应模型时为 settings.json 中的 model）。
+func checkAppliedDroidLocal(c *Config) (bool, string, error) {
+	active := c.GetActiveDroid()
+	if active == nil {
+		return true, "", nil
+	}
+	factoryDir := platformPaths.GetDroidConfigDir()
+	data, err := os.ReadFile(filepath.Join(factoryDir, "config.json"))
+	if err != nil {
+		return true, "", nil
+	}
+	var factoryConfig FactoryConfig
+	if err := json.Unmarshal(data, &factoryConfig); err != nil {
+		return true, "", err
+	}
+	selected, _ := readFactorySettings(filepath.Join(factoryDir, "settings.json"))["model"].(string)
+
+	shown := selected
+	ok := false
+	for _, m := range factoryConfig.CustomModels {
+		if selected != "" && "custom:"+m.Model == selected && m.BaseURL != "" {
+			shown = m.BaseURL
+		}
+		if m.ModelDisplayName == active.ModelDisplayName {
+			ok = m.Model == active.Model && m.BaseURL == active.BaseURL && m.APIKey == active.APIKey
+			// 切换时直接复用的用户模型只要求模型、base URL 和 API Key 相同，其余字段保持用户的设置
+			if slices.Contains(c.Managed.DroidModels, m.ModelDisplayName) {
+				ok = ok && m.Provider == active.Provider && m.MaxTokens == active.MaxTokens && maps.Equal(m.ExtraHeaders, active.ExtraHeaders)
+			}
+		}
+	}
+	if active.Model != "" && selected != "custom:"+active.Model {
+		ok = false
+	}
+	if shown == "" {
+		shown = t("value_unset")
+	}
+	return ok, shown, nil
+}
+
 // findDroidConfigIndex 查找 Droid 配置在原始列表中的索引
 func findDroidConfigIndex(configs []DroidConfig, target DroidConfig) int {
 	for i, cfg := range configs {
@@ -220,33 +295,7 @@ func findDroidConfigIndex(configs []DroidConfig, target DroidConfig) int {
 
 // getSortedDroidConfigs 获取排序后的 Droid 配置列表
 func (m model) getSortedDroidConfigs() []DroidConfig {
-	sortedConfigs := make([]DroidConfig, 0, len(m.config.Droid))
-	var activeConfig DroidConfig
-	var otherConfigs []DroidConfig
-
-	for i, cfg := range m.config.Droid {
-		if m.config.Active.Droid == i {
-			activeConfig = cfg
-		} else {
-			otherConfigs = append(otherConfigs, cfg)
-		}
-	}
-
-	// 按名称对剩余配置进行排序
-	for i := 0; i < len(otherConfigs)-1; i++ {
-		for j := i + 1; j < len(otherConfigs); j++ {
-			if otherConfigs[i].ModelDisplayName > otherConfigs[j].ModelDisplayName {
-				otherConfigs[i], otherConfigs[j] = otherConfigs[j], otherConfigs[i]
-			}
-		}
-	}
-
-	// 构建排序后的列表：当前配置在前，其余在后
-	if activeConfig.ModelDisplayName != "" {
-		sortedConfigs = append(sortedConfigs, activeConfig)
-	}
-	sortedConfigs = append(sortedConfigs, otherConfigs...)
-	return sortedConfigs
+	return activeFirst(m.config.Droid, m.config.Active.Droid, func(dc DroidConfig) string { return dc.ModelDisplayName })
 }
 
 // getOriginalDroidIndex 根据排序列表中的位置获取原始索引
@@ -0,0 +1,191 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// envEntry 额外环境变量编辑器中的一行
+type envEntry struct {
+	key   string
+	value string
+}
+
+func (e envEntry) empty() bool {
+	return e.key == "" && e.value == ""
+}
+
+// editingDroidHeaders 表示编辑器是从 Droid 表单打开的，编辑的是请求头而不是环境变量
+func (m *model) editingDroidHeaders() bool {
+	return m.envReturnState == addDroid || m.envReturnState == editDroid
+}
+
+// envEditorTarget 返回编辑器写回的表单字段：Claude Code 的额外环境变量或 Droid 的额外请求头
+func (m *model) envEditorTarget() *map[string]string {
+	if m.editingDroidHeaders() {
+		return &m.droidFormData.ExtraHeaders
+	}
+	return &m.formData.ExtraEnv
+}
+
+// openEnvEditor 从 Claude Code 表单进入额外环境变量编辑器（从 Droid 表单进入时编辑额外请求头），
+// 编辑的是表单数据的副本，只有按 Enter 确认后才写回表单
+func (m *model) openEnvEditor() {
+	m.envReturnState = m.state
+	source := *m.envEditorTarget()
+	keys := make([]string, 0, len(source))
+	for k := range source {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	m.envEntries = make([]envEntry, 0, len(keys)+1)
+	for _, k := range keys {
+		m.envEntries = append(m.envEntries, envEntry{key: k, value: source[k]})
+	}
+	m.normalizeEnvEntries()
+	m.envRow = 0
+	m.envColumn = 0
+	m.state = editClaudeEnv
+	m.error = ""
+}
+
+// normalizeEnvEntries 保证列表末尾始终有且只有一行空白行，用于新增变量
+func (m *model) normalizeEnvEntries() {
+	for len(m.envEntries) > 0 && m.envEntries[len(m.envEntries)-1].empty() {
+		m.envEntries = m.envEntries[:len(m.envEntries)-1]
+	}
+	m.envEntries = append(m.envEntries, envEntry{})
+	if m.envRow >= len(m.envEntries) {
+		m.envRow = len(m.envEntries) - 1
+	}
+}
+
+// currentEnvCell 返回当前光标所在单元格
+func (m *model) currentEnvCell() *string {
+	if m.envColumn == 0 {
+		return &m.envEntries[m.envRow].key
+	}
+	return &m.envEntries[m.envRow].value
+}
+
+// commitEnvEntries 校验编辑结果并写回表单；空行会被忽略
+func (m *model) commitEnvEntries() error {
+	env := map[string]string{}
+	for _, e := range m.envEntries {
+		if e.empty() {
+			continue
+		}
+		if e.key == "" {
+			return fmt.Errorf(t("error_env_key_empty"), e.value)
+		}
+		if _, dup := env[e.key]; dup {
+			return fmt.Errorf(t("error_env_key_duplicate"), e.key)
+		}
+		env[e.key] = e.value
+	}
+	validate := validateClaudeExtraEnv
+	if m.editingDroidHeaders() {
+		validate = validateDroidHeaders
+	}
+	if err := validate(env); err != nil {
+		return err
+	}
+	if len(env) == 0 {
+		env = nil
+	}
+	*m.envEditorTarget() = env
+	return nil
+}
+
+// closeEnvEditor 返回打开编辑器的表单，光标停在最后一个字段（编辑器字段）上
+func (m *model) closeEnvEditor() {
+	m.state = m.envReturnState
+	m.envEntries = nil
+	m.formField = ClaudeCodeFieldCount - 1
+	if m.editingDroidHeaders() {
+		m.formField = DroidFieldCount - 1
+	}
+	m.cursor = m.formField
+}
+
+func (m model) handleEnvEditorKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		// 放弃修改
+		m.closeEnvEditor()
+		m.error = ""
+		return m, nil
+	case tea.KeyEnter, tea.KeyCtrlS:
+		if err := m.commitEnvEntries(); err != nil {
+			m.error = err.Error()
+			return m, nil
+		}
+		m.closeEnvEditor()
+		m.error = ""
+		return m, nil
+	case tea.KeyUp:
+		m.envRow = (m.envRow - 1 + len(m.envEntries)) % len(m.envEntries)
+	case tea.KeyDown:
+		m.envRow = (m.envRow + 1) % len(m.envEntries)
+	case tea.KeyTab, tea.KeyLeft, tea.KeyRight:
+		m.envColumn = 1 - m.envColumn
+	case tea.KeyDelete:
+		// 删除当前行（末尾的空白行除外）
+		if m.envRow < len(m.envEntries)-1 {
+			m.envEntries = append(m.envEntries[:m.envRow], m.envEntries[m.envRow+1:]...)
+		}
+	case tea.KeyBackspace, tea.KeyCtrlH:
+		if cell := m.currentEnvCell(); *cell != "" {
+			r := []rune(*cell)
+			*cell = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		s := sanitizeInput(msg.String())
+		if m.envColumn == 0 {
+			// 变量名和请求头名中不会出现空白
+			s = strings.TrimSpace(s)
+		}
+		*m.currentEnvCell() += s
+	}
+	m.normalizeEnvEntries()
+	return m, nil
+}
+
+func (m model) envEditorView() string {
+	title := headerView(t("header_extra_env"))
+	if m.editingDroidHeaders() {
+		title = headerView(t("header_extra_headers"))
+	}
+
+	var inner strings.Builder
+	for i, e := range m.envEntries {
+		prefix := "  "
+		if i == m.envRow {
+			prefix = cursorStyle.Render(">")
+		}
+		key, value := e.key, e.value
+		if i == m.envRow {
+			if m.envColumn == 0 {
+				key = fieldHighlightStyle.Render("[" + key + "]")
+			} else {
+				value = fieldHighlightStyle.Render("[" + value + "]")
+			}
+		}
+		if e.empty() && i != m.envRow {
+			key = helpStyle.Render(t("value_env_new"))
+		}
+		inner.WriteString(formRowStyle.Render(fmt.Sprintf("%s %s = %s", prefix, key, value)) + "\n")
+	}
+
+	var content strings.Builder
+	content.WriteString(title)
+	content.WriteString("\n\n")
+	content.WriteString(boxStyle.Render(inner.String()))
+	content.WriteString("\n")
+	content.WriteString(statusBarView(t("env_nav_move"), t("env_nav_column"), t("env_nav_delete"), t("env_nav_done")))
+	return content.String()
+}
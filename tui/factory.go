@@ -0,0 +1,204 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Factory BYOK 自定义模型支持的 provider 类型
+const (
+	DroidProviderAnthropic = "anthropic"                   // Anthropic Messages API
+	DroidProviderOpenAI    = "openai"                      // OpenAI Responses API
+	DroidProviderGeneric   = "generic-chat-completion-api" // 兼容 OpenAI Chat Completions 的接口
+)
+
+// droidProviderTypes 是 Droid 表单中可选的 provider 类型，第一个为新配置的默认值
+var droidProviderTypes = []string{DroidProviderGeneric, DroidProviderAnthropic, DroidProviderOpenAI}
+
+// validateDroidHeaders 校验额外请求头的名称：不能为空，也不能包含空白或冒号
+func validateDroidHeaders(headers map[string]string) error {
+	for name := range headers {
+		if name == "" || strings.ContainsAny(name, " \t:") {
+			return fmt.Errorf(t("error_droid_header_name"), name)
+		}
+	}
+	return nil
+}
+
+// validateDroidConfig 校验 Droid 配置的 provider 类型、max_tokens 与额外请求头
+func validateDroidConfig(config DroidConfig) error {
+	if !slices.Contains(droidProviderTypes, config.Provider) {
+		return fmt.Errorf(t("error_droid_provider"), config.Provider, strings.Join(droidProviderTypes, ", "))
+	}
+	if config.MaxTokens < 0 {
+		return fmt.Errorf(t("error_droid_max_tokens"), config.MaxTokens)
+	}
+	return validateDroidHeaders(config.ExtraHeaders)
+}
+
+// legacyDroidProvider 是旧版本写入 custom_models 时固定使用的 provider，
+// 带有该 provider 的条目一定由 switcher 写入，即使没有被记录为 switcher 所有
+const legacyDroidProvider = "switcher"
+
+// migrateDroidConfigs 把旧版本保存的 provider（"switcher" 或空）改为兼容 OpenAI Chat Completions 的类型，
+// 旧版本写入 custom_models 的正是这种接口
+func (c *Config) migrateDroidConfigs() {
+	migrated := false
+	for i := range c.Droid {
+		if c.Droid[i].Provider == legacyDroidProvider || c.Droid[i].Provider == "" {
+			c.Droid[i].Provider = DroidProviderGeneric
+			migrated = true
+		}
+	}
+	if migrated {
+		c.Save()
+	}
+}
+
+// droidModelEntry 把配置转换为 custom_models 中的一个条目
+func droidModelEntry(config DroidConfig) (map[string]interface{}, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	entry := map[string]interface{}{}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// sameDroidModel 报告 custom_models 中的条目与配置的模型、base URL 和 API Key 是否相同
+func sameDroidModel(entry map[string]interface{}, config DroidConfig) bool {
+	model, _ := entry["model"].(string)
+	baseURL, _ := entry["base_url"].(string)
+	apiKey, _ := entry["api_key"].(string)
+	return model == config.Model && baseURL == config.BaseURL && apiKey == config.APIKey
+}
+
+// mergeDroidCustomModels 更新 Factory config.json 中的 custom_models：
+// 移除上一次由 switcher 写入的模型，再写入 models；用户在 Factory 中自己定义的模型和其他顶层设置保持不变。
+// 用户模型与要写入的模型同名（model_display_name 相同）时：模型、base URL 和 API Key 也相同（例如导入的配置）
+// 则直接使用用户模型，不写入也不记为 switcher 所有；否则拒绝写入，避免覆盖用户的模型。返回本次由 switcher 创建的模型名称。
+func mergeDroidCustomModels(path string, previous []string, models []DroidConfig) ([]string, error) {
+	factoryConfig := make(map[string]interface{})
+	if data, err := os.ReadFile(path); err == nil {
+		// 解析失败时拒绝写入，避免丢失用户定义的模型
+		if err := json.Unmarshal(data, &factoryConfig); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	existing, _ := factoryConfig["custom_models"].([]interface{})
+	names := make([]string, 0, len(models))
+	for _, m := range models {
+		names = append(names, m.ModelDisplayName)
+	}
+
+	var kept []interface{}
+	userNames := map[string]bool{}
+	for _, raw := range existing {
+		entry, ok := raw.(map[string]interface{})
+		if !ok {
+			kept = append(kept, raw)
+			continue
+		}
+		name, _ := entry["model_display_name"].(string)
+		provider, _ := entry["provider"].(string)
+		if slices.Contains(previous, name) || provider == legacyDroidProvider {
+			continue
+		}
+		if i := slices.Index(names, name); i >= 0 {
+			if !sameDroidModel(entry, models[i]) {
+				return nil, fmt.Errorf("a custom model named %q that switcher did not create already exists in %s; rename the Droid config or that model", name, path)
+			}
+			userNames[name] = true
+		}
+		kept = append(kept, entry)
+	}
+
+	var owned []string
+	for _, m := range models {
+		if userNames[m.ModelDisplayName] {
+			continue
+		}
+		entry, err := droidModelEntry(m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal Droid model: %w", err)
+		}
+		kept = append(kept, entry)
+		owned = append(owned, m.ModelDisplayName)
+	}
+	if kept == nil {
+		kept = []interface{}{}
+	}
+	factoryConfig["custom_models"] = kept
+
+	data, err := json.MarshalIndent(factoryConfig, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Factory config: %w", err)
+	}
+	if err := writeFileWithPerms(path, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write config.json: %w", err)
+	}
+	return owned, nil
+}
+
+// droidPublishedModels 返回切换到 config 时写入 custom_models 的模型：
+// 默认只有该配置；开启“发布全部”时为全部 Droid 配置，当前配置排在最前
+func (c *Config) droidPublishedModels(config *DroidConfig) []DroidConfig {
+	models := []DroidConfig{*config}
+	if !c.DroidPublishAll {
+		return models
+	}
+	for _, d := range c.Droid {
+		if d.ModelDisplayName != config.ModelDisplayName {
+			models = append(models, d)
+		}
+	}
+	return models
+}
+
+// ToggleDroidPublishAll 切换“发布全部 Droid 配置”，并立即按新设置重新写入当前活动的配置
+func (c *Config) ToggleDroidPublishAll() error {
+	c.DroidPublishAll = !c.DroidPublishAll
+	if active := c.GetActiveDroid(); active != nil {
+		if err := c.SwitchDroid(active); err != nil {
+			c.DroidPublishAll = !c.DroidPublishAll
+			return err
+		}
+	}
+	return c.Save()
+}
+
+// readFactorySettings 读取 Factory 的 settings.json（支持注释和末尾逗号）；文件不存在或无法解析时返回空 map
+func readFactorySettings(settingsPath string) map[string]interface{} {
+	data, err := os.ReadFile(settingsPath)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	settings, err := parseJSONC(data)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	return settings
+}
+
+// factorySettingsWithModel 返回把 settings.json 中的 model 设为 model 之后的内容，其余内容原样保留。
+// 文件无法解析时返回错误，避免丢失用户的设置。
+func factorySettingsWithModel(settingsPath, model string) ([]byte, error) {
+	data, err := os.ReadFile(settingsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", settingsPath, err)
+	}
+	updated, err := setJSONCString(data, "model", model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s, not modifying it: %w", settingsPath, err)
+	}
+	return updated, nil
+}
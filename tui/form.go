@@ -0,0 +1,228 @@
+package tui
+
+import "fmt"
+
+// formFieldKind 表单字段的输入方式
+type formFieldKind int
+
+const (
+	fieldText   formFieldKind = iota // 自由文本输入
+	fieldSecret                      // 文本输入，非编辑状态下遮蔽显示（API Key）
+	fieldNumber                      // 只接受数字
+	fieldChoice                      // 使用 ←/→ 在 options 中循环选择
+	fieldEditor                      // 只读摘要，按 Enter 打开独立的编辑界面
+)
+
+// formField 描述表单中的一行：标签、输入方式以及绑定的配置字段
+type formField struct {
+	label   string
+	kind    formFieldKind
+	value   *string
+	options []string // 仅 fieldChoice 使用；空字符串表示“未设置”
+	unset   string   // fieldChoice 值为空时显示的文字，默认为“未设置”
+	// disabled 表示字段不适用于当前选择（如 Claude Code 提供商类型），不接受输入
+	disabled bool
+}
+
+// input 把输入追加到字段；选择字段不接受文本输入
+func (f formField) input(s string) {
+	if f.disabled {
+		return
+	}
+	switch f.kind {
+	case fieldText, fieldSecret:
+		*f.value += s
+	case fieldNumber:
+		for _, r := range s {
+			if r >= '0' && r <= '9' {
+				*f.value += string(r)
+			}
+		}
+	}
+}
+
+// backspace 删除字段末尾的一个字符；选择字段不处理退格
+func (f formField) backspace() {
+	if f.disabled || f.kind == fieldChoice || f.kind == fieldEditor || *f.value == "" {
+		return
+	}
+	r := []rune(*f.value)
+	*f.value = string(r[:len(r)-1])
+}
+
+// cycle 在选项之间前进（delta>0）或后退（delta<0），当前值不在选项中时回到第一个选项
+func (f formField) cycle(delta int) {
+	if f.disabled || f.kind != fieldChoice || len(f.options) == 0 {
+		return
+	}
+	idx := -1
+	for i, o := range f.options {
+		if o == *f.value {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		*f.value = f.options[0]
+		return
+	}
+	n := len(f.options)
+	*f.value = f.options[((idx+delta)%n+n)%n]
+}
+
+// display 返回字段在表单中的显示值
+func (f formField) display(editing bool) string {
+	if f.disabled {
+		return t("value_not_applicable")
+	}
+	v := *f.value
+	switch f.kind {
+	case fieldSecret:
+		if editing {
+			return v + " " + t("hint_editing")
+		}
+		return maskAPIKey(v)
+	case fieldChoice:
+		if v == "" {
+			v = f.unset
+			if v == "" {
+				v = t("value_unset")
+			}
+		}
+		if editing {
+			return v + " " + t("hint_select")
+		}
+	case fieldEditor:
+		if editing {
+			return v + " " + t("hint_open_editor")
+		}
+	}
+	return v
+}
+
+// Codex 行为设置的可选值，空字符串表示不写入 config.toml
+var (
+	codexApprovalPolicies   = []string{"", "untrusted", "on-failure", "on-request", "never"}
+	codexSandboxModes       = []string{"", "read-only", "workspace-write", "danger-full-access"}
+	codexVerbosityLevels    = []string{"", "low", "medium", "high"}
+	codexReasoningSummaries = []string{"", "auto", "concise", "detailed", "none"}
+)
+
+// Claude Code 认证方式的可选值
+var claudeAuthModes = []string{ClaudeAuthToken, ClaudeAuthAPIKey, ClaudeAuthHelper}
+
+// codexFormFields 返回 Codex 表单的字段列表，顺序与 Field* 常量一致
+func (m *model) codexFormFields() []formField {
+	d := &m.formData
+	return []formField{
+		{label: t("field_name"), kind: fieldText, value: &d.Name},
+		{label: t("field_base_url"), kind: fieldText, value: &d.BaseURL},
+		{label: t("field_api_key"), kind: fieldSecret, value: &d.APIKey},
+		{label: t("field_model"), kind: fieldText, value: &d.Model},
+		{label: t("field_wire_api"), kind: fieldChoice, value: &d.WireAPI, options: []string{DefaultWireAPI, "chat"}},
+		{label: t("field_auth_method"), kind: fieldChoice, value: &d.AuthMethod, options: []string{"auth.json", "env"}},
+		{label: t("field_reasoning"), kind: fieldChoice, value: &d.ModelReasoningEffort, options: []string{
+			ModelReasoningEffortLow, ModelReasoningEffortMedium, ModelReasoningEffortHigh, ModelReasoningEffortXHigh,
+		}},
+		{label: t("field_provider_mode"), kind: fieldChoice, value: &d.ProviderMode, options: []string{CodexProviderModeCustom, CodexProviderModeOpenAI}},
+		{label: t("field_approval_policy"), kind: fieldChoice, value: &d.ApprovalPolicy, options: codexApprovalPolicies},
+		{label: t("field_sandbox_mode"), kind: fieldChoice, value: &d.SandboxMode, options: codexSandboxModes},
+		{label: t("field_verbosity"), kind: fieldChoice, value: &d.ModelVerbosity, options: codexVerbosityLevels},
+		{label: t("field_reasoning_summary"), kind: fieldChoice, value: &d.ModelReasoningSummary, options: codexReasoningSummaries},
+		{label: t("field_context_window"), kind: fieldNumber, value: &d.ModelContextWindow},
+		{label: t("field_max_output_tokens"), kind: fieldNumber, value: &d.ModelMaxOutputTokens},
+	}
+}
+
+// newClaudeFormData 返回新增 Claude Code 配置时表单的初始值
+func newClaudeFormData() ServiceConfig {
+	return ServiceConfig{
+		EffortLevel:            DefaultClaudeEffortLevel,
+		AutocompactPctOverride: DefaultClaudeAutocompactPct,
+		ClaudeAuthMode:         ClaudeAuthToken,
+		ClaudeProviderType:     ClaudeProviderAnthropic,
+	}
+}
+
+// claudeFormFields 返回 Claude Code 表单的字段列表
+func (m *model) claudeFormFields() []formField {
+	d := &m.formData
+	extraEnv := fmt.Sprintf(t("value_env_count"), len(d.ExtraEnv))
+	// 权限预设：空字符串表示不使用预设
+	presets := []string{""}
+	if m.config != nil {
+		for _, p := range m.config.PermissionPresets {
+			presets = append(presets, p.Name)
+		}
+	}
+	// 提供商类型决定哪些字段适用：Bedrock/Vertex 的 base URL 为可选的自定义端点，
+	// Bedrock 的 API Key 写入 AWS_BEARER_TOKEN_BEDROCK，Vertex 不使用 API Key
+	providerType := claudeProviderType(d)
+	baseURLLabel, apiKeyLabel := t("field_base_url"), t("field_api_key")
+	switch providerType {
+	case ClaudeProviderBedrock:
+		baseURLLabel, apiKeyLabel = t("field_bedrock_base_url"), t("field_bedrock_api_key")
+	case ClaudeProviderVertex:
+		baseURLLabel = t("field_vertex_base_url")
+	}
+	cloud := providerType != ClaudeProviderAnthropic
+	fields := []formField{
+		{label: t("field_name"), kind: fieldText, value: &d.Name},
+		{label: baseURLLabel, kind: fieldText, value: &d.BaseURL},
+		{label: apiKeyLabel, kind: fieldSecret, value: &d.APIKey, disabled: providerType == ClaudeProviderVertex},
+		{label: t("field_effort_level"), kind: fieldChoice, value: &d.EffortLevel, options: claudeEffortLevels},
+		{label: t("field_haiku_model"), kind: fieldText, value: &d.ClaudeDefaultHaikuModel},
+		{label: t("field_opus_model"), kind: fieldText, value: &d.ClaudeDefaultOpusModel},
+		{label: t("field_sonnet_model"), kind: fieldText, value: &d.ClaudeDefaultSonnetModel},
+		{label: t("field_main_model"), kind: fieldText, value: &d.ClaudeMainModel},
+		{label: t("field_small_fast_model"), kind: fieldText, value: &d.ClaudeSmallFastModel},
+		{label: t("field_subagent_model"), kind: fieldText, value: &d.ClaudeSubagentModel},
+		// 自动压缩阈值：1-100 的范围在写入 settings.json 时校验
+		{label: t("field_autocompact_pct"), kind: fieldNumber, value: &d.AutocompactPctOverride},
+		{label: t("field_http_proxy"), kind: fieldText, value: &d.HTTPProxy},
+		{label: t("field_https_proxy"), kind: fieldText, value: &d.HTTPSProxy},
+		{label: t("field_no_proxy"), kind: fieldText, value: &d.NOProxy},
+		{label: t("field_claude_provider_type"), kind: fieldChoice, value: &d.ClaudeProviderType, options: claudeProviderTypes},
+		{label: t("field_cloud_region"), kind: fieldText, value: &d.CloudRegion, disabled: !cloud},
+		{label: t("field_aws_profile"), kind: fieldText, value: &d.AWSProfile, disabled: providerType != ClaudeProviderBedrock},
+		{label: t("field_vertex_project"), kind: fieldText, value: &d.VertexProjectID, disabled: providerType != ClaudeProviderVertex},
+		{label: t("field_claude_auth_mode"), kind: fieldChoice, value: &d.ClaudeAuthMode, options: claudeAuthModes, disabled: cloud},
+		{label: t("field_api_key_helper"), kind: fieldText, value: &d.APIKeyHelper, disabled: cloud},
+		{label: t("field_target_dir"), kind: fieldText, value: &d.TargetDir},
+		{label: t("field_permission_preset"), kind: fieldChoice, value: &d.PermissionPreset, options: presets},
+	}
+	// 隐私/遥测开关：未设置时显示继承的全局默认值
+	for _, f := range claudePrivacyFlags {
+		def := PrivacyOn
+		if m.config != nil {
+			def = m.config.defaultPrivacyMode(f)
+		}
+		fields = append(fields, formField{label: t(f.label), kind: fieldChoice, value: f.field(d), options: privacyModes,
+			unset: fmt.Sprintf(t("value_privacy_inherit"), def)})
+	}
+	// 额外环境变量编辑器必须是最后一个字段
+	return append(fields, formField{label: t("field_extra_env"), kind: fieldEditor, value: &extraEnv})
+}
+
+// droidFormFields 返回 Droid 表单的字段列表；额外请求头编辑器必须是最后一个字段
+func (m *model) droidFormFields() []formField {
+	d := &m.droidFormData
+	headers := fmt.Sprintf(t("value_header_count"), len(d.ExtraHeaders))
+	return []formField{
+		{label: t("field_display_name"), kind: fieldText, value: &d.ModelDisplayName},
+		{label: t("field_model_name"), kind: fieldText, value: &d.Model},
+		{label: t("field_base_url"), kind: fieldText, value: &d.BaseURL},
+		{label: t("field_api_key"), kind: fieldSecret, value: &d.APIKey},
+		{label: t("field_droid_provider"), kind: fieldChoice, value: &d.Provider, options: droidProviderTypes},
+		{label: t("field_max_tokens"), kind: fieldNumber, value: &m.droidMaxTokens},
+		{label: t("field_extra_headers"), kind: fieldEditor, value: &headers},
+	}
+}
+
+// serviceFormFields 返回当前表单（Claude Code 或 Codex）的字段列表
+func (m *model) serviceFormFields() []formField {
+	if m.state == addCodex || m.state == editCodex {
+		return m.codexFormFields()
+	}
+	return m.claudeFormFields()
+}
@@ -0,0 +1,239 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// geminiAuthType 是使用 API Key 时 settings.json 中的认证方式
+const geminiAuthType = "gemini-api-key"
+
+// Gemini CLI 从 .env 读取的 API Key 和 Base URL 变量（Gemini CLI 不读取 OPENAI_* 变量）
+const (
+	geminiAPIKeyEnv  = "GEMINI_API_KEY"
+	geminiBaseURLEnv = "GOOGLE_GEMINI_BASE_URL"
+)
+
+// geminiAdapter 管理 ~/.gemini/settings.json 中的模型和认证方式，以及 ~/.gemini/.env 中的 API Key 和端点
+type geminiAdapter struct {
+	storedTool
+}
+
+func (geminiAdapter) DisplayName() string { return "Gemini CLI" }
+
+func (geminiAdapter) Fields() []ToolField {
+	return []ToolField{
+		{Key: toolKeyBaseURL, Label: "field_base_url"},
+		{Key: toolKeyAPIKey, Label: "field_api_key", Secret: true, Required: true},
+		{Key: toolKeyModel, Label: "field_model"},
+	}
+}
+
+func geminiDir() string {
+	return filepath.Join(platformPaths.GetHomeDir(), ".gemini")
+}
+
+func (geminiAdapter) Paths() []string {
+	return []string{filepath.Join(geminiDir(), "settings.json"), filepath.Join(geminiDir(), ".env")}
+}
+
+// geminiEnv 返回配置要写入 .env 的变量
+func geminiEnv(cfg ToolConfig) map[string]string {
+	set := map[string]string{geminiAPIKeyEnv: cfg.Values[toolKeyAPIKey]}
+	if v := cfg.Values[toolKeyBaseURL]; v != "" {
+		set[geminiBaseURLEnv] = v
+	}
+	return set
+}
+
+// geminiSettingsModel 返回 settings.json 中的模型：新版本为 model.name，旧版本为字符串 model
+func geminiSettingsModel(settings map[string]interface{}) string {
+	switch m := settings["model"].(type) {
+	case string:
+		return m
+	case map[string]interface{}:
+		name, _ := m["name"].(string)
+		return name
+	}
+	return ""
+}
+
+// geminiSettingsAuth 返回 settings.json 中的认证方式：新版本为 security.auth.selectedType，旧版本为顶层 selectedAuthType
+func geminiSettingsAuth(settings map[string]interface{}) string {
+	if legacy, ok := settings["selectedAuthType"].(string); ok {
+		return legacy
+	}
+	security, _ := settings["security"].(map[string]interface{})
+	auth, _ := security["auth"].(map[string]interface{})
+	selected, _ := auth["selectedType"].(string)
+	return selected
+}
+
+// geminiSettingsFor 返回应用配置后的 settings.json 内容：设置模型，并把认证方式设为 API Key
+func geminiSettingsFor(data []byte, cfg ToolConfig) ([]byte, error) {
+	return settingsWithModelAuth(data, "Gemini", cfg.Values[toolKeyModel], geminiAuthType)
+}
+
+// settingsWithModelAuth 在 Gemini CLI 格式的 settings.json 中设置模型和认证方式（值为空时不修改）。
+// 沿用文件已有的格式（旧版本的顶层 model/selectedAuthType），其余内容原样保留；无法解析时返回错误。
+func settingsWithModelAuth(data []byte, tool, model, authType string) ([]byte, error) {
+	settings := map[string]interface{}{}
+	if len(data) > 0 {
+		var err error
+		if settings, err = parseJSONC(data); err != nil {
+			return nil, fmt.Errorf("failed to parse %s settings.json: %w", tool, err)
+		}
+	}
+	var err error
+	if model != "" {
+		if _, legacy := settings["model"].(string); legacy {
+			data, err = setJSONCString(data, "model", model)
+		} else {
+			data, err = setJSONCPath(data, []string{"model", "name"}, model)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if authType != "" {
+		if _, legacy := settings["selectedAuthType"]; legacy {
+			data, err = setJSONCString(data, "selectedAuthType", authType)
+		} else {
+			data, err = setJSONCPath(data, []string{"security", "auth", "selectedType"}, authType)
+		}
+	}
+	return data, err
+}
+
+// readGemini 读取 Gemini CLI 的 .env 和 settings.json
+func readGemini() (map[string]string, map[string]interface{}, error) {
+	return readEnvSettings(geminiDir(), "Gemini")
+}
+
+// readEnvSettings 读取目录中 .env 的变量和 settings.json；文件不存在时返回空值
+func readEnvSettings(dir, tool string) (map[string]string, map[string]interface{}, error) {
+	env := map[string]string{}
+	if data, err := os.ReadFile(filepath.Join(dir, ".env")); err == nil {
+		env = parseDotenv(data)
+	}
+	settings := map[string]interface{}{}
+	if data, err := os.ReadFile(filepath.Join(dir, "settings.json")); err == nil {
+		if settings, err = parseJSONC(data); err != nil {
+			return env, nil, fmt.Errorf("failed to parse %s settings.json: %w", tool, err)
+		}
+	}
+	return env, settings, nil
+}
+
+func (a geminiAdapter) Apply(c *Config, index int) error {
+	return c.applyStoredTool(a, index, func(cfg ToolConfig) error {
+		st := c.toolState(a.id)
+		set := geminiEnv(cfg)
+		err := writeEnvSettings(geminiDir(), set, staleManagedKeys(st.Managed, set), func(data []byte) ([]byte, error) {
+			return geminiSettingsFor(data, cfg)
+		})
+		if err != nil {
+			return err
+		}
+		st.Managed = slices.Sorted(maps.Keys(set))
+		return nil
+	})
+}
+
+// staleManagedKeys 返回上一次由 switcher 写入、这次不再写入的变量；用户自己设置的变量不在其中，保持不变
+func staleManagedKeys(managed []string, set map[string]string) []string {
+	return slices.DeleteFunc(slices.Clone(managed), func(key string) bool {
+		_, ok := set[key]
+		return ok
+	})
+}
+
+// writeEnvSettings 更新目录中的 settings.json 和 .env：先用 settingsFor 准备好 settings.json，
+// 无法解析时不修改任何文件；.env 只写入 set 中的变量并删除 remove 中的变量
+func writeEnvSettings(dir string, set map[string]string, remove []string, settingsFor func([]byte) ([]byte, error)) error {
+	settingsPath, envPath := filepath.Join(dir, "settings.json"), filepath.Join(dir, ".env")
+	oldSettings, err := os.ReadFile(settingsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	settings, err := settingsFor(oldSettings)
+	if err != nil {
+		return err
+	}
+	oldEnv, err := os.ReadFile(envPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	env := updateDotenv(oldEnv, set, remove)
+
+	if err := mkdirWithPerms(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s directory: %w", filepath.Base(dir), err)
+	}
+	if string(settings) != string(oldSettings) {
+		if err := writeFileWithPerms(settingsPath, settings, 0644); err != nil {
+			return err
+		}
+	}
+	return writeFileWithPerms(envPath, env, 0600)
+}
+
+// Verify 检查 .env 中的 API Key 和 Base URL、settings.json 中的模型和认证方式是否与活动配置一致；
+// 不一致时返回实际生效的 base URL（未设置时为模型）
+func (a geminiAdapter) Verify(c *Config) (bool, string, error) {
+	active := c.GetActiveTool(a.id)
+	if active == nil {
+		return true, "", nil
+	}
+	env, settings, err := readGemini()
+	if err != nil {
+		return true, "", err
+	}
+	ok := true
+	set := geminiEnv(*active)
+	for _, key := range []string{geminiAPIKeyEnv, geminiBaseURLEnv} {
+		if env[key] != set[key] {
+			ok = false
+		}
+	}
+	model := geminiSettingsModel(settings)
+	if want := active.Values[toolKeyModel]; want != "" && model != want {
+		ok = false
+	}
+	if geminiSettingsAuth(settings) != geminiAuthType {
+		ok = false
+	}
+	shown := env[geminiBaseURLEnv]
+	if shown == "" {
+		shown = model
+	}
+	if shown == "" {
+		shown = t("value_unset")
+	}
+	return ok, shown, nil
+}
+
+// Import 把 .env 中的 GEMINI_API_KEY、GOOGLE_GEMINI_BASE_URL 和 settings.json 中的模型导入为活动配置
+func (a geminiAdapter) Import(c *Config) ([]ToolImportResult, error) {
+	env, settings, err := readGemini()
+	if err != nil {
+		return nil, err
+	}
+	if env[geminiAPIKeyEnv] == "" {
+		return nil, nil
+	}
+	cfg := ToolConfig{Values: map[string]string{toolKeyAPIKey: env[geminiAPIKeyEnv]}}
+	if base := env[geminiBaseURLEnv]; base != "" {
+		cfg.Values[toolKeyBaseURL] = base
+	}
+	if model := geminiSettingsModel(settings); model != "" {
+		cfg.Values[toolKeyModel] = model
+	}
+	cfg.Name = hostOf(cfg.Values[toolKeyBaseURL])
+	if cfg.Name == "" {
+		cfg.Name = "gemini"
+	}
+	return []ToolImportResult{c.importToolConfig(a, cfg, true)}, c.Save()
+}
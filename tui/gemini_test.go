@@ -0,0 +1,150 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUpdateDotenvTouchesOnlyManagedKeys(t *testing.T) {
+	// GOOGLE_GEMINI_BASE_URL 由 switcher 写入（在 Managed 中），GOOGLE_CLOUD_PROJECT 是用户自己设置的
+	in := "# my env\nexport GEMINI_API_KEY=old # inline\nOTHER='keep me'\nGOOGLE_CLOUD_PROJECT=mine\nGOOGLE_GEMINI_BASE_URL=https://old\nGEMINI_API_KEY=dup\n"
+	set := map[string]string{"GEMINI_API_KEY": "new key"}
+	remove := staleManagedKeys([]string{"GEMINI_API_KEY", "GOOGLE_GEMINI_BASE_URL"}, set)
+	if !slices.Equal(remove, []string{"GOOGLE_GEMINI_BASE_URL"}) {
+		t.Fatalf("staleManagedKeys = %v, want [GOOGLE_GEMINI_BASE_URL]", remove)
+	}
+	got := string(updateDotenv([]byte(in), set, remove))
+	want := "# my env\nexport GEMINI_API_KEY=\"new key\"\nOTHER='keep me'\nGOOGLE_CLOUD_PROJECT=mine\n"
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+	env := parseDotenv([]byte(got))
+	if env["GEMINI_API_KEY"] != "new key" || env["OTHER"] != "keep me" || env["GOOGLE_CLOUD_PROJECT"] != "mine" || len(env) != 3 {
+		t.Fatalf("parsed back %v", env)
+	}
+}
+
+func TestGeminiApplyVerifyAndImport(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	gemini := FindToolAdapter("gemini")
+	geminiDir := filepath.Join(dir, ".gemini")
+	if err := os.MkdirAll(geminiDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	settingsPath, envPath := filepath.Join(geminiDir, "settings.json"), filepath.Join(geminiDir, ".env")
+	os.WriteFile(settingsPath, []byte("{\n  // 用户设置\n  \"theme\": \"GitHub\",\n  \"model\": {\"name\": \"gemini-2.5-flash\"},\n  \"security\": {\"auth\": {\"selectedType\": \"oauth-personal\"}}\n}\n"), 0644)
+	os.WriteFile(envPath, []byte("HTTPS_PROXY=http://proxy\n"), 0600)
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	configs := []ToolConfig{
+		{Name: "relay", Values: map[string]string{toolKeyAPIKey: "r-key", toolKeyBaseURL: "https://relay.example.com", toolKeyModel: "gemini-2.5-pro"}},
+		{Name: "google", Values: map[string]string{toolKeyAPIKey: "g-key", toolKeyModel: "gemini-2.5-flash"}},
+	}
+	for _, cfg := range configs {
+		if err := c.AddToolConfig(gemini, cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 写入 Gemini CLI 读取的 GEMINI_API_KEY/GOOGLE_GEMINI_BASE_URL，并把认证方式切换为 API Key
+	if err := gemini.Apply(c, 0); err != nil {
+		t.Fatal(err)
+	}
+	settings, _ := os.ReadFile(settingsPath)
+	if !strings.Contains(string(settings), "// 用户设置") || !strings.Contains(string(settings), `"theme": "GitHub"`) {
+		t.Fatalf("settings.json lost user content:\n%s", settings)
+	}
+	_, parsed, err := readGemini()
+	if err != nil || geminiSettingsModel(parsed) != "gemini-2.5-pro" || geminiSettingsAuth(parsed) != geminiAuthType {
+		t.Fatalf("settings.json after apply:\n%s (err=%v)", settings, err)
+	}
+	if data, _ := os.ReadFile(envPath); string(data) != "HTTPS_PROXY=http://proxy\nGEMINI_API_KEY=r-key\nGOOGLE_GEMINI_BASE_URL=https://relay.example.com\n" {
+		t.Fatalf(".env after apply:\n%s", data)
+	}
+	if ok, _, err := gemini.Verify(c); !ok || err != nil {
+		t.Fatalf("Verify after apply = %v, %v", ok, err)
+	}
+
+	// 切换到没有 Base URL 的配置：switcher 写入的 GOOGLE_GEMINI_BASE_URL 被移除，用户的变量保留
+	if err := gemini.Apply(c, 1); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(envPath); string(data) != "HTTPS_PROXY=http://proxy\nGEMINI_API_KEY=g-key\n" {
+		t.Fatalf(".env after switching:\n%s", data)
+	}
+	if got := c.toolState("gemini").Managed; !slices.Equal(got, []string{"GEMINI_API_KEY"}) {
+		t.Fatalf("managed variables = %v, want [GEMINI_API_KEY]", got)
+	}
+
+	// 在 Gemini CLI 中改回 OAuth 登录后检测到差异
+	data, _ := os.ReadFile(settingsPath)
+	os.WriteFile(settingsPath, []byte(strings.Replace(string(data), geminiAuthType, "oauth-personal", 1)), 0644)
+	if ok, _, _ := gemini.Verify(c); ok {
+		t.Fatalf("Verify should report a non API-key auth type")
+	}
+	os.WriteFile(settingsPath, data, 0644)
+
+	// 手动修改 .env 后检测到差异，并能重新导入
+	os.WriteFile(envPath, []byte("GEMINI_API_KEY=g-key\nGOOGLE_GEMINI_BASE_URL=https://other.example.com\n"), 0600)
+	if ok, actual, _ := gemini.Verify(c); ok || actual != "https://other.example.com" {
+		t.Fatalf("Verify after hand edit = %v, %q", ok, actual)
+	}
+	results, err := gemini.Import(c)
+	if err != nil || len(results) != 1 || results[0].Existing || results[0].Name != "other.example.com" || !results[0].Active {
+		t.Fatalf("import = %+v (err=%v)", results, err)
+	}
+	if ok, _, _ := gemini.Verify(c); !ok {
+		t.Fatalf("imported config should match the files on disk")
+	}
+}
+
+func TestGeminiApplyKeepsHandSetVariables(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	gemini := FindToolAdapter("gemini")
+	envPath := filepath.Join(dir, ".gemini", ".env")
+	if err := os.MkdirAll(filepath.Dir(envPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(envPath, []byte("GOOGLE_GEMINI_BASE_URL=https://mine\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	if err := c.AddToolConfig(gemini, ToolConfig{Name: "g", Values: map[string]string{toolKeyAPIKey: "k", toolKeyModel: "m"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := gemini.Apply(c, 0); err != nil {
+		t.Fatal(err)
+	}
+	env, _, err := readGemini()
+	if err != nil || env["GOOGLE_GEMINI_BASE_URL"] != "https://mine" || env["GEMINI_API_KEY"] != "k" {
+		t.Fatalf("hand-set base URL should be kept: env=%v err=%v", env, err)
+	}
+}
+
+func TestGeminiApplyDoesNotRewriteUnparsableSettings(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	gemini := FindToolAdapter("gemini")
+	settingsPath := filepath.Join(dir, ".gemini", "settings.json")
+	os.MkdirAll(filepath.Dir(settingsPath), 0755)
+	broken := "{\"theme\": \"dark\" \"model\": {}}"
+	os.WriteFile(settingsPath, []byte(broken), 0644)
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	c.AddToolConfig(gemini, ToolConfig{Name: "g", Values: map[string]string{toolKeyAPIKey: "k", toolKeyModel: "m"}})
+	if err := gemini.Apply(c, 0); err == nil {
+		t.Fatalf("apply with an unparsable settings.json should fail")
+	}
+	if data, _ := os.ReadFile(settingsPath); string(data) != broken {
+		t.Fatalf("settings.json was modified: %s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".gemini", ".env")); !os.IsNotExist(err) {
+		t.Fatalf(".env should not be written when settings.json cannot be updated")
+	}
+	if ActiveToolName(c, gemini) != "" {
+		t.Fatalf("failed apply should not change the active config")
+	}
+}
@@ -132,6 +132,167 @@ var translations = map[string]map[string]string{
 		"cli_switched_codex":     "Switched Codex to '%s' (provider=%s)\n",
 		"cli_switched_claude":    "Switched Claude Code to '%s' (provider=%s)\n",
 		"cli_switched_droid":     "Switched Droid to '%s' (provider=%s)\n",
+
+		// Codex behavior settings
+		"field_approval_policy":   "审批策略",
+		"field_sandbox_mode":      "沙箱模式",
+		"field_verbosity":         "输出详细度",
+		"field_reasoning_summary": "推理摘要",
+		"field_context_window":    "上下文窗口",
+		"field_max_output_tokens": "最大输出 Token",
+		"value_unset":             "(未设置)",
+
+		// MCP servers
+		"display_mcp_servers": "MCP 服务器: %s",
+
+		// Shell integration
+		"hint_shell_activate": "💡 运行 `%s` 或新开一个终端，使环境变量在当前 shell 中生效",
+
+		// Codex provider mode
+		"field_provider_mode": "Provider 类型",
+
+		// Claude extra environment variables
+		"field_extra_env":         "额外环境变量",
+		"value_env_count":         "%d 个",
+		"hint_open_editor":        "(Enter 编辑)",
+		"header_extra_env":        "额外环境变量",
+		"value_env_new":           "+ 新增变量",
+		"env_nav_move":            "↑/↓ 切换行",
+		"env_nav_column":          "Tab 切换名称/值",
+		"env_nav_delete":          "Del 删除行",
+		"env_nav_done":            "Enter 确认  Esc 放弃",
+		"error_env_key_empty":     "⚠️ 值 %q 缺少变量名",
+		"error_env_key_duplicate": "⚠️ 变量名重复: %s",
+
+		// Claude Code scopes
+		"display_scope": "📁 作用域: %s → %s",
+		"nav_scope":     "S 切换作用域",
+
+		// Claude auth modes
+		"field_claude_auth_mode": "认证方式",
+		"field_api_key_helper":   "Key Helper 命令",
+
+		// Claude permission presets
+		"field_permission_preset": "权限预设",
+		"header_presets":          "Claude Code 权限预设",
+		"header_edit_preset":      "编辑权限预设",
+		"field_preset_name":       "名称",
+		"field_preset_allow":      "允许规则",
+		"field_preset_deny":       "拒绝规则",
+		"hint_preset_rules":       "在允许/拒绝规则上按 Enter 编辑，每行一条规则，例如: Bash(git log --format=%h,%s)",
+		"display_preset_rules":    "允许 %d / 拒绝 %d",
+		"menu_add_preset":         "➕ 新增预设",
+		"nav_presets":             "P 权限预设",
+		"nav_preset_edit":         "Enter 编辑  A 添加",
+		"nav_preset_delete":       "Del 删除",
+		"success_save_preset":     "✅ 权限预设已保存",
+		"success_delete_preset":   "✅ 权限预设 %s 已删除",
+		"error_preset_in_use":     "权限预设 %q 正在被配置 %q 使用",
+
+		// Claude privacy flags
+		"field_privacy_traffic":   "禁用非必要流量",
+		"field_privacy_telemetry": "禁用遥测",
+		"field_privacy_dnt":       "Do Not Track",
+		"field_privacy_bash_cwd":  "Bash 保持项目目录",
+		"value_privacy_inherit":   "继承 (%s)",
+		"display_privacy":         "隐私: %s",
+
+		// Claude main / small-fast / subagent models
+		"field_main_model":       "主模型",
+		"field_small_fast_model": "快速小模型",
+		"field_subagent_model":   "子代理模型",
+
+		// Resolved target paths
+		"field_target_dir":    "目标目录",
+		"display_target_path": "写入: %s",
+
+		// Claude provider types (Bedrock / Vertex)
+		"field_claude_provider_type": "提供商类型",
+		"field_cloud_region":         "区域",
+		"field_aws_profile":          "AWS Profile",
+		"field_vertex_project":       "GCP 项目 ID",
+		"field_bedrock_base_url":     "Bedrock 端点（可选）",
+		"field_bedrock_api_key":      "Bedrock API Key（可选）",
+		"field_vertex_base_url":      "Vertex 端点（可选）",
+		"value_not_applicable":       "—（当前类型不适用）",
+
+		// 差异视图
+		"nav_drift":               "D 差异",
+		"header_drift":            "磁盘上的改动",
+		"display_drift_file":      "文件: %s",
+		"display_drift_none":      "与当前配置一致，没有差异",
+		"hint_drift":              "左侧为 switcher 会写入的值，右侧为磁盘上的实际值",
+		"nav_drift_adopt":         "A 采纳到当前配置",
+		"nav_drift_new":           "N 另存为新配置",
+		"success_adopt":           "已用磁盘上的设置更新配置 %s",
+		"success_adopt_new":       "已将磁盘上的设置保存为新配置 %s",
+		"error_drift_unavailable": "没有活动配置或配置文件不存在",
+		"error_drift_user_scope":  "差异视图仅支持用户作用域，按 S 切换",
+
+		// Droid 发布全部配置
+		"nav_droid_publish":            "P 发布全部: %s",
+		"value_on":                     "开",
+		"value_off":                    "关",
+		"success_droid_publish_all":    "✅ 切换 Droid 时将把全部配置写入 custom_models",
+		"success_droid_publish_active": "✅ 切换 Droid 时只写入当前配置",
+
+		// Droid 模型定义
+		"field_droid_provider":    "Provider 类型",
+		"field_max_tokens":        "最大输出 Token",
+		"field_extra_headers":     "额外请求头",
+		"value_header_count":      "%d 个",
+		"header_extra_headers":    "额外请求头",
+		"error_droid_header_name": "⚠️ 请求头名称 %q 无效：不能为空，也不能包含空白或冒号",
+		"error_droid_provider":    "⚠️ 不支持的 provider 类型 %q，可选：%s",
+		"error_droid_max_tokens":  "⚠️ 最大输出 Token 无效：%v",
+
+		// Droid 导入
+		"nav_import":              "I 导入",
+		"success_import_droid":    "✅ 已从 Factory 导入 %d 个模型，%d 个已存在",
+		"error_import_droid_none": "Factory 的 config.json 中没有自定义模型",
+
+		// 通用工具（ToolAdapter）
+		"header_tool":              "%s 配置",
+		"success_add_tool":         "✅ %s 配置添加成功！",
+		"success_update_tool":      "✅ %s 配置更新成功！",
+		"success_switch_tool":      "✅ %s 配置切换成功！",
+		"success_delete_tool":      "✅ %s 配置 '%s' 删除成功！",
+		"error_switch_tool":        "切换 %s 配置失败: %v",
+		"success_import_tool":      "✅ 已导入 %d 个配置，%d 个已存在",
+		"error_import_tool_none":   "没有找到可导入的 %s 设置",
+		"error_import_unsupported": "%s 不支持导入现有设置",
+
+		// 通用工具的主菜单项
+		"menu_tool": "⚙️ %s 配置 (当前: %s)",
+
+		// Gemini CLI
+		"menu_gemini":           "✨ Gemini CLI 配置 (当前: %s)",
+
+		// opencode
+		"menu_opencode":           "🧩 opencode 配置 (当前: %s)",
+		"field_opencode_provider": "Provider ID",
+		"field_opencode_npm":      "SDK 包",
+
+		// Aider
+		"menu_aider":      "🛠️ Aider 配置 (当前: %s)",
+		"field_aider_api": "API 类型",
+
+		// Qwen Code
+		"menu_qwen": "🐉 Qwen Code 配置 (当前: %s)",
+
+		// Codex 内置 openai 模式
+		"warn_codex_openai_env": "⚠️ 内置 openai 模式会在 shell 中导出 OPENAI_BASE_URL（env 认证时还有 OPENAI_API_KEY），同一 shell 中启动的其他 OpenAI SDK 工具也会使用该地址",
+
+		// 项目作用域凭证确认
+		"confirm_project_secrets_title": "写入项目 settings.json",
+		"confirm_project_secrets_warn":  "⚠️  配置 '%s' 的 API Key 将写入 %s，该文件通常会随项目提交。",
+		"confirm_project_secrets_msg":   "凭证建议使用 project-local 作用域（settings.local.json，按 s 切换）。",
+		"confirm_project_secrets_yes":   "⚠️  仍然写入",
+		"confirm_project_secrets_no":    "❌ 取消",
+
+		// 权限预设规则编辑器
+		"value_rule_count": "%d 条",
+		"value_rule_new":   "+ 新增规则",
 	},
 	"en": {
 		// Main menu
@@ -262,6 +423,167 @@ var translations = map[string]map[string]string{
 		"cli_switched_codex":     "Switched Codex to '%s' (provider=%s)\n",
 		"cli_switched_claude":    "Switched Claude Code to '%s' (provider=%s)\n",
 		"cli_switched_droid":     "Switched Droid to '%s' (provider=%s)\n",
+
+		// Codex behavior settings
+		"field_approval_policy":   "Approval Policy",
+		"field_sandbox_mode":      "Sandbox Mode",
+		"field_verbosity":         "Verbosity",
+		"field_reasoning_summary": "Reasoning Summary",
+		"field_context_window":    "Context Window",
+		"field_max_output_tokens": "Max Output Tokens",
+		"value_unset":             "(not set)",
+
+		// MCP servers
+		"display_mcp_servers": "MCP Servers: %s",
+
+		// Shell integration
+		"hint_shell_activate": "💡 Run `%s` or open a new shell to load the variable into your current session",
+
+		// Codex provider mode
+		"field_provider_mode": "Provider Type",
+
+		// Claude extra environment variables
+		"field_extra_env":         "Extra Env",
+		"value_env_count":         "%d set",
+		"hint_open_editor":        "(Enter to edit)",
+		"header_extra_env":        "Extra Environment Variables",
+		"value_env_new":           "+ new variable",
+		"env_nav_move":            "↑/↓ Row",
+		"env_nav_column":          "Tab Name/Value",
+		"env_nav_delete":          "Del Remove Row",
+		"env_nav_done":            "Enter Done  Esc Discard",
+		"error_env_key_empty":     "⚠️ Value %q has no variable name",
+		"error_env_key_duplicate": "⚠️ Duplicate variable: %s",
+
+		// Claude Code scopes
+		"display_scope": "📁 Scope: %s → %s",
+		"nav_scope":     "S Scope",
+
+		// Claude auth modes
+		"field_claude_auth_mode": "Auth Mode",
+		"field_api_key_helper":   "Key Helper Command",
+
+		// Claude permission presets
+		"field_permission_preset": "Permission Preset",
+		"header_presets":          "Claude Code Permission Presets",
+		"header_edit_preset":      "Edit Permission Preset",
+		"field_preset_name":       "Name",
+		"field_preset_allow":      "Allow Rules",
+		"field_preset_deny":       "Deny Rules",
+		"hint_preset_rules":       "Press Enter on Allow/Deny Rules to edit them, one rule per line, e.g. Bash(git log --format=%h,%s)",
+		"display_preset_rules":    "allow %d / deny %d",
+		"menu_add_preset":         "➕ Add Preset",
+		"nav_presets":             "P Presets",
+		"nav_preset_edit":         "Enter Edit  A Add",
+		"nav_preset_delete":       "Del Delete",
+		"success_save_preset":     "✅ Permission preset saved",
+		"success_delete_preset":   "✅ Permission preset %s deleted",
+		"error_preset_in_use":     "permission preset %q is used by config %q",
+
+		// Claude privacy flags
+		"field_privacy_traffic":   "Disable Nonessential Traffic",
+		"field_privacy_telemetry": "Disable Telemetry",
+		"field_privacy_dnt":       "Do Not Track",
+		"field_privacy_bash_cwd":  "Bash Keep Project Dir",
+		"value_privacy_inherit":   "inherit (%s)",
+		"display_privacy":         "Privacy: %s",
+
+		// Claude main / small-fast / subagent models
+		"field_main_model":       "Main Model",
+		"field_small_fast_model": "Small Fast Model",
+		"field_subagent_model":   "Subagent Model",
+
+		// Resolved target paths
+		"field_target_dir":    "Target Dir",
+		"display_target_path": "Writes to: %s",
+
+		// Claude provider types (Bedrock / Vertex)
+		"field_claude_provider_type": "Provider Type",
+		"field_cloud_region":         "Region",
+		"field_aws_profile":          "AWS Profile",
+		"field_vertex_project":       "GCP Project ID",
+		"field_bedrock_base_url":     "Bedrock Endpoint (optional)",
+		"field_bedrock_api_key":      "Bedrock API Key (optional)",
+		"field_vertex_base_url":      "Vertex Endpoint (optional)",
+		"value_not_applicable":       "— (not used by this type)",
+
+		// 差异视图
+		"nav_drift":               "D Diff",
+		"header_drift":            "Changes on disk",
+		"display_drift_file":      "File: %s",
+		"display_drift_none":      "Matches the active config, no differences",
+		"hint_drift":              "Left: value switcher would write. Right: value on disk",
+		"nav_drift_adopt":         "A Adopt into active config",
+		"nav_drift_new":           "N Save as new config",
+		"success_adopt":           "Updated %s from the settings on disk",
+		"success_adopt_new":       "Saved the settings on disk as new config %s",
+		"error_drift_unavailable": "No active config or the config file does not exist",
+		"error_drift_user_scope":  "Diff view is only available in the user scope, press S to switch",
+
+		// Droid 发布全部配置
+		"nav_droid_publish":            "P Publish all: %s",
+		"value_on":                     "on",
+		"value_off":                    "off",
+		"success_droid_publish_all":    "✅ Switching Droid now publishes all configs to custom_models",
+		"success_droid_publish_active": "✅ Switching Droid now publishes only the active config",
+
+		// Droid 模型定义
+		"field_droid_provider":    "Provider Type",
+		"field_max_tokens":        "Max Tokens",
+		"field_extra_headers":     "Extra Headers",
+		"value_header_count":      "%d set",
+		"header_extra_headers":    "Extra HTTP Headers",
+		"error_droid_header_name": "⚠️ Invalid header name %q: must be non-empty without whitespace or colons",
+		"error_droid_provider":    "⚠️ Unsupported provider type %q, expected one of: %s",
+		"error_droid_max_tokens":  "⚠️ Invalid max tokens: %v",
+
+		// Droid 导入
+		"nav_import":              "I Import",
+		"success_import_droid":    "✅ Imported %d models from Factory, %d already present",
+		"error_import_droid_none": "No custom models in Factory config.json",
+
+		// 通用工具（ToolAdapter）
+		"header_tool":              "%s Configuration",
+		"success_add_tool":         "✅ %s configuration added successfully!",
+		"success_update_tool":      "✅ %s configuration updated successfully!",
+		"success_switch_tool":      "✅ %s configuration switched successfully!",
+		"success_delete_tool":      "✅ %s configuration '%s' deleted successfully!",
+		"error_switch_tool":        "Failed to switch %s config: %v",
+		"success_import_tool":      "✅ Imported %d configs, %d already existed",
+		"error_import_tool_none":   "No %s settings found to import",
+		"error_import_unsupported": "Importing existing settings is not supported for %s",
+
+		// 通用工具的主菜单项
+		"menu_tool": "⚙️ %s Config (Current: %s)",
+
+		// Gemini CLI
+		"menu_gemini":           "✨ Gemini CLI Config (Current: %s)",
+
+		// opencode
+		"menu_opencode":           "🧩 opencode Config (Current: %s)",
+		"field_opencode_provider": "Provider ID",
+		"field_opencode_npm":      "SDK Package",
+
+		// Aider
+		"menu_aider":      "🛠️ Aider Config (Current: %s)",
+		"field_aider_api": "API Type",
+
+		// Qwen Code
+		"menu_qwen": "🐉 Qwen Code Config (Current: %s)",
+
+		// Codex 内置 openai 模式
+		"warn_codex_openai_env": "⚠️ Built-in openai mode exports OPENAI_BASE_URL (and OPENAI_API_KEY with env auth) in your shell, so other OpenAI SDK tools started from it use this endpoint too",
+
+		// 项目作用域凭证确认
+		"confirm_project_secrets_title": "Write to project settings.json",
+		"confirm_project_secrets_warn":  "⚠️  The API key of config '%s' will be written to %s, a file that is usually committed.",
+		"confirm_project_secrets_msg":   "Use the project-local scope (settings.local.json, press s) for credentials.",
+		"confirm_project_secrets_yes":   "⚠️  Write anyway",
+		"confirm_project_secrets_no":    "❌ Cancel",
+
+		// 权限预设规则编辑器
+		"value_rule_count": "%d rules",
+		"value_rule_new":   "+ new rule",
 	},
 }
 
@@ -0,0 +1,310 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ClaudeImportResult 描述一个 settings 文件的导入结果
+type ClaudeImportResult struct {
+	Scope    string // 文件所属作用域
+	Path     string // 读取的 settings 文件
+	Name     string // 新增或匹配到的配置名
+	Existing bool   // 已有相同的配置，没有新增
+}
+
+// claudeConfigFromSettings 把 settings 中 switcher 能管理的设置转换为配置，
+// 同时返回 permissions 中的规则；文件中既没有 base URL 也没有凭据时返回 nil。
+func claudeConfigFromSettings(settings map[string]interface{}) (*ServiceConfig, PermissionPreset) {
+	env := map[string]string{}
+	if raw, ok := settings["env"].(map[string]interface{}); ok {
+		for k, v := range raw {
+			if s, ok := v.(string); ok {
+				env[k] = s
+			}
+		}
+	}
+	helper, _ := settings["apiKeyHelper"].(string)
+
+	sc := &ServiceConfig{
+		BaseURL:                env["ANTHROPIC_BASE_URL"],
+		EffortLevel:            env["ANTHROPIC_EFFORT_LEVEL"],
+		AutocompactPctOverride: env["CLAUDE_AUTOCOMPACT_PCT_OVERRIDE"],
+		HTTPProxy:              env["HTTP_PROXY"],
+		HTTPSProxy:             env["HTTPS_PROXY"],
+		NOProxy:                env["NO_PROXY"],
+	}
+	switch {
+	case env["CLAUDE_CODE_USE_BEDROCK"] != "":
+		sc.ClaudeProviderType = ClaudeProviderBedrock
+		sc.BaseURL = env["ANTHROPIC_BEDROCK_BASE_URL"]
+		sc.APIKey = env["AWS_BEARER_TOKEN_BEDROCK"]
+		sc.CloudRegion = env["AWS_REGION"]
+		sc.AWSProfile = env["AWS_PROFILE"]
+	case env["CLAUDE_CODE_USE_VERTEX"] != "":
+		sc.ClaudeProviderType = ClaudeProviderVertex
+		sc.BaseURL = env["ANTHROPIC_VERTEX_BASE_URL"]
+		sc.CloudRegion = env["CLOUD_ML_REGION"]
+		sc.VertexProjectID = env["ANTHROPIC_VERTEX_PROJECT_ID"]
+	case helper != "":
+		sc.ClaudeAuthMode = ClaudeAuthHelper
+		sc.APIKeyHelper = helper
+	case env["ANTHROPIC_AUTH_TOKEN"] != "":
+		sc.ClaudeAuthMode = ClaudeAuthToken
+		sc.APIKey = env["ANTHROPIC_AUTH_TOKEN"]
+	case env["ANTHROPIC_API_KEY"] != "":
+		sc.ClaudeAuthMode = ClaudeAuthAPIKey
+		sc.APIKey = env["ANTHROPIC_API_KEY"]
+	default:
+		if sc.BaseURL == "" {
+			return nil, PermissionPreset{}
+		}
+		sc.ClaudeAuthMode = ClaudeAuthToken
+	}
+
+	for key, field := range claudeModelEnv(sc) {
+		*field = env[key]
+	}
+	// 隐私开关：与开启值一致为 on，不存在为 off，其他值说明是用户自己设置的，保持 unmanaged
+	for _, f := range claudePrivacyFlags {
+		v, ok := env[f.envKey]
+		switch {
+		case !ok:
+			*f.field(sc) = PrivacyOff
+		case v == f.onValue:
+			*f.field(sc) = PrivacyOn
+		default:
+			*f.field(sc) = PrivacyUnmanaged
+		}
+	}
+	// 其余 env 作为额外环境变量导入
+	for k, v := range env {
+		if slices.Contains(claudeSwitcherEnvKeys, k) || validateEnvVar(k, v) != nil {
+			continue
+		}
+		if sc.ExtraEnv == nil {
+			sc.ExtraEnv = map[string]string{}
+		}
+		sc.ExtraEnv[k] = v
+	}
+
+	var rules PermissionPreset
+	if permissions, ok := settings["permissions"].(map[string]interface{}); ok {
+		rules.Allow = toStringSlice(permissions["allow"])
+		rules.Deny = toStringSlice(permissions["deny"])
+	}
+	return sc, rules
+}
+
+// claudeImportName 使用 base URL 的主机名作为导入配置的名称；
+// 没有自定义端点的 Bedrock/Vertex 配置使用“类型-区域”
+func claudeImportName(sc *ServiceConfig) string {
+	if u, err := url.Parse(sc.BaseURL); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	if usesClaudeCloud(sc) {
+		return claudeProviderType(sc) + "-" + sc.CloudRegion
+	}
+	return "Current Claude"
+}
+
+// findMatchingClaudeCode 查找端点与凭据（Bedrock/Vertex 为区域、项目等）都相同的已有配置，找不到时返回 -1
+func (c *Config) findMatchingClaudeCode(sc *ServiceConfig) int {
+	for i := range c.ClaudeCode {
+		existing := &c.ClaudeCode[i]
+		if !maps.Equal(claudeProviderEnv(existing), claudeProviderEnv(sc)) {
+			continue
+		}
+		if !usesClaudeCloud(sc) && claudeAuthMode(sc) == ClaudeAuthHelper &&
+			(claudeAuthMode(existing) != ClaudeAuthHelper || existing.APIKeyHelper != sc.APIKeyHelper) {
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+// importPermissionPreset 为导入的规则找到内容相同的预设，没有时以 name 新建；规则为空时返回空字符串
+func (c *Config) importPermissionPreset(name string, rules PermissionPreset) string {
+	if len(rules.Allow) == 0 && len(rules.Deny) == 0 {
+		return ""
+	}
+	for _, p := range c.PermissionPresets {
+		if slices.Equal(p.Allow, rules.Allow) && slices.Equal(p.Deny, rules.Deny) {
+			return p.Name
+		}
+	}
+	rules.Name = uniqueName(name, func(n string) bool { return c.FindPermissionPreset(n) != nil })
+	c.PermissionPresets = append(c.PermissionPresets, rules)
+	return rules.Name
+}
+
+// importClaudeSettingsFile 导入一个 settings 文件（不保存配置）。文件不存在或没有可导入的内容时返回 nil。
+// 导入的配置会被记为该作用域当前使用的配置（用户作用域仅在尚无活动配置时）。
+func (c *Config) importClaudeSettingsFile(path, scope string) (*ClaudeImportResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	settings := map[string]interface{}{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	sc, rules := claudeConfigFromSettings(settings)
+	if sc == nil {
+		return nil, nil
+	}
+
+	result := &ClaudeImportResult{Scope: scope, Path: path}
+	index := c.findMatchingClaudeCode(sc)
+	if index >= 0 {
+		result.Name = c.ClaudeCode[index].Name
+		result.Existing = true
+	} else {
+		sc.Name = uniqueName(claudeImportName(sc), func(n string) bool {
+			return findConfigIndexByName(c.ClaudeCode, n) >= 0
+		})
+		sc.Provider = "switcher"
+		sc.PermissionPreset = c.importPermissionPreset(sc.Name, rules)
+		c.ClaudeCode = append(c.ClaudeCode, *sc)
+		index = len(c.ClaudeCode) - 1
+		result.Name = sc.Name
+	}
+
+	if scope == ClaudeScopeUser {
+		if c.Active.ClaudeCode < 0 {
+			c.Active.ClaudeCode = index
+		}
+	} else {
+		if c.ClaudeProjects == nil {
+			c.ClaudeProjects = map[string]string{}
+		}
+		c.ClaudeProjects[path] = result.Name
+	}
+	return result, nil
+}
+
+// ImportClaudeCode 导入各作用域 settings 文件中的 Claude Code 设置，与已有配置相同的不会重复添加。
+// 返回每个找到可导入内容的文件的结果。
+func (c *Config) ImportClaudeCode(scopes []string) ([]ClaudeImportResult, error) {
+	var results []ClaudeImportResult
+	for _, scope := range scopes {
+		path, err := ClaudeSettingsPath(scope)
+		if err != nil {
+			return results, err
+		}
+		result, err := c.importClaudeSettingsFile(path, scope)
+		if err != nil {
+			return results, err
+		}
+		if result != nil {
+			results = append(results, *result)
+		}
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+	return results, c.Save()
+}
+
+// findConfigIndexByName 按名称查找配置，找不到时返回 -1
+func findConfigIndexByName(configs []ServiceConfig, name string) int {
+	for i, sc := range configs {
+		if sc.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// DroidImportResult 描述 Factory custom_models 中一个模型的导入结果
+type DroidImportResult struct {
+	Name     string // 新增或匹配到的配置名（model_display_name）
+	Existing bool   // 已有相同的配置，没有新增
+	Active   bool   // settings.json 当前选中的模型
+}
+
+// importDroidModels 导入 Factory config.json 中的全部自定义模型（不保存配置）。
+// 显示名、模型、base URL 和 API Key 都相同的模型视为已有配置；settings.json 中选中的模型会被设为活动配置。
+// config.json 不存在时返回 nil。
+func (c *Config) importDroidModels() ([]DroidImportResult, error) {
+	factoryDir := platformPaths.GetDroidConfigDir()
+	path := filepath.Join(factoryDir, "config.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var factoryConfig FactoryConfig
+	if err := json.Unmarshal(data, &factoryConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	selected, _ := readFactorySettings(filepath.Join(factoryDir, "settings.json"))["model"].(string)
+
+	var results []DroidImportResult
+	for _, model := range factoryConfig.CustomModels {
+		result := DroidImportResult{Active: selected != "" && "custom:"+model.Model == selected}
+		index := c.findImportedDroid(model)
+		if index >= 0 {
+			result.Existing = true
+		} else {
+			// 旧版本 switcher 写入的模型使用 legacyDroidProvider，导入为同样的兼容 Chat Completions 类型
+			if model.Provider == legacyDroidProvider || model.Provider == "" {
+				model.Provider = DroidProviderGeneric
+			}
+			model.ModelDisplayName = uniqueName(model.ModelDisplayName, func(n string) bool {
+				for _, d := range c.Droid {
+					if d.ModelDisplayName == n {
+						return true
+					}
+				}
+				return false
+			})
+			c.Droid = append(c.Droid, model)
+			index = len(c.Droid) - 1
+		}
+		result.Name = c.Droid[index].ModelDisplayName
+		if result.Active {
+			c.Active.Droid = index
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// findImportedDroid 按 findDroidConfigIndex 的规则查找已有配置；导入时因重名被追加了 -2、-3 等后缀的配置同样视为相同
+func (c *Config) findImportedDroid(model DroidConfig) int {
+	if index := findDroidConfigIndex(c.Droid, model); index >= 0 {
+		return index
+	}
+	for i, d := range c.Droid {
+		suffix, renamed := strings.CutPrefix(d.ModelDisplayName, model.ModelDisplayName+"-")
+		if !renamed || suffix == "" || strings.Trim(suffix, "0123456789") != "" {
+			continue
+		}
+		if d.Model == model.Model && d.BaseURL == model.BaseURL && d.APIKey == model.APIKey {
+			return i
+		}
+	}
+	return -1
+}
+
+// ImportDroid 导入 Factory 中定义的全部自定义模型并保存配置，返回每个模型的结果
+func (c *Config) ImportDroid() ([]DroidImportResult, error) {
+	results, err := c.importDroidModels()
+	if err != nil || len(results) == 0 {
+		return results, err
+	}
+	return results, c.Save()
+}
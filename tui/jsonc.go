@@ -0,0 +1,503 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JSONC（带注释的 JSON）支持：Factory 的 settings.json 允许 // 与 /* */ 注释以及末尾多余的逗号。
+// 这里只实现 switcher 需要的部分：解析为标准 JSON，以及在保留注释、顺序和格式的前提下修改顶层的字符串键。
+
+// jsoncScanner 按字节扫描 JSONC 文本
+type jsoncScanner struct {
+	data []byte
+	pos  int
+}
+
+// jsoncMember 记录顶层对象中一个成员的位置
+type jsoncMember struct {
+	key        string
+	keyStart   int
+	valueStart int
+	valueEnd   int
+}
+
+func (s *jsoncScanner) errorf(format string, args ...interface{}) error {
+	line := bytes.Count(s.data[:min(s.pos, len(s.data))], []byte("\n")) + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// skipSpace 跳过空白和注释
+func (s *jsoncScanner) skipSpace() error {
+	for s.pos < len(s.data) {
+		switch c := s.data[s.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			s.pos++
+		case c == '/' && s.pos+1 < len(s.data) && s.data[s.pos+1] == '/':
+			if end := bytes.IndexByte(s.data[s.pos:], '\n'); end >= 0 {
+				s.pos += end + 1
+			} else {
+				s.pos = len(s.data)
+			}
+		case c == '/' && s.pos+1 < len(s.data) && s.data[s.pos+1] == '*':
+			end := bytes.Index(s.data[s.pos+2:], []byte("*/"))
+			if end < 0 {
+				return s.errorf("unterminated comment")
+			}
+			s.pos += end + 4
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// scanString 读取一个字符串字面量并返回解码后的值
+func (s *jsoncScanner) scanString() (string, error) {
+	start := s.pos
+	if s.pos >= len(s.data) || s.data[s.pos] != '"' {
+		return "", s.errorf("expected string")
+	}
+	for s.pos++; s.pos < len(s.data); s.pos++ {
+		switch s.data[s.pos] {
+		case '\\':
+			s.pos++
+		case '"':
+			s.pos++
+			var v string
+			if err := json.Unmarshal(s.data[start:s.pos], &v); err != nil {
+				return "", s.errorf("invalid string: %v", err)
+			}
+			return v, nil
+		case '\n':
+			return "", s.errorf("unterminated string")
+		}
+	}
+	return "", s.errorf("unterminated string")
+}
+
+// skipValue 跳过一个值（对象、数组、字符串或字面量）
+func (s *jsoncScanner) skipValue() error {
+	if s.pos >= len(s.data) {
+		return s.errorf("unexpected end of input")
+	}
+	switch s.data[s.pos] {
+	case '{':
+		_, err := s.scanObject(nil)
+		return err
+	case '[':
+		s.pos++
+		for {
+			if err := s.skipSpace(); err != nil {
+				return err
+			}
+			if s.pos < len(s.data) && s.data[s.pos] == ']' {
+				s.pos++
+				return nil
+			}
+			if err := s.skipValue(); err != nil {
+				return err
+			}
+			if err := s.skipSpace(); err != nil {
+				return err
+			}
+			if s.pos < len(s.data) && s.data[s.pos] == ',' {
+				s.pos++
+			} else if s.pos >= len(s.data) || s.data[s.pos] != ']' {
+				return s.errorf("expected ',' or ']'")
+			}
+		}
+	case '"':
+		_, err := s.scanString()
+		return err
+	default:
+		// 数字、true、false、null：合法性由 json.Unmarshal 在 parseJSONC 中检查
+		start := s.pos
+		for s.pos < len(s.data) && bytes.IndexByte([]byte(" \t\r\n,]}/"), s.data[s.pos]) < 0 {
+			s.pos++
+		}
+		if s.pos == start {
+			return s.errorf("unexpected character %q", s.data[s.pos])
+		}
+		return nil
+	}
+}
+
+// scanObject 扫描一个对象，members 不为 nil 时记录各成员的位置；返回对象结束后的位置
+func (s *jsoncScanner) scanObject(members *[]jsoncMember) (int, error) {
+	s.pos++ // '{'
+	for {
+		if err := s.skipSpace(); err != nil {
+			return 0, err
+		}
+		if s.pos < len(s.data) && s.data[s.pos] == '}' {
+			s.pos++
+			return s.pos, nil
+		}
+		m := jsoncMember{keyStart: s.pos}
+		key, err := s.scanString()
+		if err != nil {
+			return 0, err
+		}
+		m.key = key
+		if err := s.skipSpace(); err != nil {
+			return 0, err
+		}
+		if s.pos >= len(s.data) || s.data[s.pos] != ':' {
+			return 0, s.errorf("expected ':' after %q", key)
+		}
+		s.pos++
+		if err := s.skipSpace(); err != nil {
+			return 0, err
+		}
+		m.valueStart = s.pos
+		if err := s.skipValue(); err != nil {
+			return 0, err
+		}
+		m.valueEnd = s.pos
+		if members != nil {
+			*members = append(*members, m)
+		}
+		if err := s.skipSpace(); err != nil {
+			return 0, err
+		}
+		if s.pos < len(s.data) && s.data[s.pos] == ',' {
+			s.pos++
+		} else if s.pos >= len(s.data) || s.data[s.pos] != '}' {
+			return 0, s.errorf("expected ',' or '}'")
+		}
+	}
+}
+
+// scanJSONCObject 扫描顶层对象，返回 '{' 的位置和各成员的位置
+func scanJSONCObject(data []byte) (int, []jsoncMember, error) {
+	s := &jsoncScanner{data: data}
+	if err := s.skipSpace(); err != nil {
+		return 0, nil, err
+	}
+	if s.pos >= len(data) || data[s.pos] != '{' {
+		return 0, nil, s.errorf("expected a JSON object")
+	}
+	open := s.pos
+	var members []jsoncMember
+	if _, err := s.scanObject(&members); err != nil {
+		return 0, nil, err
+	}
+	if err := s.skipSpace(); err != nil {
+		return 0, nil, err
+	}
+	if s.pos != len(data) {
+		return 0, nil, s.errorf("unexpected content after the object")
+	}
+	return open, members, nil
+}
+
+// stripJSONC 去掉注释和末尾多余的逗号，返回标准 JSON；注释替换为空格以保持行号不变
+func stripJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '"':
+			// 原样复制字符串
+			j := i + 1
+			for j < len(data) && data[j] != '"' && data[j] != '\n' {
+				if data[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(data))
+			out = append(out, data[i:j]...)
+			i = j - 1
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return append(out, data[i:]...) // 交给 json.Unmarshal 报错
+			}
+			for _, b := range data[i : i+end+4] {
+				if b == '\n' {
+					out = append(out, '\n')
+				} else {
+					out = append(out, ' ')
+				}
+			}
+			i += end + 3
+		case c == ']' || c == '}':
+			// 去掉紧挨着的末尾逗号
+			k := len(out) - 1
+			for k >= 0 && (out[k] == ' ' || out[k] == '\t' || out[k] == '\n' || out[k] == '\r') {
+				k--
+			}
+			if k >= 0 && out[k] == ',' {
+				out[k] = ' '
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// parseJSONC 把 JSONC 文本解析为顶层对象
+func parseJSONC(data []byte) (map[string]interface{}, error) {
+	if _, _, err := scanJSONCObject(data); err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{}
+	if err := json.Unmarshal(stripJSONC(data), &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// jsoncKey 把 key 编码为 JSON 字符串（Go 的 %q 转义与 JSON 不完全相同，例如 \x00、\a）
+func jsoncKey(key string) string {
+	encoded, _ := json.Marshal(key)
+	return string(encoded)
+}
+
+// setJSONCString 把顶层对象中 key 的值设为字符串 value，其余内容（注释、顺序、缩进）保持不变；
+// key 不存在时作为第一个成员插入。data 为空时返回只包含该键的新对象。无法解析时返回错误，不做任何修改。
+func setJSONCString(data []byte, key, value string) ([]byte, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return setJSONCRaw(data, key, encoded)
+}
+
+// setJSONCPath 与 setJSONCString 相同，但 path 指定嵌套对象中的键（如 model.name）：
+// 沿途已有的对象原样保留，缺失的层级（或不是对象的值）替换为新对象
+func setJSONCPath(data []byte, path []string, value string) ([]byte, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return setJSONCPathRaw(data, path, encoded)
+}
+
+// setJSONCPathRaw 把 path 处的值替换为已编码的 JSON 值 encoded，规则同 setJSONCPath
+func setJSONCPathRaw(data []byte, path []string, encoded []byte) ([]byte, error) {
+	// 找到已存在的最深一层对象，缺失的层级作为新对象插入其中
+	for n := len(path) - 1; n >= 0; n-- {
+		start, end, ok, err := jsoncObjectAt(data, path[:n])
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		// 新成员相对于该对象所在行的缩进排版
+		lineStart := bytes.LastIndexByte(data[:start], '\n') + 1
+		base := data[lineStart:start]
+		base = base[:len(base)-len(bytes.TrimLeft(base, " \t"))]
+		sub, err := setJSONCMember(data[start:end], string(base), path[n], path[n+1:], encoded)
+		if err != nil {
+			return nil, err
+		}
+		out := append([]byte{}, data[:start]...)
+		out = append(out, sub...)
+		return append(out, data[end:]...), nil
+	}
+	return nil, fmt.Errorf("empty path")
+}
+
+// jsoncObjectAt 返回 path 指向的嵌套对象在 data 中的范围；path 为空时为整个文本。
+// 对象不存在（或该值不是对象）时 ok 为 false。
+func jsoncObjectAt(data []byte, path []string) (start, end int, ok bool, err error) {
+	if len(path) == 0 {
+		return 0, len(data), true, nil
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return 0, 0, false, nil
+	}
+	if _, err := parseJSONC(data); err != nil {
+		return 0, 0, false, err
+	}
+	_, members, err := scanJSONCObject(data)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	for i := len(members) - 1; i >= 0; i-- {
+		if m := members[i]; m.key == path[0] {
+			if data[m.valueStart] != '{' {
+				return 0, 0, false, nil
+			}
+			start, end, ok, err := jsoncObjectAt(data[m.valueStart:m.valueEnd], path[1:])
+			return m.valueStart + start, m.valueStart + end, ok, err
+		}
+	}
+	return 0, 0, false, nil
+}
+
+// deleteJSONCPath 删除 path 指向的成员，其余内容保持不变；成员不存在时原样返回
+func deleteJSONCPath(data []byte, path []string) ([]byte, error) {
+	start, end, ok, err := jsoncObjectAt(data, path[:len(path)-1])
+	if err != nil || !ok {
+		return data, err
+	}
+	sub, err := deleteJSONCMember(data[start:end], path[len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	out := append([]byte{}, data[:start]...)
+	out = append(out, sub...)
+	return append(out, data[end:]...), nil
+}
+
+// deleteJSONCMember 删除对象中名为 key 的全部成员（连同其后的逗号；独占一行时连同行尾注释整行删除）
+func deleteJSONCMember(data []byte, key string) ([]byte, error) {
+	if _, err := parseJSONC(data); err != nil {
+		return nil, err
+	}
+	_, members, err := scanJSONCObject(data)
+	if err != nil {
+		return nil, err
+	}
+	// 从后往前删除，前面成员的位置不受影响
+	for i := len(members) - 1; i >= 0; i-- {
+		m := members[i]
+		if m.key != key {
+			continue
+		}
+		start, end := m.keyStart, m.valueEnd
+		lineStart := bytes.LastIndexByte(data[:start], '\n') + 1
+		ownLine := len(bytes.TrimSpace(data[lineStart:start])) == 0
+		s := &jsoncScanner{data: data, pos: end}
+		if err := s.skipSpace(); err != nil {
+			return nil, err
+		}
+		if s.pos < len(data) && data[s.pos] == ',' {
+			end = s.pos + 1
+		} else if i > 0 {
+			// 最后一个成员：去掉前一个成员后面的逗号
+			p := &jsoncScanner{data: data, pos: members[i-1].valueEnd}
+			if err := p.skipSpace(); err != nil {
+				return nil, err
+			}
+			if p.pos < start && data[p.pos] == ',' {
+				data = append(append([]byte{}, data[:p.pos]...), data[p.pos+1:]...)
+				start, end, lineStart = start-1, end-1, lineStart-1
+			}
+		}
+		if ownLine {
+			rest := end
+			for rest < len(data) && (data[rest] == ' ' || data[rest] == '\t' || data[rest] == '\r') {
+				rest++
+			}
+			// 成员后面的行尾注释一并删除
+			if bytes.HasPrefix(data[rest:], []byte("//")) {
+				if n := bytes.IndexByte(data[rest:], '\n'); n >= 0 {
+					rest += n
+				} else {
+					rest = len(data)
+				}
+			}
+			if rest < len(data) && data[rest] == '\n' {
+				start, end = lineStart, rest+1
+			}
+		}
+		data = append(append([]byte{}, data[:start]...), data[end:]...)
+		if _, members, err = scanJSONCObject(data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// setJSONCRaw 把顶层对象中 key 的值替换为已编码的 JSON 值 encoded，规则同 setJSONCString
+func setJSONCRaw(data []byte, key string, encoded []byte) ([]byte, error) {
+	return setJSONCMember(data, "", key, nil, encoded)
+}
+
+// setJSONCMember 把对象 data 中 key 的值替换为 encoded 包装在 nested 各层新对象中的值；
+// base 是对象所在行的缩进，插入空对象时新成员和右括号相对于它缩进
+func setJSONCMember(data []byte, base, key string, nested []string, encoded []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return []byte(fmt.Sprintf("{\n  %s: %s\n}\n", jsoncKey(key), jsoncNested(nested, encoded, "  ", "  "))), nil
+	}
+	if _, err := parseJSONC(data); err != nil {
+		return nil, err
+	}
+	open, members, err := scanJSONCObject(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// 重复的 key 以最后一个为准，与 JSON 解析一致
+	for i := len(members) - 1; i >= 0; i-- {
+		if m := members[i]; m.key == key {
+			indent, ownLine := jsoncLineIndent(data, open, m.keyStart)
+			value := jsoncNested(nested, encoded, "", "")
+			if ownLine {
+				value = jsoncNested(nested, encoded, indent, jsoncIndentUnit(base, indent))
+			}
+			out := append([]byte{}, data[:m.valueStart]...)
+			out = append(out, value...)
+			return append(out, data[m.valueEnd:]...), nil
+		}
+	}
+
+	// 插入为第一个成员，缩进沿用原来的第一个成员
+	var insert string
+	if len(members) > 0 {
+		if indent, ownLine := jsoncLineIndent(data, open, members[0].keyStart); ownLine {
+			value := jsoncNested(nested, encoded, indent, jsoncIndentUnit(base, indent))
+			insert = fmt.Sprintf("\n%s%s: %s,", indent, jsoncKey(key), value)
+		} else {
+			insert = fmt.Sprintf(" %s: %s,", jsoncKey(key), jsoncNested(nested, encoded, "", ""))
+		}
+	} else {
+		indent := base + "  "
+		insert = fmt.Sprintf("\n%s%s: %s", indent, jsoncKey(key), jsoncNested(nested, encoded, indent, "  "))
+		if closing := bytes.LastIndexByte(data, '}'); !bytes.Contains(data[open:closing], []byte("\n")) {
+			// 空对象 {} 展开为多行
+			insert += "\n" + base
+		}
+	}
+	out := append([]byte{}, data[:open+1]...)
+	out = append(out, insert...)
+	return append(out, data[open+1:]...), nil
+}
+
+// jsoncLineIndent 返回 pos 所在行的缩进；该行在 pos 之前还有其他内容（或位于 open 之前）时 ownLine 为 false
+func jsoncLineIndent(data []byte, open, pos int) (string, bool) {
+	lineStart := bytes.LastIndexByte(data[:pos], '\n') + 1
+	prefix := data[lineStart:pos]
+	if lineStart <= open || len(bytes.TrimSpace(prefix)) != 0 {
+		return "", false
+	}
+	return string(prefix), true
+}
+
+// jsoncIndentUnit 根据对象所在行的缩进 base 和成员缩进 indent 推断每层的缩进，推断不出时使用两个空格
+func jsoncIndentUnit(base, indent string) string {
+	if unit, ok := strings.CutPrefix(indent, base); ok && unit != "" {
+		return unit
+	}
+	return "  "
+}
+
+// jsoncNested 把 encoded 依次包装为 keys 各层的新对象；unit 为空时写成单行，
+// 否则每层换行缩进，indent 是最外层成员的缩进
+func jsoncNested(keys []string, encoded []byte, indent, unit string) []byte {
+	if len(keys) == 0 {
+		return encoded
+	}
+	inner := jsoncNested(keys[1:], encoded, indent+unit, unit)
+	if unit == "" {
+		return []byte(fmt.Sprintf("{%s: %s}", jsoncKey(keys[0]), inner))
+	}
+	return []byte(fmt.Sprintf("{\n%s%s: %s\n%s}", indent+unit, jsoncKey(keys[0]), inner, indent))
+}
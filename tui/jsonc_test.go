@@ -0,0 +1,199 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetJSONCStringPreservesFormatting(t *testing.T) {
+	cases := []struct {
+		name, in, want string
+	}{
+		{
+			name: "replace with comments and trailing comma",
+			in: `// Factory CLI Settings
+{
+  // 默认模型
+  "model": "claude-sonnet", /* 旧值 */
+  "autonomyLevel": "auto-low",
+  "hooks": { "a": [1, 2,], },
+}
+`,
+			want: `// Factory CLI Settings
+{
+  // 默认模型
+  "model": "custom:glm", /* 旧值 */
+  "autonomyLevel": "auto-low",
+  "hooks": { "a": [1, 2,], },
+}
+`,
+		},
+		{
+			name: "insert keeps indentation",
+			in:   "{\n\t\"diffMode\": \"github\" // inline\n}\n",
+			want: "{\n\t\"model\": \"custom:glm\",\n\t\"diffMode\": \"github\" // inline\n}\n",
+		},
+		{
+			name: "empty object",
+			in:   "{}",
+			want: "{\n  \"model\": \"custom:glm\"\n}",
+		},
+		{
+			name: "comment-like text inside strings",
+			in:   `{"url": "https://x//y", "model": "a/*b*/"}`,
+			want: `{"url": "https://x//y", "model": "custom:glm"}`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := setJSONCString([]byte(tc.in), "model", "custom:glm")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Fatalf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+			parsed, err := parseJSONC(got)
+			if err != nil || parsed["model"] != "custom:glm" {
+				t.Fatalf("result does not parse back: %v (err=%v)", parsed, err)
+			}
+		})
+	}
+}
+
+func TestSetJSONCStringRejectsInvalidInput(t *testing.T) {
+	for _, in := range []string{`{"model": }`, `{"a": 1 "b": 2}`, `{"a": "x"} trailing`, `/* open {"a": 1}`, `[1, 2]`} {
+		if _, err := setJSONCString([]byte(in), "model", "m"); err == nil {
+			t.Fatalf("setJSONCString(%q) should fail", in)
+		}
+	}
+}
+
+func TestSwitchDroidDoesNotRewriteUnparsableSettings(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	settingsPath := filepath.Join(dir, ".factory", "settings.json")
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	broken := "{\n  \"model\": \"custom:old\"\n  \"diffMode\": \"github\"\n}\n"
+	if err := os.WriteFile(settingsPath, []byte(broken), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	droid := DroidConfig{ModelDisplayName: "a", Model: "ma", BaseURL: "https://a", APIKey: "k", Provider: DroidProviderGeneric}
+	if err := c.SwitchDroid(&droid); err == nil {
+		t.Fatalf("switching with an unparsable settings.json should fail")
+	}
+	if data, _ := os.ReadFile(settingsPath); string(data) != broken {
+		t.Fatalf("settings.json was modified:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".factory", "config.json")); !os.IsNotExist(err) {
+		t.Fatalf("config.json should not be written when settings.json cannot be updated")
+	}
+}
+
+func TestSetJSONCPathKeepsNestedObjects(t *testing.T) {
+	in := "{\n  // 模型设置\n  \"model\": {\n    \"maxSessionTurns\": 10, // 保留\n  },\n  \"theme\": \"dark\"\n}\n"
+	want := "{\n  // 模型设置\n  \"model\": {\n    \"name\": \"gemini-2.5-pro\",\n    \"maxSessionTurns\": 10, // 保留\n  },\n  \"theme\": \"dark\"\n}\n"
+	got, err := setJSONCPath([]byte(in), []string{"model", "name"}, "gemini-2.5-pro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	got, err = setJSONCPath([]byte(`{"theme": "dark"}`), []string{"security", "auth", "selectedType"}, "gemini-api-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := parseJSONC(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, _ := parsed["security"].(map[string]interface{})["auth"].(map[string]interface{})
+	if auth["selectedType"] != "gemini-api-key" || parsed["theme"] != "dark" {
+		t.Fatalf("unexpected result %s", got)
+	}
+}
+
+func TestSetJSONCPathIndentsInsertedMembers(t *testing.T) {
+	// 插入空对象时相对于父对象所在行缩进
+	in := "{\n  \"model\": {},\n  \"theme\": \"dark\"\n}\n"
+	want := "{\n  \"model\": {\n    \"name\": \"gemini-2.5-pro\"\n  },\n  \"theme\": \"dark\"\n}\n"
+	got, err := setJSONCPath([]byte(in), []string{"model", "name"}, "gemini-2.5-pro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// 缺失的嵌套层级逐层换行，沿用文件的缩进
+	in = "{\n\t\"theme\": \"dark\"\n}\n"
+	want = "{\n\t\"security\": {\n\t\t\"auth\": {\n\t\t\t\"selectedType\": \"gemini-api-key\"\n\t\t}\n\t},\n\t\"theme\": \"dark\"\n}\n"
+	got, err = setJSONCPath([]byte(in), []string{"security", "auth", "selectedType"}, "gemini-api-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSetJSONCEncodesKeysAsJSON(t *testing.T) {
+	// %q 会把 \a、\x01 写成 JSON 不支持的转义
+	key := "a\a\x01"
+	for _, in := range []string{"", `{"theme": "dark"}`, "{\n  \"theme\": \"dark\"\n}\n", "{}"} {
+		got, err := setJSONCString([]byte(in), key, "v")
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := parseJSONC(got)
+		if err != nil {
+			t.Fatalf("setJSONCString(%q) produced invalid JSON %s: %v", in, got, err)
+		}
+		if parsed[key] != "v" {
+			t.Fatalf("setJSONCString(%q) = %s", in, got)
+		}
+	}
+
+	got, err := setJSONCPath([]byte(`{"provider": {}}`), []string{"provider", key, "name"}, "v")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := parseJSONC(got)
+	if err != nil {
+		t.Fatalf("setJSONCPath produced invalid JSON %s: %v", got, err)
+	}
+	if nested, _ := parsed["provider"].(map[string]interface{})[key].(map[string]interface{}); nested["name"] != "v" {
+		t.Fatalf("setJSONCPath = %s", got)
+	}
+}
+
+func TestDeleteJSONCPathKeepsOtherMembers(t *testing.T) {
+	in := "{\n  \"provider\": {\n    \"a\": {\"npm\": \"x\"}, // 保留\n    \"b\": {\n      \"npm\": \"y\"\n    }\n  },\n  \"theme\": \"dark\"\n}\n"
+	want := "{\n  \"provider\": {\n    \"a\": {\"npm\": \"x\"} // 保留\n  },\n  \"theme\": \"dark\"\n}\n"
+	got, err := deleteJSONCPath([]byte(in), []string{"provider", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	got, err = deleteJSONCPath([]byte(want), []string{"provider", "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"provider\": {\n  },\n  \"theme\": \"dark\"\n}\n"; string(got) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// 路径不存在时不修改内容
+	if got, err := deleteJSONCPath([]byte(in), []string{"missing", "b"}); err != nil || string(got) != in {
+		t.Fatalf("missing path changed the content: %s (err=%v)", got, err)
+	}
+}
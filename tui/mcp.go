@@ -0,0 +1,197 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// MCPServer 描述一个随配置切换的 MCP 服务器：
+// 本地进程（Command/Args/Env）或远程服务（URL），二者取其一。
+type MCPServer struct {
+	Name    string            `json:"name"`
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	URL     string            `json:"url,omitempty"`
+}
+
+func (s MCPServer) validate() error {
+	if !isValidTomlSectionName(s.Name) {
+		return fmt.Errorf("invalid MCP server name %q: must contain only letters, digits, underscores, and hyphens", s.Name)
+	}
+	if (s.Command == "") == (s.URL == "") {
+		return fmt.Errorf("MCP server %q must set exactly one of command or url", s.Name)
+	}
+	return nil
+}
+
+// validateMCPServers 校验服务器定义，并拒绝重名的服务器
+func validateMCPServers(servers []MCPServer) error {
+	seen := map[string]bool{}
+	for _, s := range servers {
+		if err := s.validate(); err != nil {
+			return err
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("duplicate MCP server name %q", s.Name)
+		}
+		seen[s.Name] = true
+	}
+	return nil
+}
+
+// tomlBody 返回 [mcp_servers.NAME] 段的内容
+func (s MCPServer) tomlBody() string {
+	var lines []string
+	if s.URL != "" {
+		lines = append(lines, fmt.Sprintf(`url = "%s"`, escapeTomlString(s.URL)))
+	} else {
+		lines = append(lines, fmt.Sprintf(`command = "%s"`, escapeTomlString(s.Command)))
+		if len(s.Args) > 0 {
+			args := make([]string, len(s.Args))
+			for i, a := range s.Args {
+				args[i] = fmt.Sprintf(`"%s"`, escapeTomlString(a))
+			}
+			lines = append(lines, "args = ["+strings.Join(args, ", ")+"]")
+		}
+	}
+	if len(s.Env) > 0 {
+		keys := make([]string, 0, len(s.Env))
+		for k := range s.Env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, len(keys))
+		for i, k := range keys {
+			pairs[i] = fmt.Sprintf(`"%s" = "%s"`, escapeTomlString(k), escapeTomlString(s.Env[k]))
+		}
+		lines = append(lines, "env = { "+strings.Join(pairs, ", ")+" }")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// claudeEntry 返回 Claude Code mcpServers 中的条目
+func (s MCPServer) claudeEntry() map[string]interface{} {
+	if s.URL != "" {
+		return map[string]interface{}{
+			"type": "http",
+			"url":  s.URL,
+		}
+	}
+	args := s.Args
+	if args == nil {
+		args = []string{}
+	}
+	entry := map[string]interface{}{
+		"type":    "stdio",
+		"command": s.Command,
+		"args":    args,
+	}
+	if len(s.Env) > 0 {
+		entry["env"] = s.Env
+	}
+	return entry
+}
+
+// applyCodexMCPServers 把配置的 MCP 服务器写入 config.toml 内容：
+// 先移除上一个配置写入的服务器，再写入当前配置的服务器；用户手动添加的服务器保持不变，
+// 与其同名时拒绝切换，避免覆盖后无法恢复。返回新的内容和本次由 switcher 创建的服务器名称。
+func applyCodexMCPServers(content string, previous []string, servers []MCPServer) (string, []string, error) {
+	if err := validateMCPServers(servers); err != nil {
+		return content, nil, err
+	}
+
+	for _, name := range previous {
+		content, _ = removeTomlSection(content, "mcp_servers."+name)
+	}
+
+	var owned []string
+	for _, s := range servers {
+		section := "mcp_servers." + s.Name
+		if hasTomlSection(content, section) {
+			return "", nil, fmt.Errorf("MCP server %q is already defined in config.toml and was not added by switcher; rename one of them", s.Name)
+		}
+		owned = append(owned, s.Name)
+		content = updateOrAddTomlSection(content, section, s.tomlBody())
+	}
+	return content, owned, nil
+}
+
+// readClaudeMCPServers 读取 Claude Code 状态文件，返回完整内容和其中的 mcpServers；文件不存在时返回空内容
+func readClaudeMCPServers(path string) (map[string]interface{}, map[string]interface{}, error) {
+	state := make(map[string]interface{})
+	if data, err := os.ReadFile(path); err == nil {
+		// ~/.claude.json 保存了 Claude Code 的大量状态，解析失败时拒绝写入，避免丢失数据
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	mcpServers, _ := state["mcpServers"].(map[string]interface{})
+	if mcpServers == nil {
+		mcpServers = map[string]interface{}{}
+	}
+	return state, mcpServers, nil
+}
+
+// checkClaudeMCPServers 在写入任何文件之前检查配置的服务器能否写入 path：
+// 定义无效、与用户手动添加的服务器同名或文件无法解析时返回错误。
+func checkClaudeMCPServers(path string, previous []string, servers []MCPServer) error {
+	if err := validateMCPServers(servers); err != nil {
+		return err
+	}
+	if len(servers) == 0 {
+		return nil
+	}
+	_, mcpServers, err := readClaudeMCPServers(path)
+	if err != nil {
+		return err
+	}
+	for _, s := range servers {
+		if _, exists := mcpServers[s.Name]; exists && !slices.Contains(previous, s.Name) {
+			return fmt.Errorf("MCP server %q is already defined in %s and was not added by switcher; rename one of them", s.Name, path)
+		}
+	}
+	return nil
+}
+
+// applyClaudeMCPServers 更新 Claude Code 用户级配置（~/.claude.json）中的 mcpServers，
+// 规则与 Codex 相同：只移除上一个配置写入的服务器，拒绝覆盖用户的同名服务器。
+// 返回本次由 switcher 创建的服务器名称。
+func applyClaudeMCPServers(path string, previous []string, servers []MCPServer) ([]string, error) {
+	if err := checkClaudeMCPServers(path, previous, servers); err != nil {
+		return nil, err
+	}
+	// 既没有要移除的也没有要写入的服务器时，不触碰 ~/.claude.json
+	if len(previous) == 0 && len(servers) == 0 {
+		return nil, nil
+	}
+
+	state, mcpServers, err := readClaudeMCPServers(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range previous {
+		delete(mcpServers, name)
+	}
+	var owned []string
+	for _, s := range servers {
+		owned = append(owned, s.Name)
+		mcpServers[s.Name] = s.claudeEntry()
+	}
+	state["mcpServers"] = mcpServers
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Claude state: %w", err)
+	}
+	if err := writeFileWithPerms(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return owned, nil
+}
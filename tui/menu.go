@@ -22,9 +22,9 @@ const (
 
 // 配置类型字段数量
 const (
-	ClaudeCodeFieldCount = 11 // Name, BaseURL, APIKey, EffortLevel, HaikuModel, OpusModel, SonnetModel, AutocompactPct, HTTPProxy, HTTPSProxy, NOProxy
-	CodexFieldCount      = 7 // Name, BaseURL, APIKey, Model, WireAPI, AuthMethod, Reasoning
-	DroidFieldCount      = 4
+	ClaudeCodeFieldCount = 27 // Name, BaseURL, APIKey, EffortLevel, HaikuModel, OpusModel, SonnetModel, MainModel, SmallFastModel, SubagentModel, AutocompactPct, HTTPProxy, HTTPSProxy, NOProxy, ProviderType, Region, AWSProfile, VertexProject, AuthMode, KeyHelper, TargetDir, PermissionPreset, 4 privacy flags, ExtraEnv
+	CodexFieldCount      = 14 // Name, BaseURL, APIKey, Model, WireAPI, AuthMethod, Reasoning, ProviderMode, Approval, Sandbox, Verbosity, ReasoningSummary, ContextWindow, MaxOutputTokens
+	DroidFieldCount      = 7  // DisplayName, Model, BaseURL, APIKey, Provider, MaxTokens, ExtraHeaders
 )
 
 type state int
@@ -47,6 +47,15 @@ const (
 	confirmExitAddClaudeCode
 	confirmExitAddCodex
 	confirmExitAddDroid
+	editClaudeEnv         // Claude Code 表单中的额外环境变量编辑器
+	permissionPresetList  // Claude Code 权限预设列表
+	editPermissionPreset  // 新增/编辑权限预设
+	editPresetRules       // 权限预设表单中的规则编辑器，每行一条规则
+	driftView             // 活动配置与磁盘实际设置的差异
+	toolList              // 通用工具（ToolAdapter）的配置列表
+	editTool              // 新增/编辑通用工具配置
+	confirmDeleteTool     // 确认删除通用工具配置
+	confirmProjectSecrets // 确认把凭证写入项目作用域的 settings.json
 )
 
 type model struct {
@@ -66,18 +75,52 @@ type model struct {
 	sortedCodex      []ServiceConfig // 排序后的 Codex 配置列表
 	sortedDroid      []DroidConfig   // 排序后的 Droid 配置列表
 	windowHeight     int             // 终端窗口高度
+	envEntries       []envEntry      // 额外环境变量编辑器中的行
+	envRow           int             // 编辑器当前行
+	envColumn        int             // 编辑器当前列：0=变量名，1=值
+	envReturnState   state           // 编辑器关闭后返回的表单状态
+	claudeScope      string          // Claude Code 切换写入的作用域，空字符串等同于用户作用域
+	presetCursor     int             // 权限预设列表中的光标
+	presetIndex      int             // 正在编辑的权限预设索引，-1 表示新增
+	presetField      int             // 权限预设表单中的当前字段
+	presetName       string          // 权限预设表单：名称
+	presetAllow      []string        // 权限预设表单：allow 规则，每项一条
+	presetDeny       []string        // 权限预设表单：deny 规则，每项一条
+	ruleEntries      []string        // 规则编辑器中的行，末尾始终为一行空白行
+	ruleRow          int             // 规则编辑器当前行
+	drift            *driftReport    // 差异视图中的对比结果
+	driftReturnState state           // 差异视图关闭后返回的列表状态
+	droidMaxTokens   string          // Droid 表单：max_tokens 的输入文本
+	tool             ToolAdapter     // 通用列表/表单当前管理的工具
+	toolOrder        []int           // 通用列表中各行对应的配置索引（活动配置在前）
+	toolEditIndex    int             // 正在编辑的通用工具配置索引，-1 表示新增
+	toolForm         []string        // 通用表单的输入：配置名，然后依次为 tool.Fields()
 }
 
 func (m model) hasFormContent() bool {
-	return m.formData.Name != "" || m.formData.Provider != "" || m.formData.BaseURL != "" || m.formData.APIKey != "" || m.formData.Model != "" || m.formData.WireAPI != "" || m.formData.EnvKey != "" || m.formData.ModelReasoningEffort != "" || m.formData.EffortLevel != "" || m.formData.ClaudeDefaultHaikuModel != "" || m.formData.ClaudeDefaultOpusModel != "" || m.formData.ClaudeDefaultSonnetModel != "" || m.formData.AutocompactPctOverride != "" || m.formData.HTTPProxy != "" || m.formData.HTTPSProxy != "" || m.formData.NOProxy != ""
+	return m.formData.Name != "" || m.formData.Provider != "" || m.formData.BaseURL != "" || m.formData.APIKey != "" || m.formData.Model != "" || m.formData.WireAPI != "" || m.formData.EnvKey != "" || m.formData.ModelReasoningEffort != "" || m.formData.ProviderMode != "" || m.formData.EffortLevel != "" || m.formData.ClaudeDefaultHaikuModel != "" || m.formData.ClaudeDefaultOpusModel != "" || m.formData.ClaudeDefaultSonnetModel != "" || m.formData.ClaudeMainModel != "" || m.formData.ClaudeSmallFastModel != "" || m.formData.ClaudeSubagentModel != "" || m.formData.AutocompactPctOverride != "" || m.formData.HTTPProxy != "" || m.formData.HTTPSProxy != "" || m.formData.NOProxy != "" || m.formData.ApprovalPolicy != "" || m.formData.SandboxMode != "" || m.formData.ModelVerbosity != "" || m.formData.ModelReasoningSummary != "" || m.formData.ModelContextWindow != "" || m.formData.ModelMaxOutputTokens != "" || len(m.formData.ExtraEnv) > 0 || m.formData.APIKeyHelper != "" || m.formData.PermissionPreset != "" || m.formData.TargetDir != "" || m.formData.CloudRegion != "" || m.formData.AWSProfile != "" || m.formData.VertexProjectID != "" ||
+		m.formData.DisableNonessentialTraffic != "" || m.formData.DisableTelemetry != "" || m.formData.DoNotTrack != "" || m.formData.MaintainProjectWorkingDir != ""
 }
 
+// hasRequiredServiceFields 检查必填字段；使用内置 openai provider 时 Base URL 可以留空（使用官方地址），
+// Claude Code 使用 apiKeyHelper 认证时以 helper 命令代替 API Key
 func (m model) hasRequiredServiceFields() bool {
-	return m.formData.Name != "" && m.formData.BaseURL != "" && m.formData.APIKey != ""
+	// Bedrock/Vertex 通过云厂商凭据认证，只需要区域（Vertex 还需要项目）
+	switch m.formData.ClaudeProviderType {
+	case ClaudeProviderBedrock:
+		return m.formData.Name != "" && m.formData.CloudRegion != ""
+	case ClaudeProviderVertex:
+		return m.formData.Name != "" && m.formData.CloudRegion != "" && m.formData.VertexProjectID != ""
+	}
+	hasCredential := m.formData.APIKey != ""
+	if m.formData.ClaudeAuthMode == ClaudeAuthHelper {
+		hasCredential = m.formData.APIKeyHelper != ""
+	}
+	return m.formData.Name != "" && (m.formData.BaseURL != "" || m.formData.UsesBuiltinOpenAI()) && hasCredential
 }
 
 func (m model) hasDroidFormContent() bool {
-	return m.droidFormData.ModelDisplayName != "" || m.droidFormData.Model != "" || m.droidFormData.BaseURL != "" || m.droidFormData.APIKey != ""
+	return m.droidFormData.ModelDisplayName != "" || m.droidFormData.Model != "" || m.droidFormData.BaseURL != "" || m.droidFormData.APIKey != "" || m.droidMaxTokens != "" || len(m.droidFormData.ExtraHeaders) > 0
 }
 
 func (m model) View() string {
@@ -117,6 +160,24 @@ func (m model) View() string {
 		content = m.confirmExitAddView("Codex")
 	case confirmExitAddDroid:
 		content = m.confirmExitAddView("Droid")
+	case editClaudeEnv:
+		content = m.envEditorView()
+	case permissionPresetList:
+		content = m.presetListView()
+	case editPermissionPreset:
+		content = m.presetEditorView()
+	case editPresetRules:
+		content = m.ruleEditorView()
+	case driftView:
+		content = m.driftView()
+	case toolList:
+		content = m.toolListView()
+	case editTool:
+		content = m.toolFormView()
+	case confirmDeleteTool:
+		content = m.confirmDeleteView(m.tool.DisplayName())
+	case confirmProjectSecrets:
+		content = m.confirmProjectSecretsView()
 	}
 
 	if m.error != "" {
@@ -130,28 +191,15 @@ func (m model) mainMenuView() string {
 	version := GetVersion()
 	title := headerViewWithVersion(t("app_title"), version)
 
-	activeClaude := t("none")
-	if active := m.config.GetActiveClaudeCode(); active != nil {
-		activeClaude = active.Name
-	}
-
-	activeCodex := t("none")
-	if active := m.config.GetActiveCodex(); active != nil {
-		activeCodex = active.Name
-	}
-
-	activeDroid := t("none")
-	if active := m.config.GetActiveDroid(); active != nil {
-		activeDroid = active.ModelDisplayName
-	}
-
-	items := []string{
-		fmt.Sprintf(t("menu_claude"), activeClaude),
-		fmt.Sprintf(t("menu_codex"), activeCodex),
-		fmt.Sprintf(t("menu_droid"), activeDroid),
-		t("menu_switch_lang"),
-		t("menu_exit"),
+	var items []string
+	for _, a := range toolAdapters {
+		active := ActiveToolName(m.config, a)
+		if active == "" {
+			active = t("none")
+		}
+		items = append(items, toolMenuLabel(a, active))
 	}
+	items = append(items, t("menu_switch_lang"), t("menu_exit"))
 
 	var content strings.Builder
 	content.WriteString(title)
@@ -192,18 +240,15 @@ func (m model) addConfigView(serviceType string) string {
 		if m.formData.ModelReasoningEffort == "" {
 			m.formData.ModelReasoningEffort = DefaultModelReasoningEffort
 		}
+		if m.formData.ProviderMode == "" {
+			m.formData.ProviderMode = CodexProviderModeCustom
+		}
 
-		fields = []struct {
-			label string
-			value string
-		}{
-			{t("field_name"), m.formData.Name},
-			{t("field_base_url"), m.formData.BaseURL},
-			{t("field_api_key"), m.formData.APIKey},
-			{t("field_model"), m.formData.Model},
-			{t("field_wire_api"), m.formData.WireAPI},
-			{t("field_auth_method"), m.formData.AuthMethod},
-			{t("field_reasoning"), m.formData.ModelReasoningEffort},
+		for _, f := range m.codexFormFields() {
+			fields = append(fields, struct {
+				label string
+				value string
+			}{f.label, *f.value})
 		}
 	} else {
 		// 设置默认值
@@ -213,25 +258,23 @@ func (m model) addConfigView(serviceType string) string {
 		if m.formData.AutocompactPctOverride == "" {
 			m.formData.AutocompactPctOverride = DefaultClaudeAutocompactPct
 		}
+		if m.formData.ClaudeAuthMode == "" {
+			m.formData.ClaudeAuthMode = ClaudeAuthToken
+		}
+		if m.formData.ClaudeProviderType == "" {
+			m.formData.ClaudeProviderType = ClaudeProviderAnthropic
+		}
 
-		fields = []struct {
-			label string
-			value string
-		}{
-			{t("field_name"), m.formData.Name},
-			{t("field_base_url"), m.formData.BaseURL},
-			{t("field_api_key"), m.formData.APIKey},
-			{t("field_effort_level"), m.formData.EffortLevel},
-			{t("field_haiku_model"), m.formData.ClaudeDefaultHaikuModel},
-			{t("field_opus_model"), m.formData.ClaudeDefaultOpusModel},
-			{t("field_sonnet_model"), m.formData.ClaudeDefaultSonnetModel},
-			{t("field_autocompact_pct"), m.formData.AutocompactPctOverride},
-			{t("field_http_proxy"), m.formData.HTTPProxy},
-			{t("field_https_proxy"), m.formData.HTTPSProxy},
-			{t("field_no_proxy"), m.formData.NOProxy},
+		for _, f := range m.claudeFormFields() {
+			fields = append(fields, struct {
+				label string
+				value string
+			}{f.label, *f.value})
 		}
 	}
 
+	formFields := m.serviceFormFields()
+
 	var content strings.Builder
 	content.WriteString(title)
 	content.WriteString("\n\n")
@@ -248,38 +291,10 @@ func (m model) addConfigView(serviceType string) string {
 			highlight = fieldHighlightStyle.Render(" ◀")
 		}
 
-		// 对于Wire API字段，显示选择选项
+		// 对于选择字段和编辑器字段，显示选择选项或操作提示
 		displayValue := field.value
-		if serviceType == "Codex" && i == FieldWireAPI { // Wire API字段
-			if m.formField == i {
-				displayValue = field.value + " " + t("hint_select")
-			} else {
-				displayValue = field.value
-			}
-		}
-		// 对于认证方式字段，显示选择选项
-		if serviceType == "Codex" && i == FieldAuthMethod {
-			if m.formField == i {
-				displayValue = field.value + " " + t("hint_select")
-			} else {
-				displayValue = field.value
-			}
-		}
-		// 对于推理强度字段，显示选择选项
-		if serviceType == "Codex" && i == FieldModelReasoningEffort { // 推理强度字段
-			if m.formField == i {
-				displayValue = field.value + " " + t("hint_select")
-			} else {
-				displayValue = field.value
-			}
-		}
-		// 对于Claude推理强度字段，显示选择选项
-		if serviceType != "Codex" && i == 3 {
-			if m.formField == i {
-				displayValue = field.value + " " + t("hint_select")
-			} else {
-				displayValue = field.value
-			}
+		if kind := formFields[i].kind; kind == fieldChoice || kind == fieldEditor || formFields[i].disabled {
+			displayValue = formFields[i].display(m.formField == i)
 		}
 
 		inner.WriteString(fmt.Sprintf("%s %s:%s %s\n", prefix, field.label, highlight, displayValue))
@@ -288,6 +303,7 @@ func (m model) addConfigView(serviceType string) string {
 	content.WriteString(boxStyle.Render(inner.String()))
 	content.WriteString("\n")
 	content.WriteString(statusBarView(t("form_nav_field"), t("form_nav_save"), t("form_nav_cancel"), ""))
+	content.WriteString(m.codexFormWarning(serviceType))
 
 	return content.String()
 }
@@ -305,17 +321,11 @@ func (m model) editConfigView(serviceType string) string {
 	}
 
 	if serviceType == "Codex" {
-		fields = []struct {
-			label string
-			value string
-		}{
-			{t("field_name"), m.formData.Name},
-			{t("field_base_url"), m.formData.BaseURL},
-			{t("field_api_key"), m.formData.APIKey},
-			{t("field_model"), m.formData.Model},
-			{t("field_wire_api"), m.formData.WireAPI},
-			{t("field_auth_method"), m.formData.AuthMethod},
-			{t("field_reasoning"), m.formData.ModelReasoningEffort},
+		for _, f := range m.codexFormFields() {
+			fields = append(fields, struct {
+				label string
+				value string
+			}{f.label, *f.value})
 		}
 	} else {
 		// 设置默认值
@@ -325,25 +335,23 @@ func (m model) editConfigView(serviceType string) string {
 		if m.formData.AutocompactPctOverride == "" {
 			m.formData.AutocompactPctOverride = DefaultClaudeAutocompactPct
 		}
+		if m.formData.ClaudeAuthMode == "" {
+			m.formData.ClaudeAuthMode = ClaudeAuthToken
+		}
+		if m.formData.ClaudeProviderType == "" {
+			m.formData.ClaudeProviderType = ClaudeProviderAnthropic
+		}
 
-		fields = []struct {
-			label string
-			value string
-		}{
-			{t("field_name"), m.formData.Name},
-			{t("field_base_url"), m.formData.BaseURL},
-			{t("field_api_key"), m.formData.APIKey},
-			{t("field_effort_level"), m.formData.EffortLevel},
-			{t("field_haiku_model"), m.formData.ClaudeDefaultHaikuModel},
-			{t("field_opus_model"), m.formData.ClaudeDefaultOpusModel},
-			{t("field_sonnet_model"), m.formData.ClaudeDefaultSonnetModel},
-			{t("field_autocompact_pct"), m.formData.AutocompactPctOverride},
-			{t("field_http_proxy"), m.formData.HTTPProxy},
-			{t("field_https_proxy"), m.formData.HTTPSProxy},
-			{t("field_no_proxy"), m.formData.NOProxy},
+		for _, f := range m.claudeFormFields() {
+			fields = append(fields, struct {
+				label string
+				value string
+			}{f.label, *f.value})
 		}
 	}
 
+	formFields := m.serviceFormFields()
+
 	var inner strings.Builder
 	for i, field := range fields {
 		prefix := "  "
@@ -360,48 +368,19 @@ func (m model) editConfigView(serviceType string) string {
 			displayValue = field.value + " " + t("hint_editing")
 		}
 
-		// 对于Wire API字段，显示选择选项
-		if serviceType == "Codex" && i == FieldWireAPI { // Wire API字段
-			if m.formField == i {
-				displayValue = field.value + " " + t("hint_select")
-			} else {
-				displayValue = field.value
-			}
-		}
-
-		// 对于认证方式字段，显示选择选项
-		if serviceType == "Codex" && i == FieldAuthMethod {
-			if m.formField == i {
-				displayValue = field.value + " " + t("hint_select")
-			} else {
-				displayValue = field.value
-			}
-		}
-
-		// 对于推理强度字段，显示选择选项
-		if serviceType == "Codex" && i == FieldModelReasoningEffort { // 推理强度字段
-			if m.formField == i {
-				displayValue = field.value + " " + t("hint_select")
-			} else {
-				displayValue = field.value
-			}
-		}
-		// 对于Claude推理强度字段，显示选择选项
-		if serviceType != "Codex" && i == 3 {
-			if m.formField == i {
-				displayValue = field.value + " " + t("hint_select")
-			} else {
-				displayValue = field.value
-			}
+		// 对于选择字段和编辑器字段，显示选择选项或操作提示
+		if kind := formFields[i].kind; kind == fieldChoice || kind == fieldEditor || formFields[i].disabled {
+			displayValue = formFields[i].display(m.formField == i)
 		}
 
 		highlight := ""
 		if m.formField == i {
-			if serviceType == "Codex" && (i == FieldWireAPI || i == FieldAuthMethod || i == FieldModelReasoningEffort) { // Wire API、认证方式和推理强度字段
+			switch {
+			case formFields[i].disabled: // 不适用的字段不显示输入提示
+			case formFields[i].kind == fieldChoice: // Wire API、认证方式、推理强度等选择字段
 				highlight = fieldHighlightStyle.Render(" " + t("hint_use_arrows"))
-			} else if serviceType != "Codex" && i == 3 {
-				highlight = fieldHighlightStyle.Render(" " + t("hint_use_arrows"))
-			} else {
+			case formFields[i].kind == fieldEditor: // 操作提示已包含在显示值中
+			default:
 				highlight = fieldHighlightStyle.Render(" " + t("hint_input"))
 			}
 		}
@@ -412,6 +391,7 @@ func (m model) editConfigView(serviceType string) string {
 	content.WriteString(boxStyle.Render(inner.String()))
 	content.WriteString("\n")
 	content.WriteString(statusBarView(t("form_nav_field"), t("form_nav_save"), t("form_nav_cancel"), ""))
+	content.WriteString(m.codexFormWarning(serviceType))
 
 	// 添加当前编辑状态提示
 	if m.formField >= 0 && m.formField < len(fields) {
@@ -424,6 +404,14 @@ func (m model) editConfigView(serviceType string) string {
 	return content.String()
 }
 
+// codexFormWarning 在 Codex 表单选择内置 openai 模式时提示：OPENAI_* 环境变量对整个 shell 生效
+func (m model) codexFormWarning(serviceType string) string {
+	if serviceType != "Codex" || !m.formData.UsesBuiltinOpenAI() {
+		return ""
+	}
+	return "\n" + fieldHighlightStyle.Render(t("warn_codex_openai_env"))
+}
+
 // confirmDeleteView 显示删除确认对话框
 func (m model) confirmDeleteView(serviceType string) string {
 	var configName string
@@ -431,6 +419,8 @@ func (m model) confirmDeleteView(serviceType string) string {
 		configName = m.config.ClaudeCode[m.deleteIndex].Name
 	} else if m.state == confirmDeleteCodex && m.deleteIndex >= 0 && m.deleteIndex < len(m.config.Codex) {
 		configName = m.config.Codex[m.deleteIndex].Name
+	} else if names := m.toolNames(); m.state == confirmDeleteTool && m.deleteIndex >= 0 && m.deleteIndex < len(names) {
+		configName = names[m.deleteIndex]
 	}
 
 	title := headerView(fmt.Sprintf(t("confirm_delete_title"), serviceType))
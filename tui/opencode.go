@@ -0,0 +1,279 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// opencode 的 provider SDK 包，第一个为默认值
+var opencodeNPMPackages = []string{"@ai-sdk/openai-compatible", "@ai-sdk/anthropic", "@ai-sdk/openai"}
+
+// opencode 配置的字段 Key
+const (
+	toolKeyProviderID = "provider_id"
+	toolKeyNPM        = "npm"
+)
+
+// opencodeAdapter 管理 opencode.json 中 provider 映射里的一个 provider 以及顶层的 model 选择
+type opencodeAdapter struct {
+	storedTool
+}
+
+func (opencodeAdapter) DisplayName() string { return "opencode" }
+
+func (opencodeAdapter) Fields() []ToolField {
+	return []ToolField{
+		{Key: toolKeyProviderID, Label: "field_opencode_provider", Required: true},
+		{Key: toolKeyNPM, Label: "field_opencode_npm", Options: opencodeNPMPackages},
+		{Key: toolKeyBaseURL, Label: "field_base_url"},
+		{Key: toolKeyAPIKey, Label: "field_api_key", Secret: true},
+		{Key: toolKeyModel, Label: "field_model", Required: true},
+	}
+}
+
+// opencodeConfigPath 返回 opencode 的全局配置文件：OPENCODE_CONFIG 指定的文件，
+// 否则为 $XDG_CONFIG_HOME/opencode（默认 ~/.config/opencode）下的 opencode.json；只有 opencode.jsonc 时使用它
+func opencodeConfigPath() string {
+	if path := strings.TrimSpace(os.Getenv("OPENCODE_CONFIG")); path != "" {
+		return expandHome(path)
+	}
+	dir := envDir("XDG_CONFIG_HOME", filepath.Join(platformPaths.GetHomeDir(), ".config"))
+	path := filepath.Join(dir, "opencode", "opencode.json")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if _, err := os.Stat(path + "c"); err == nil {
+			return path + "c"
+		}
+	}
+	return path
+}
+
+func (opencodeAdapter) Paths() []string {
+	return []string{opencodeConfigPath()}
+}
+
+// readOpencodeConfig 读取 opencode.json，文件不存在时返回空内容
+func readOpencodeConfig() ([]byte, map[string]interface{}, error) {
+	data, err := os.ReadFile(opencodeConfigPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, map[string]interface{}{}, nil
+		}
+		return nil, nil, err
+	}
+	parsed, err := parseJSONC(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse opencode.json: %w", err)
+	}
+	return data, parsed, nil
+}
+
+// opencodeProviders 返回 provider 映射，不存在时返回空 map
+func opencodeProviders(parsed map[string]interface{}) map[string]interface{} {
+	providers, _ := parsed["provider"].(map[string]interface{})
+	if providers == nil {
+		return map[string]interface{}{}
+	}
+	return providers
+}
+
+// opencodeProviderEntry 是新建 provider 时写入的内容
+type opencodeProviderEntry struct {
+	NPM     string                            `json:"npm,omitempty"`
+	Name    string                            `json:"name,omitempty"`
+	Options map[string]string                 `json:"options,omitempty"`
+	Models  map[string]map[string]interface{} `json:"models"`
+}
+
+// opencodeWithConfig 返回把配置合并进 opencode.json 之后的内容：
+// 已有的 provider 只更新 npm、baseURL、apiKey 并确保 models 中有该模型，其余设置保留；
+// 没有时新建 provider。previous 中由 switcher 创建、但不再使用的 provider 会被删除。
+func opencodeWithConfig(data []byte, parsed map[string]interface{}, cfg ToolConfig, previous []string) ([]byte, error) {
+	id, model := cfg.Values[toolKeyProviderID], cfg.Values[toolKeyModel]
+	providers := opencodeProviders(parsed)
+	var err error
+	for _, old := range previous {
+		if old != id {
+			if data, err = deleteJSONCPath(data, []string{"provider", old}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	options := map[string]string{}
+	if v := cfg.Values[toolKeyBaseURL]; v != "" {
+		options["baseURL"] = v
+	}
+	if v := cfg.Values[toolKeyAPIKey]; v != "" {
+		options["apiKey"] = v
+	}
+	existing, _ := providers[id].(map[string]interface{})
+	if existing == nil {
+		entry := opencodeProviderEntry{NPM: cfg.Values[toolKeyNPM], Name: cfg.Name, Options: options,
+			Models: map[string]map[string]interface{}{model: {}}}
+		encoded, err := json.MarshalIndent(entry, "    ", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if data, err = setJSONCPathRaw(data, []string{"provider", id}, encoded); err != nil {
+			return nil, err
+		}
+	} else {
+		if npm := cfg.Values[toolKeyNPM]; npm != "" {
+			if data, err = setJSONCPath(data, []string{"provider", id, "npm"}, npm); err != nil {
+				return nil, err
+			}
+		}
+		for _, key := range []string{"baseURL", "apiKey"} {
+			if v, ok := options[key]; ok {
+				data, err = setJSONCPath(data, []string{"provider", id, "options", key}, v)
+			} else {
+				data, err = deleteJSONCPath(data, []string{"provider", id, "options", key})
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		if models, _ := existing["models"].(map[string]interface{}); models[model] == nil {
+			if data, err = setJSONCPathRaw(data, []string{"provider", id, "models", model}, []byte("{}")); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return setJSONCString(data, "model", id+"/"+model)
+}
+
+func (a opencodeAdapter) Apply(c *Config, index int) error {
+	return c.applyStoredTool(a, index, func(cfg ToolConfig) error {
+		data, parsed, err := readOpencodeConfig()
+		if err != nil {
+			return err
+		}
+		st := c.toolState(a.id)
+		updated, err := opencodeWithConfig(data, parsed, cfg, st.Managed)
+		if err != nil {
+			return err
+		}
+		path := opencodeConfigPath()
+		if err := mkdirWithPerms(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create opencode config directory: %w", err)
+		}
+		if err := writeFileWithPerms(path, updated, 0600); err != nil {
+			return err
+		}
+		// 与用户自己定义的 provider 同名时覆盖设置，但不记录为 switcher 创建，之后也不会删除
+		id := cfg.Values[toolKeyProviderID]
+		_, userDefined := opencodeProviders(parsed)[id]
+		if !userDefined || slices.Contains(st.Managed, id) {
+			st.Managed = []string{id}
+		} else {
+			st.Managed = nil
+		}
+		return nil
+	})
+}
+
+// opencodeProviderValues 读取 provider 中 switcher 管理的设置
+func opencodeProviderValues(provider map[string]interface{}) (npm, baseURL, apiKey string) {
+	npm, _ = provider["npm"].(string)
+	options, _ := provider["options"].(map[string]interface{})
+	baseURL, _ = options["baseURL"].(string)
+	apiKey, _ = options["apiKey"].(string)
+	return npm, baseURL, apiKey
+}
+
+// Verify 检查 opencode.json 选中的模型和对应 provider 的设置是否与活动配置一致；
+// 不一致时返回实际选中的 provider 的 baseURL（没有时为选中的模型）
+func (a opencodeAdapter) Verify(c *Config) (bool, string, error) {
+	active := c.GetActiveTool(a.id)
+	if active == nil {
+		return true, "", nil
+	}
+	_, parsed, err := readOpencodeConfig()
+	if err != nil {
+		return true, "", err
+	}
+	id := active.Values[toolKeyProviderID]
+	selected, _ := parsed["model"].(string)
+	providers := opencodeProviders(parsed)
+
+	ok := selected == id+"/"+active.Values[toolKeyModel]
+	provider, _ := providers[id].(map[string]interface{})
+	npm, baseURL, apiKey := opencodeProviderValues(provider)
+	if provider == nil || baseURL != active.Values[toolKeyBaseURL] || apiKey != active.Values[toolKeyAPIKey] ||
+		(active.Values[toolKeyNPM] != "" && npm != active.Values[toolKeyNPM]) {
+		ok = false
+	}
+
+	shown := selected
+	if selectedID, _, found := strings.Cut(selected, "/"); found {
+		if p, _ := providers[selectedID].(map[string]interface{}); p != nil {
+			if _, url, _ := opencodeProviderValues(p); url != "" {
+				shown = url
+			}
+		}
+	}
+	if shown == "" {
+		shown = t("value_unset")
+	}
+	return ok, shown, nil
+}
+
+// Import 把 provider 映射中的每个 provider 导入为配置：模型为顶层 model 选中的模型，
+// 未选中该 provider（或 model 中没有模型部分）时为其 models 中按名称排序的第一个；顶层 model 选中的 provider 成为活动配置
+func (a opencodeAdapter) Import(c *Config) ([]ToolImportResult, error) {
+	_, parsed, err := readOpencodeConfig()
+	if err != nil {
+		return nil, err
+	}
+	// 顶层 model 不是字符串（或未设置）时没有选中的 provider
+	selected, _ := parsed["model"].(string)
+	selectedID, selectedModel, _ := strings.Cut(selected, "/")
+	providers := opencodeProviders(parsed)
+	ids := make([]string, 0, len(providers))
+	for id := range providers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var results []ToolImportResult
+	for _, id := range ids {
+		provider, _ := providers[id].(map[string]interface{})
+		if provider == nil {
+			continue
+		}
+		model := ""
+		if id == selectedID && selectedModel != "" {
+			model = selectedModel
+		} else if models, _ := provider["models"].(map[string]interface{}); len(models) > 0 {
+			names := make([]string, 0, len(models))
+			for name := range models {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			model = names[0]
+		}
+		if model == "" {
+			continue
+		}
+		npm, baseURL, apiKey := opencodeProviderValues(provider)
+		cfg := ToolConfig{Name: id, Values: map[string]string{toolKeyProviderID: id, toolKeyModel: model}}
+		if name, _ := provider["name"].(string); name != "" {
+			cfg.Name = name
+		}
+		for key, v := range map[string]string{toolKeyNPM: npm, toolKeyBaseURL: baseURL, toolKeyAPIKey: apiKey} {
+			if v != "" {
+				cfg.Values[key] = v
+			}
+		}
+		results = append(results, c.importToolConfig(a, cfg, id == selectedID))
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+	return results, c.Save()
+}
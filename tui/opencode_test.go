@@ -0,0 +1,141 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpencodeApplyVerifyAndImport(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	t.Setenv("OPENCODE_CONFIG", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	opencode := FindToolAdapter("opencode")
+	path := filepath.Join(dir, ".config", "opencode", "opencode.json")
+	if got := opencode.Paths(); len(got) != 1 || got[0] != path {
+		t.Fatalf("Paths = %v, want %s", got, path)
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, []byte(`{
+  "$schema": "https://opencode.ai/config.json",
+  // 用户自己的 provider
+  "provider": {
+    "ollama": {
+      "npm": "@ai-sdk/openai-compatible",
+      "options": {"baseURL": "http://localhost:11434/v1"},
+      "models": {"llama3": {"name": "Llama 3"}}
+    }
+  },
+  "theme": "opencode"
+}
+`), 0644)
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	configs := []ToolConfig{
+		{Name: "relay", Values: map[string]string{toolKeyProviderID: "relay", toolKeyNPM: opencodeNPMPackages[0], toolKeyBaseURL: "https://relay.example.com/v1", toolKeyAPIKey: "r-key", toolKeyModel: "gpt-4o"}},
+		{Name: "other", Values: map[string]string{toolKeyProviderID: "other", toolKeyAPIKey: "o-key", toolKeyModel: "claude-sonnet-4"}},
+		{Name: "local", Values: map[string]string{toolKeyProviderID: "ollama", toolKeyBaseURL: "http://127.0.0.1:11434/v1", toolKeyModel: "qwen3"}},
+	}
+	for _, cfg := range configs {
+		if err := c.AddToolConfig(opencode, cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := opencode.Apply(c, 0); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{"// 用户自己的 provider", `"theme": "opencode"`, `"model": "relay/gpt-4o"`, `"baseURL": "https://relay.example.com/v1"`, `"name": "Llama 3"`} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("opencode.json missing %s:\n%s", want, data)
+		}
+	}
+	if ok, _, err := opencode.Verify(c); !ok || err != nil {
+		t.Fatalf("Verify after apply = %v, %v", ok, err)
+	}
+
+	// 切换后 switcher 创建的 relay 被删除，用户的 ollama 保留
+	if err := opencode.Apply(c, 1); err != nil {
+		t.Fatal(err)
+	}
+	_, parsed, err := readOpencodeConfig()
+	providers := opencodeProviders(parsed)
+	if err != nil || providers["relay"] != nil || providers["other"] == nil || providers["ollama"] == nil || parsed["model"] != "other/claude-sonnet-4" {
+		t.Fatalf("after switching to other: %v (err=%v)", parsed, err)
+	}
+
+	// 选择用户已有的 provider：只更新 baseURL 和模型，其余设置保留，之后也不会被删除
+	if err := opencode.Apply(c, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := opencode.Apply(c, 1); err != nil {
+		t.Fatal(err)
+	}
+	_, parsed, _ = readOpencodeConfig()
+	ollama, _ := opencodeProviders(parsed)["ollama"].(map[string]interface{})
+	models, _ := ollama["models"].(map[string]interface{})
+	if npm, baseURL, _ := opencodeProviderValues(ollama); npm != opencodeNPMPackages[0] || baseURL != "http://127.0.0.1:11434/v1" || models["llama3"] == nil || models["qwen3"] == nil {
+		t.Fatalf("user provider not merged correctly: %v", ollama)
+	}
+
+	// 手动切换模型后检测到差异，导入后一致
+	data, _ = os.ReadFile(path)
+	os.WriteFile(path, []byte(strings.Replace(string(data), `"other/claude-sonnet-4"`, `"ollama/llama3"`, 1)), 0644)
+	if ok, actual, _ := opencode.Verify(c); ok || actual != "http://127.0.0.1:11434/v1" {
+		t.Fatalf("Verify after hand edit = %v, %q", ok, actual)
+	}
+	results, err := opencode.Import(c)
+	if err != nil || len(results) != 2 {
+		t.Fatalf("import = %+v (err=%v)", results, err)
+	}
+	if got := ActiveToolName(c, opencode); got != "ollama" {
+		t.Fatalf("active after import = %q, want ollama", got)
+	}
+	if ok, _, _ := opencode.Verify(c); !ok {
+		t.Fatalf("imported config should match opencode.json")
+	}
+}
+
+func TestOpencodeApplyDoesNotRewriteUnparsableConfig(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	path := filepath.Join(dir, "opencode.json")
+	t.Setenv("OPENCODE_CONFIG", path)
+	in := []byte(`{"provider": {`)
+	os.WriteFile(path, in, 0644)
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	opencode := FindToolAdapter("opencode")
+	c.AddToolConfig(opencode, ToolConfig{Name: "relay", Values: map[string]string{toolKeyProviderID: "relay", toolKeyModel: "gpt-4o"}})
+	if err := opencode.Apply(c, 0); err == nil {
+		t.Fatalf("Apply should fail on an unparsable opencode.json")
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(in) {
+		t.Fatalf("opencode.json was rewritten: %s", data)
+	}
+	if c.GetActiveTool("opencode") != nil {
+		t.Fatalf("active config should not change when Apply fails")
+	}
+}
+
+func TestOpencodeImportIgnoresNonStringModel(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	path := filepath.Join(dir, "opencode.json")
+	t.Setenv("OPENCODE_CONFIG", path)
+	in := `{"model": {"id": "relay/gpt-4o"}, "provider": {"relay": {"options": {"baseURL": "https://relay.example.com/v1"}, "models": {"gpt-4o": {}}}}}`
+	if err := os.WriteFile(path, []byte(in), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	opencode := FindToolAdapter("opencode")
+	results, err := opencode.Import(c)
+	if err != nil || len(results) != 1 || results[0].Active {
+		t.Fatalf("import = %+v (err=%v); a non-string model should select no provider", results, err)
+	}
+	cfg := c.Tools["opencode"].Configs[0]
+	if cfg.Values[toolKeyProviderID] != "relay" || cfg.Values[toolKeyModel] != "gpt-4o" {
+		t.Fatalf("imported config = %+v", cfg)
+	}
+}
@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PermissionPreset 是一组可复用的 Claude Code 权限规则，可以挂到任意 Claude Code 配置上
+type PermissionPreset struct {
+	Name  string   `json:"name"`
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// ManagedRules 记录 switcher 写入某个 settings 文件 permissions 中的规则
+type ManagedRules struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// defaultPermissionPresets 首次使用时提供的示例预设
+func defaultPermissionPresets() []PermissionPreset {
+	return []PermissionPreset{
+		{
+			Name:  "locked-down",
+			Allow: []string{"Read", "Grep", "Glob"},
+			Deny:  []string{"Bash(curl:*)", "Bash(wget:*)", "WebFetch", "Read(./.env)", "Read(./.env.*)", "Read(./secrets/**)"},
+		},
+		{
+			Name:  "full-dev",
+			Allow: []string{"Bash", "Edit", "Write", "Read", "Grep", "Glob", "WebFetch"},
+			Deny:  []string{"Read(./.env)", "Read(./.env.*)"},
+		},
+	}
+}
+
+// FindPermissionPreset 按名称查找预设，找不到时返回 nil
+func (c *Config) FindPermissionPreset(name string) *PermissionPreset {
+	for i := range c.PermissionPresets {
+		if c.PermissionPresets[i].Name == name {
+			return &c.PermissionPresets[i]
+		}
+	}
+	return nil
+}
+
+func (c *Config) validatePermissionPreset(index int, preset PermissionPreset) error {
+	if strings.TrimSpace(preset.Name) == "" {
+		return fmt.Errorf("permission preset name must not be empty")
+	}
+	for i, p := range c.PermissionPresets {
+		if i != index && p.Name == preset.Name {
+			return fmt.Errorf("permission preset %q already exists", preset.Name)
+		}
+	}
+	return nil
+}
+
+func (c *Config) AddPermissionPreset(preset PermissionPreset) error {
+	if err := c.validatePermissionPreset(-1, preset); err != nil {
+		return err
+	}
+	c.PermissionPresets = append(c.PermissionPresets, preset)
+	return c.Save()
+}
+
+// UpdatePermissionPreset 替换 index 处的预设；预设被重命名时同步更新引用它的 Claude Code 配置
+func (c *Config) UpdatePermissionPreset(index int, preset PermissionPreset) error {
+	if index < 0 || index >= len(c.PermissionPresets) {
+		return fmt.Errorf("invalid permission preset index")
+	}
+	if err := c.validatePermissionPreset(index, preset); err != nil {
+		return err
+	}
+	oldName := c.PermissionPresets[index].Name
+	c.PermissionPresets[index] = preset
+	if oldName != preset.Name {
+		for i := range c.ClaudeCode {
+			if c.ClaudeCode[i].PermissionPreset == oldName {
+				c.ClaudeCode[i].PermissionPreset = preset.Name
+			}
+		}
+	}
+	return c.Save()
+}
+
+// DeletePermissionPreset 删除预设；仍被 Claude Code 配置引用时拒绝删除
+func (c *Config) DeletePermissionPreset(index int) error {
+	if index < 0 || index >= len(c.PermissionPresets) {
+		return fmt.Errorf("invalid permission preset index")
+	}
+	name := c.PermissionPresets[index].Name
+	for _, sc := range c.ClaudeCode {
+		if sc.PermissionPreset == name {
+			return fmt.Errorf(t("error_preset_in_use"), name, sc.Name)
+		}
+	}
+	c.PermissionPresets = append(c.PermissionPresets[:index], c.PermissionPresets[index+1:]...)
+	return c.Save()
+}
+
+// toStringSlice 把 JSON 解码得到的数组转换为字符串切片，忽略非字符串元素
+func toStringSlice(v interface{}) []string {
+	items, _ := v.([]interface{})
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// mergeRules 先从 existing 中移除上一次由 switcher 写入的规则，再追加预设中的规则。
+// 返回合并后的规则和本次由 switcher 新增的规则；用户已有的同名规则不记为 switcher 所有。
+func mergeRules(existing, previous, preset []string) ([]string, []string) {
+	drop := map[string]bool{}
+	for _, r := range previous {
+		drop[r] = true
+	}
+	merged := make([]string, 0, len(existing)+len(preset))
+	present := map[string]bool{}
+	for _, r := range existing {
+		if drop[r] || present[r] {
+			continue
+		}
+		merged = append(merged, r)
+		present[r] = true
+	}
+	var owned []string
+	for _, r := range preset {
+		if present[r] {
+			continue
+		}
+		merged = append(merged, r)
+		present[r] = true
+		owned = append(owned, r)
+	}
+	return merged, owned
+}
+
+// applyClaudePermissions 把预设合并进 settings 的 permissions；preset 为 nil 时只移除上一次写入的规则。
+// permissions 中的其他字段（ask、defaultMode 等）保持不变。
+func applyClaudePermissions(settings map[string]interface{}, previous ManagedRules, preset *PermissionPreset) ManagedRules {
+	permissions, _ := settings["permissions"].(map[string]interface{})
+	if permissions == nil {
+		permissions = map[string]interface{}{}
+	}
+
+	var allow, deny []string
+	if preset != nil {
+		allow, deny = preset.Allow, preset.Deny
+	}
+	var owned ManagedRules
+	var mergedAllow, mergedDeny []string
+	mergedAllow, owned.Allow = mergeRules(toStringSlice(permissions["allow"]), previous.Allow, allow)
+	mergedDeny, owned.Deny = mergeRules(toStringSlice(permissions["deny"]), previous.Deny, deny)
+	setOrDeleteRules(permissions, "allow", mergedAllow)
+	setOrDeleteRules(permissions, "deny", mergedDeny)
+	if len(permissions) == 0 {
+		delete(settings, "permissions")
+	} else {
+		settings["permissions"] = permissions
+	}
+	return owned
+}
+
+// setOrDeleteRules 写入规则列表，列表为空时删除该字段，避免留下空的 allow/deny
+func setOrDeleteRules(permissions map[string]interface{}, key string, rules []string) {
+	if len(rules) == 0 {
+		delete(permissions, key)
+		return
+	}
+	permissions[key] = rules
+}
@@ -4,19 +4,57 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 )
 
 type PlatformPaths interface {
 	GetAppConfigPath() string
 	GetClaudeConfigDir() string
+	GetClaudeStatePath() string
 	GetCodexConfigDir() string
 	GetDroidConfigDir() string
+	// GetHomeDir 返回用户主目录，供配置文件位于主目录下的工具（通过 ToolAdapter 接入）使用
+	GetHomeDir() string
 }
 
 type platformPathsImpl struct {
 	home string
 }
 
+// 工具自身支持的配置目录覆盖变量，设置后 switcher 读写与工具相同的位置
+const (
+	claudeConfigDirEnv = "CLAUDE_CONFIG_DIR"
+	codexHomeEnv       = "CODEX_HOME"
+)
+
+// expandHome 把路径开头的 ~ 展开为用户主目录
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// envDir 返回环境变量指定的目录，未设置时返回 fallback
+func envDir(key, fallback string) string {
+	if dir := strings.TrimSpace(os.Getenv(key)); dir != "" {
+		return expandHome(dir)
+	}
+	return fallback
+}
+
+// claudeStatePath 返回 Claude Code 的 .claude.json：设置了 CLAUDE_CONFIG_DIR 时位于该目录中，否则位于主目录
+func claudeStatePath(home string) string {
+	if dir := envDir(claudeConfigDirEnv, ""); dir != "" {
+		return filepath.Join(dir, ".claude.json")
+	}
+	return filepath.Join(home, ".claude.json")
+}
+
 func NewPlatformPaths() (PlatformPaths, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -43,17 +81,25 @@ func (p *linuxPaths) GetAppConfigPath() string {
 }
 
 func (p *linuxPaths) GetClaudeConfigDir() string {
-	return filepath.Join(p.home, ".claude")
+	return envDir(claudeConfigDirEnv, filepath.Join(p.home, ".claude"))
+}
+
+func (p *linuxPaths) GetClaudeStatePath() string {
+	return claudeStatePath(p.home)
 }
 
 func (p *linuxPaths) GetCodexConfigDir() string {
-	return filepath.Join(p.home, ".codex")
+	return envDir(codexHomeEnv, filepath.Join(p.home, ".codex"))
 }
 
 func (p *linuxPaths) GetDroidConfigDir() string {
 	return filepath.Join(p.home, ".factory")
 }
 
+func (p *linuxPaths) GetHomeDir() string {
+	return p.home
+}
+
 // macOS paths
 type darwinPaths struct {
 	home string
@@ -64,17 +110,25 @@ func (p *darwinPaths) GetAppConfigPath() string {
 }
 
 func (p *darwinPaths) GetClaudeConfigDir() string {
-	return filepath.Join(p.home, ".claude")
+	return envDir(claudeConfigDirEnv, filepath.Join(p.home, ".claude"))
+}
+
+func (p *darwinPaths) GetClaudeStatePath() string {
+	return claudeStatePath(p.home)
 }
 
 func (p *darwinPaths) GetCodexConfigDir() string {
-	return filepath.Join(p.home, ".codex")
+	return envDir(codexHomeEnv, filepath.Join(p.home, ".codex"))
 }
 
 func (p *darwinPaths) GetDroidConfigDir() string {
 	return filepath.Join(p.home, ".factory")
 }
 
+func (p *darwinPaths) GetHomeDir() string {
+	return p.home
+}
+
 // Windows paths
 type windowsPaths struct {
 	home string
@@ -89,17 +143,25 @@ func (p *windowsPaths) GetAppConfigPath() string {
 }
 
 func (p *windowsPaths) GetClaudeConfigDir() string {
-	return filepath.Join(p.home, ".claude")
+	return envDir(claudeConfigDirEnv, filepath.Join(p.home, ".claude"))
+}
+
+func (p *windowsPaths) GetClaudeStatePath() string {
+	return claudeStatePath(p.home)
 }
 
 func (p *windowsPaths) GetCodexConfigDir() string {
-	return filepath.Join(p.home, ".codex")
+	return envDir(codexHomeEnv, filepath.Join(p.home, ".codex"))
 }
 
 func (p *windowsPaths) GetDroidConfigDir() string {
 	return filepath.Join(p.home, ".factory")
 }
 
+func (p *windowsPaths) GetHomeDir() string {
+	return p.home
+}
+
 // mkdirWithPerms creates directory with permissions on Unix, ignores perms on Windows
 func mkdirWithPerms(path string, perm os.FileMode) error {
 	if runtime.GOOS == "windows" {
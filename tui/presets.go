@@ -0,0 +1,291 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// 权限预设表单中规则编辑器字段的位置；规则本身可能包含逗号，因此每条规则单独占一行编辑
+const (
+	presetFieldAllow = 1
+	presetFieldDeny  = 2
+)
+
+// openPresetList 从 Claude Code 列表进入权限预设管理界面
+func (m *model) openPresetList() {
+	m.state = permissionPresetList
+	m.presetCursor = 0
+	m.error = ""
+}
+
+// openPresetEditor 编辑 index 处的预设；index 为 -1 时新增预设
+func (m *model) openPresetEditor(index int) {
+	m.presetIndex = index
+	m.presetName, m.presetAllow, m.presetDeny = "", nil, nil
+	if index >= 0 && index < len(m.config.PermissionPresets) {
+		p := m.config.PermissionPresets[index]
+		m.presetName = p.Name
+		m.presetAllow = slices.Clone(p.Allow)
+		m.presetDeny = slices.Clone(p.Deny)
+	}
+	m.presetField = 0
+	m.state = editPermissionPreset
+	m.error = ""
+}
+
+// presetFormFields 返回权限预设编辑表单的字段列表，顺序与 presetField* 常量一致
+func (m *model) presetFormFields() []formField {
+	allow := fmt.Sprintf(t("value_rule_count"), len(m.presetAllow))
+	deny := fmt.Sprintf(t("value_rule_count"), len(m.presetDeny))
+	return []formField{
+		{label: t("field_preset_name"), kind: fieldText, value: &m.presetName},
+		{label: t("field_preset_allow"), kind: fieldEditor, value: &allow},
+		{label: t("field_preset_deny"), kind: fieldEditor, value: &deny},
+	}
+}
+
+func (m model) handlePresetListKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	count := len(m.config.PermissionPresets)
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = claudeCodeList
+		m.cursor = 0
+		m.error = ""
+	case tea.KeyUp:
+		if m.presetCursor > 0 {
+			m.presetCursor--
+		}
+	case tea.KeyDown:
+		if m.presetCursor < count {
+			m.presetCursor++
+		}
+	case tea.KeyEnter:
+		// 最后一行为“新增预设”
+		if m.presetCursor == count {
+			m.openPresetEditor(-1)
+		} else {
+			m.openPresetEditor(m.presetCursor)
+		}
+	case tea.KeyDelete:
+		if m.presetCursor < count {
+			name := m.config.PermissionPresets[m.presetCursor].Name
+			if err := m.config.DeletePermissionPreset(m.presetCursor); err != nil {
+				m.error = err.Error()
+			} else {
+				m.error = fmt.Sprintf(t("success_delete_preset"), name)
+				if m.presetCursor > 0 && m.presetCursor >= len(m.config.PermissionPresets) {
+					m.presetCursor--
+				}
+			}
+		}
+	case tea.KeyRunes:
+		switch msg.Runes[0] {
+		case 'k', 'K':
+			if m.presetCursor > 0 {
+				m.presetCursor--
+			}
+		case 'j', 'J':
+			if m.presetCursor < count {
+				m.presetCursor++
+			}
+		case 'a', 'A':
+			m.openPresetEditor(-1)
+		}
+	}
+	return m, nil
+}
+
+func (m model) handlePresetEditorKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	fields := m.presetFormFields()
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = permissionPresetList
+		m.error = ""
+	case tea.KeyUp:
+		m.presetField = (m.presetField - 1 + len(fields)) % len(fields)
+	case tea.KeyDown, tea.KeyTab:
+		m.presetField = (m.presetField + 1) % len(fields)
+	case tea.KeyEnter, tea.KeyCtrlS:
+		if msg.Type == tea.KeyEnter && fields[m.presetField].kind == fieldEditor {
+			m.openRuleEditor()
+			return m, nil
+		}
+		preset := PermissionPreset{
+			Name:  strings.TrimSpace(m.presetName),
+			Allow: m.presetAllow,
+			Deny:  m.presetDeny,
+		}
+		var err error
+		if m.presetIndex >= 0 {
+			err = m.config.UpdatePermissionPreset(m.presetIndex, preset)
+		} else {
+			err = m.config.AddPermissionPreset(preset)
+		}
+		if err != nil {
+			m.error = err.Error()
+			return m, nil
+		}
+		m.error = t("success_save_preset")
+		m.state = permissionPresetList
+	case tea.KeyBackspace, tea.KeyCtrlH:
+		fields[m.presetField].backspace()
+	case tea.KeyRunes, tea.KeySpace:
+		if s := sanitizeInput(msg.String()); s != "" {
+			fields[m.presetField].input(s)
+		}
+	}
+	return m, nil
+}
+
+func (m model) presetListView() string {
+	title := headerView(t("header_presets"))
+
+	var inner strings.Builder
+	for i, p := range m.config.PermissionPresets {
+		prefix := "  "
+		if i == m.presetCursor {
+			prefix = cursorStyle.Render(">")
+		}
+		inner.WriteString(formRowStyle.Render(fmt.Sprintf("%s %s  %s", prefix, p.Name,
+			helpStyle.Render(fmt.Sprintf(t("display_preset_rules"), len(p.Allow), len(p.Deny))))) + "\n")
+	}
+	prefix := "  "
+	if m.presetCursor == len(m.config.PermissionPresets) {
+		prefix = cursorStyle.Render(">")
+	}
+	inner.WriteString(formRowStyle.Render(fmt.Sprintf("%s %s", prefix, t("menu_add_preset"))) + "\n")
+
+	var content strings.Builder
+	content.WriteString(title)
+	content.WriteString("\n\n")
+	content.WriteString(boxStyle.Render(inner.String()))
+	content.WriteString("\n")
+	content.WriteString(statusBarView(t("nav_select"), t("nav_preset_edit"), t("nav_preset_delete"), t("nav_back")))
+	return content.String()
+}
+
+func (m model) presetEditorView() string {
+	title := headerView(t("header_edit_preset"))
+
+	var inner strings.Builder
+	for i, f := range m.presetFormFields() {
+		prefix := "  "
+		highlight := ""
+		if i == m.presetField {
+			prefix = cursorStyle.Render(">")
+			highlight = fieldHighlightStyle.Render(" " + t("hint_input"))
+		}
+		inner.WriteString(formRowStyle.Render(fmt.Sprintf("%s %s:%s %s", prefix, f.label, highlight, f.display(i == m.presetField))) + "\n")
+	}
+
+	var content strings.Builder
+	content.WriteString(title)
+	content.WriteString("\n\n")
+	content.WriteString(boxStyle.Render(inner.String()))
+	content.WriteString("\n")
+	content.WriteString(helpStyle.Render(t("hint_preset_rules")))
+	content.WriteString("\n")
+	content.WriteString(statusBarView(t("form_nav_field"), t("form_nav_save"), t("form_nav_cancel"), ""))
+	return content.String()
+}
+
+// ruleEditorTarget 返回规则编辑器写回的表单字段：当前字段对应的允许或拒绝规则
+func (m *model) ruleEditorTarget() *[]string {
+	if m.presetField == presetFieldDeny {
+		return &m.presetDeny
+	}
+	return &m.presetAllow
+}
+
+// openRuleEditor 打开当前字段的规则编辑器，编辑的是表单数据的副本，只有按 Enter 确认后才写回表单
+func (m *model) openRuleEditor() {
+	m.ruleEntries = slices.Clone(*m.ruleEditorTarget())
+	m.ruleRow = 0
+	m.normalizeRuleEntries()
+	m.state = editPresetRules
+	m.error = ""
+}
+
+// normalizeRuleEntries 保证列表末尾始终有且只有一行空白行，用于新增规则
+func (m *model) normalizeRuleEntries() {
+	for len(m.ruleEntries) > 0 && strings.TrimSpace(m.ruleEntries[len(m.ruleEntries)-1]) == "" {
+		m.ruleEntries = m.ruleEntries[:len(m.ruleEntries)-1]
+	}
+	m.ruleEntries = append(m.ruleEntries, "")
+	if m.ruleRow >= len(m.ruleEntries) {
+		m.ruleRow = len(m.ruleEntries) - 1
+	}
+}
+
+// commitRuleEntries 把编辑结果写回表单；空行会被忽略
+func (m *model) commitRuleEntries() {
+	var rules []string
+	for _, r := range m.ruleEntries {
+		if r = strings.TrimSpace(r); r != "" {
+			rules = append(rules, r)
+		}
+	}
+	*m.ruleEditorTarget() = rules
+}
+
+func (m model) handleRuleEditorKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyEnter, tea.KeyCtrlS:
+		// Esc 放弃修改，Enter 确认
+		if msg.Type != tea.KeyEsc {
+			m.commitRuleEntries()
+		}
+		m.state = editPermissionPreset
+		m.ruleEntries = nil
+		m.error = ""
+		return m, nil
+	case tea.KeyUp:
+		m.ruleRow = (m.ruleRow - 1 + len(m.ruleEntries)) % len(m.ruleEntries)
+	case tea.KeyDown, tea.KeyTab:
+		m.ruleRow = (m.ruleRow + 1) % len(m.ruleEntries)
+	case tea.KeyDelete:
+		// 删除当前行（末尾的空白行除外）
+		if m.ruleRow < len(m.ruleEntries)-1 {
+			m.ruleEntries = append(m.ruleEntries[:m.ruleRow], m.ruleEntries[m.ruleRow+1:]...)
+		}
+	case tea.KeyBackspace, tea.KeyCtrlH:
+		if rule := m.ruleEntries[m.ruleRow]; rule != "" {
+			r := []rune(rule)
+			m.ruleEntries[m.ruleRow] = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.ruleEntries[m.ruleRow] += sanitizeInput(msg.String())
+	}
+	m.normalizeRuleEntries()
+	return m, nil
+}
+
+func (m model) ruleEditorView() string {
+	title := headerView(t("field_preset_allow"))
+	if m.presetField == presetFieldDeny {
+		title = headerView(t("field_preset_deny"))
+	}
+
+	var inner strings.Builder
+	for i, rule := range m.ruleEntries {
+		prefix := "  "
+		if i == m.ruleRow {
+			prefix = cursorStyle.Render(">")
+			rule = fieldHighlightStyle.Render("[" + rule + "]")
+		} else if i == len(m.ruleEntries)-1 {
+			rule = helpStyle.Render(t("value_rule_new"))
+		}
+		inner.WriteString(formRowStyle.Render(fmt.Sprintf("%s %s", prefix, rule)) + "\n")
+	}
+
+	var content strings.Builder
+	content.WriteString(title)
+	content.WriteString("\n\n")
+	content.WriteString(boxStyle.Render(inner.String()))
+	content.WriteString("\n")
+	content.WriteString(statusBarView(t("env_nav_move"), t("env_nav_delete"), t("env_nav_done"), ""))
+	return content.String()
+}
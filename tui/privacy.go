@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Claude Code 隐私/遥测开关的取值
+const (
+	PrivacyOn        = "on"        // 写入开关对应的 env 值
+	PrivacyOff       = "off"       // 从 settings.json 的 env 中移除该变量
+	PrivacyUnmanaged = "unmanaged" // switcher 不读写该变量，保留用户自己的设置
+)
+
+// privacyModes 是表单中隐私开关的可选值，空字符串表示继承全局默认值
+var privacyModes = []string{"", PrivacyOn, PrivacyOff, PrivacyUnmanaged}
+
+// privacyFlag 描述一个由 switcher 管理的 Claude Code 隐私/遥测 env 变量
+type privacyFlag struct {
+	name    string // ServiceConfig 与 claude_privacy_defaults 中使用的名称
+	envKey  string // settings.json env 中的变量名
+	onValue string // 开启时写入的值
+	label   string // 表单与详情中的 i18n key
+	field   func(*ServiceConfig) *string
+}
+
+// claudePrivacyFlags 列出所有隐私/遥测开关，顺序与表单一致
+var claudePrivacyFlags = []privacyFlag{
+	{"disable_nonessential_traffic", "CLAUDE_CODE_DISABLE_NONESSENTIAL_TRAFFIC", "1", "field_privacy_traffic",
+		func(sc *ServiceConfig) *string { return &sc.DisableNonessentialTraffic }},
+	{"disable_telemetry", "DISABLE_TELEMETRY", "1", "field_privacy_telemetry",
+		func(sc *ServiceConfig) *string { return &sc.DisableTelemetry }},
+	{"do_not_track", "DO_NOT_TRACK", "1", "field_privacy_dnt",
+		func(sc *ServiceConfig) *string { return &sc.DoNotTrack }},
+	{"maintain_project_working_dir", "CLAUDE_BASH_MAINTAIN_PROJECT_WORKING_DIR", "true", "field_privacy_bash_cwd",
+		func(sc *ServiceConfig) *string { return &sc.MaintainProjectWorkingDir }},
+}
+
+func isPrivacyMode(v string) bool {
+	for _, m := range privacyModes {
+		if v == m {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultPrivacyMode 返回开关的全局默认值，未设置时为 on（与早期版本始终写入的行为一致）
+func (c *Config) defaultPrivacyMode(f privacyFlag) string {
+	if v := c.ClaudePrivacyDefaults[f.name]; v != "" {
+		return v
+	}
+	return PrivacyOn
+}
+
+// claudePrivacyMode 返回配置实际生效的开关取值：配置自身的值优先，否则使用全局默认值
+func (c *Config) claudePrivacyMode(config *ServiceConfig, f privacyFlag) string {
+	if v := *f.field(config); v != "" {
+		return v
+	}
+	return c.defaultPrivacyMode(f)
+}
+
+// validateClaudePrivacy 校验配置和全局默认值中的隐私开关取值
+func (c *Config) validateClaudePrivacy(config *ServiceConfig) error {
+	for _, f := range claudePrivacyFlags {
+		if v := *f.field(config); !isPrivacyMode(v) {
+			return fmt.Errorf("invalid %s value %q (want %s, %s or %s)", f.name, v, PrivacyOn, PrivacyOff, PrivacyUnmanaged)
+		}
+		if v := c.ClaudePrivacyDefaults[f.name]; !isPrivacyMode(v) {
+			return fmt.Errorf("invalid claude_privacy_defaults.%s value %q (want %s, %s or %s)", f.name, v, PrivacyOn, PrivacyOff, PrivacyUnmanaged)
+		}
+	}
+	return nil
+}
+
+// claudePrivacySummary 返回配置详情中显示的隐私开关摘要（已解析继承值）
+func (c *Config) claudePrivacySummary(config *ServiceConfig) string {
+	parts := make([]string, len(claudePrivacyFlags))
+	for i, f := range claudePrivacyFlags {
+		parts[i] = fmt.Sprintf("%s=%s", f.name, c.claudePrivacyMode(config, f))
+	}
+	return fmt.Sprintf(t("display_privacy"), strings.Join(parts, " · "))
+}
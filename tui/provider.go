@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Claude Code 的提供商类型
+const (
+	ClaudeProviderAnthropic = "anthropic" // Anthropic 兼容端点：base URL + 凭据
+	ClaudeProviderBedrock   = "bedrock"   // AWS Bedrock
+	ClaudeProviderVertex    = "vertex"    // Google Vertex AI
+)
+
+// claudeProviderTypes 是表单中提供商类型的可选值
+var claudeProviderTypes = []string{ClaudeProviderAnthropic, ClaudeProviderBedrock, ClaudeProviderVertex}
+
+// claudeProviderType 返回配置的提供商类型，未设置时为 Anthropic 兼容端点
+func claudeProviderType(config *ServiceConfig) string {
+	if config.ClaudeProviderType == "" {
+		return ClaudeProviderAnthropic
+	}
+	return config.ClaudeProviderType
+}
+
+// usesClaudeCloud 表示配置通过 Bedrock 或 Vertex 访问 Claude，不使用 Anthropic 凭据
+func usesClaudeCloud(config *ServiceConfig) bool {
+	return claudeProviderType(config) != ClaudeProviderAnthropic
+}
+
+// validateClaudeProvider 校验提供商类型及其必填字段
+func validateClaudeProvider(config *ServiceConfig) error {
+	switch claudeProviderType(config) {
+	case ClaudeProviderAnthropic:
+	case ClaudeProviderBedrock:
+		if strings.TrimSpace(config.CloudRegion) == "" {
+			return fmt.Errorf("bedrock provider requires a region")
+		}
+	case ClaudeProviderVertex:
+		if strings.TrimSpace(config.CloudRegion) == "" || strings.TrimSpace(config.VertexProjectID) == "" {
+			return fmt.Errorf("vertex provider requires a region and a project ID")
+		}
+	default:
+		return fmt.Errorf("unknown Claude provider type %q", config.ClaudeProviderType)
+	}
+	return nil
+}
+
+// claudeProviderEnv 返回提供商相关的 env：端点、凭据以及 Bedrock/Vertex 的开关、区域和项目。
+// 切换时写入，漂移检查时与 settings.json 对比；未列出的提供商 key 会在切换时被移除。
+func claudeProviderEnv(config *ServiceConfig) map[string]string {
+	env := map[string]string{}
+	setIf := func(key, value string) {
+		if value != "" {
+			env[key] = value
+		}
+	}
+	switch claudeProviderType(config) {
+	case ClaudeProviderBedrock:
+		env["CLAUDE_CODE_USE_BEDROCK"] = "1"
+		env["AWS_REGION"] = config.CloudRegion
+		setIf("AWS_PROFILE", config.AWSProfile)
+		setIf("AWS_BEARER_TOKEN_BEDROCK", config.APIKey)
+		setIf("ANTHROPIC_BEDROCK_BASE_URL", config.BaseURL)
+	case ClaudeProviderVertex:
+		env["CLAUDE_CODE_USE_VERTEX"] = "1"
+		env["CLOUD_ML_REGION"] = config.CloudRegion
+		env["ANTHROPIC_VERTEX_PROJECT_ID"] = config.VertexProjectID
+		setIf("ANTHROPIC_VERTEX_BASE_URL", config.BaseURL)
+	default:
+		env["ANTHROPIC_BASE_URL"] = config.BaseURL
+		if key := claudeAuthEnvKey(config); key != "" {
+			env[key] = config.APIKey
+		}
+	}
+	return env
+}
+
+// claudeEndpointOf 返回 settings.json env 中实际生效的端点描述，用于漂移提示
+func claudeEndpointOf(env map[string]string) string {
+	switch {
+	case env["CLAUDE_CODE_USE_BEDROCK"] != "":
+		return ClaudeProviderBedrock + "/" + env["AWS_REGION"]
+	case env["CLAUDE_CODE_USE_VERTEX"] != "":
+		return ClaudeProviderVertex + "/" + env["CLOUD_ML_REGION"]
+	default:
+		return strings.TrimSpace(env["ANTHROPIC_BASE_URL"])
+	}
+}
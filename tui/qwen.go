@@ -0,0 +1,161 @@
+package tui
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+)
+
+// qwenAuthType 是 Qwen Code 使用 OpenAI 兼容端点时 settings.json 中的认证方式
+const qwenAuthType = "openai"
+
+// qwenEnvKeys 写入 .env 的 API Key、Base URL 和模型变量名
+var qwenEnvKeys = [3]string{"OPENAI_API_KEY", "OPENAI_BASE_URL", "OPENAI_MODEL"}
+
+// qwenAdapter 管理 ~/.qwen/.env 中的 OpenAI 兼容端点，以及 ~/.qwen/settings.json 中的模型和认证方式。
+// Qwen Code 是 Gemini CLI 的分支，settings.json 的格式相同。
+type qwenAdapter struct {
+	storedTool
+}
+
+func (qwenAdapter) DisplayName() string { return "Qwen Code" }
+
+func (qwenAdapter) Fields() []ToolField {
+	return []ToolField{
+		{Key: toolKeyBaseURL, Label: "field_base_url"},
+		{Key: toolKeyAPIKey, Label: "field_api_key", Secret: true, Required: true},
+		{Key: toolKeyModel, Label: "field_model", Required: true},
+	}
+}
+
+func qwenDir() string {
+	return filepath.Join(platformPaths.GetHomeDir(), ".qwen")
+}
+
+func (qwenAdapter) Paths() []string {
+	return []string{filepath.Join(qwenDir(), "settings.json"), filepath.Join(qwenDir(), ".env")}
+}
+
+// qwenEnv 返回配置要写入 .env 的变量（值为空的变量不写入）
+func qwenEnv(cfg ToolConfig) map[string]string {
+	values := [3]string{cfg.Values[toolKeyAPIKey], cfg.Values[toolKeyBaseURL], cfg.Values[toolKeyModel]}
+	set := map[string]string{}
+	for i, key := range qwenEnvKeys {
+		if values[i] != "" {
+			set[key] = values[i]
+		}
+	}
+	return set
+}
+
+// qwenSettingsAuth 返回 settings.json 中 security.auth 下的设置
+func qwenSettingsAuth(settings map[string]interface{}) map[string]interface{} {
+	security, _ := settings["security"].(map[string]interface{})
+	auth, _ := security["auth"].(map[string]interface{})
+	return auth
+}
+
+// qwenSettingsFor 返回应用配置后的 settings.json 内容：设置模型并把认证方式设为 OpenAI。
+// settings.json 中已有 security.auth.apiKey/baseUrl 时它们优先于 .env，一并更新为配置的值。
+func qwenSettingsFor(data []byte, cfg ToolConfig) ([]byte, error) {
+	data, err := settingsWithModelAuth(data, "Qwen Code", cfg.Values[toolKeyModel], qwenAuthType)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := parseJSONC(data)
+	if err != nil {
+		return nil, err
+	}
+	auth := qwenSettingsAuth(settings)
+	for key, field := range map[string]string{"apiKey": toolKeyAPIKey, "baseUrl": toolKeyBaseURL} {
+		if _, ok := auth[key]; !ok {
+			continue
+		}
+		path := []string{"security", "auth", key}
+		if v := cfg.Values[field]; v != "" {
+			data, err = setJSONCPath(data, path, v)
+		} else {
+			data, err = deleteJSONCPath(data, path)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// qwenEffective 返回 Qwen Code 实际使用的 API Key、Base URL 和模型：settings.json 中的设置优先于 .env
+func qwenEffective(env map[string]string, settings map[string]interface{}) [3]string {
+	values := [3]string{env[qwenEnvKeys[0]], env[qwenEnvKeys[1]], env[qwenEnvKeys[2]]}
+	auth := qwenSettingsAuth(settings)
+	if v, ok := auth["apiKey"].(string); ok {
+		values[0] = v
+	}
+	if v, ok := auth["baseUrl"].(string); ok {
+		values[1] = v
+	}
+	if model := geminiSettingsModel(settings); model != "" {
+		values[2] = model
+	}
+	return values
+}
+
+func (a qwenAdapter) Apply(c *Config, index int) error {
+	return c.applyStoredTool(a, index, func(cfg ToolConfig) error {
+		st := c.toolState(a.id)
+		set := qwenEnv(cfg)
+		err := writeEnvSettings(qwenDir(), set, staleManagedKeys(st.Managed, set), func(data []byte) ([]byte, error) {
+			return qwenSettingsFor(data, cfg)
+		})
+		if err != nil {
+			return err
+		}
+		st.Managed = slices.Sorted(maps.Keys(set))
+		return nil
+	})
+}
+
+// Verify 检查实际使用的 API Key、Base URL 和模型是否与活动配置一致；
+// 不一致时返回实际生效的 base URL（未设置时为模型）
+func (a qwenAdapter) Verify(c *Config) (bool, string, error) {
+	active := c.GetActiveTool(a.id)
+	if active == nil {
+		return true, "", nil
+	}
+	env, settings, err := readEnvSettings(qwenDir(), "Qwen Code")
+	if err != nil {
+		return true, "", err
+	}
+	values := qwenEffective(env, settings)
+	ok := values == [3]string{active.Values[toolKeyAPIKey], active.Values[toolKeyBaseURL], active.Values[toolKeyModel]}
+	shown := values[1]
+	if shown == "" {
+		shown = values[2]
+	}
+	if shown == "" {
+		shown = t("value_unset")
+	}
+	return ok, shown, nil
+}
+
+// Import 把 Qwen Code 当前使用的 OpenAI 兼容端点导入为配置并设为活动配置
+func (a qwenAdapter) Import(c *Config) ([]ToolImportResult, error) {
+	env, settings, err := readEnvSettings(qwenDir(), "Qwen Code")
+	if err != nil {
+		return nil, err
+	}
+	values := qwenEffective(env, settings)
+	if values[0] == "" || values[2] == "" {
+		return nil, nil
+	}
+	cfg := ToolConfig{Values: map[string]string{toolKeyAPIKey: values[0], toolKeyModel: values[2]}}
+	if values[1] != "" {
+		cfg.Values[toolKeyBaseURL] = values[1]
+	}
+	cfg.Name = hostOf(values[1])
+	if cfg.Name == "" {
+		cfg.Name = "qwen"
+	}
+	result := c.importToolConfig(a, cfg, true)
+	return []ToolImportResult{result}, c.Save()
+}
@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQwenApplyVerifyAndImport(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	qwen := FindToolAdapter("qwen")
+	qwenDir := filepath.Join(dir, ".qwen")
+	if err := os.MkdirAll(qwenDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	settingsPath, envPath := filepath.Join(qwenDir, "settings.json"), filepath.Join(qwenDir, ".env")
+	os.WriteFile(settingsPath, []byte("{\n  // 用户设置\n  \"theme\": \"Qwen Dark\"\n}\n"), 0644)
+	os.WriteFile(envPath, []byte("# proxy\nHTTPS_PROXY=http://proxy\nOPENAI_BASE_URL=https://old.example.com\n"), 0600)
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	configs := []ToolConfig{
+		{Name: "dashscope", Values: map[string]string{toolKeyBaseURL: "https://dashscope.aliyuncs.com/compatible-mode/v1", toolKeyAPIKey: "d-key", toolKeyModel: "qwen3-coder-plus"}},
+		{Name: "openai", Values: map[string]string{toolKeyAPIKey: "o-key", toolKeyModel: "gpt-4o"}},
+	}
+	for _, cfg := range configs {
+		if err := c.AddToolConfig(qwen, cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := qwen.Apply(c, 0); err != nil {
+		t.Fatal(err)
+	}
+	settings, _ := os.ReadFile(settingsPath)
+	env, parsed, err := readEnvSettings(qwenDir, "Qwen Code")
+	if err != nil || !strings.Contains(string(settings), "// 用户设置") || geminiSettingsModel(parsed) != "qwen3-coder-plus" ||
+		qwenSettingsAuth(parsed)["selectedType"] != qwenAuthType {
+		t.Fatalf("settings.json after apply:\n%s (err=%v)", settings, err)
+	}
+	if env["OPENAI_BASE_URL"] != "https://dashscope.aliyuncs.com/compatible-mode/v1" || env["OPENAI_API_KEY"] != "d-key" || env["HTTPS_PROXY"] != "http://proxy" {
+		t.Fatalf("env after apply: %v", env)
+	}
+	if ok, _, err := qwen.Verify(c); !ok || err != nil {
+		t.Fatalf("Verify after apply = %v, %v", ok, err)
+	}
+
+	// 没有 Base URL 的配置会删除之前写入的 OPENAI_BASE_URL，其他行保留
+	if err := qwen.Apply(c, 1); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(envPath)
+	if want := "# proxy\nHTTPS_PROXY=http://proxy\nOPENAI_API_KEY=o-key\nOPENAI_MODEL=gpt-4o\n"; string(data) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", data, want)
+	}
+
+	// settings.json 中的 apiKey/baseUrl 优先于 .env：检测到差异，导入后一致
+	os.WriteFile(settingsPath, []byte(`{"security": {"auth": {"selectedType": "openai", "baseUrl": "https://relay.example.com/v1"}}, "model": {"name": "gpt-4o"}}`), 0644)
+	if ok, actual, _ := qwen.Verify(c); ok || actual != "https://relay.example.com/v1" {
+		t.Fatalf("Verify after hand edit = %v, %q", ok, actual)
+	}
+	results, err := qwen.Import(c)
+	if err != nil || len(results) != 1 || results[0].Existing || results[0].Name != "relay.example.com" || !results[0].Active {
+		t.Fatalf("import = %+v (err=%v)", results, err)
+	}
+	if ok, _, _ := qwen.Verify(c); !ok {
+		t.Fatalf("imported config should match the files on disk")
+	}
+
+	// 再切换时同时更新 settings.json 中已有的 baseUrl
+	if err := qwen.Apply(c, 0); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _, _ := qwen.Verify(c); !ok {
+		t.Fatalf("Verify after switching back should pass")
+	}
+}
+
+func TestQwenApplyKeepsHandSetVariables(t *testing.T) {
+	dir := useTempPlatformPaths(t)
+	qwen := FindToolAdapter("qwen")
+	envPath := filepath.Join(dir, ".qwen", ".env")
+	if err := os.MkdirAll(filepath.Dir(envPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(envPath, []byte("OPENAI_BASE_URL=https://mine.example.com/v1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{Active: ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1}}
+	if err := c.AddToolConfig(qwen, ToolConfig{Name: "q", Values: map[string]string{toolKeyAPIKey: "k", toolKeyModel: "qwen3-coder-plus"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := qwen.Apply(c, 0); err != nil {
+		t.Fatal(err)
+	}
+	env, _, err := readEnvSettings(filepath.Dir(envPath), "Qwen Code")
+	if err != nil || env["OPENAI_BASE_URL"] != "https://mine.example.com/v1" || env["OPENAI_API_KEY"] != "k" {
+		t.Fatalf("hand-set base URL should be kept: env=%v err=%v", env, err)
+	}
+}
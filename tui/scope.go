@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Claude Code settings 作用域
+const (
+	ClaudeScopeUser         = "user"          // ~/.claude/settings.json
+	ClaudeScopeProject      = "project"       // ./.claude/settings.json（随项目提交）
+	ClaudeScopeProjectLocal = "project-local" // ./.claude/settings.local.json（仅本机，Claude Code 默认忽略提交）
+)
+
+// ClaudeScopes 按 TUI 中切换的顺序列出所有作用域
+var ClaudeScopes = []string{ClaudeScopeUser, ClaudeScopeProject, ClaudeScopeProjectLocal}
+
+// nextClaudeScope 返回 TUI 中下一个作用域
+func nextClaudeScope(scope string) string {
+	for i, s := range ClaudeScopes {
+		if s == scope {
+			return ClaudeScopes[(i+1)%len(ClaudeScopes)]
+		}
+	}
+	return ClaudeScopeProject
+}
+
+// ClaudeSettingsPath 返回作用域对应的 settings 文件路径；项目作用域基于当前工作目录
+func ClaudeSettingsPath(scope string) (string, error) {
+	switch scope {
+	case "", ClaudeScopeUser:
+		return filepath.Join(platformPaths.GetClaudeConfigDir(), "settings.json"), nil
+	case ClaudeScopeProject, ClaudeScopeProjectLocal:
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get current directory: %w", err)
+		}
+		name := "settings.json"
+		if scope == ClaudeScopeProjectLocal {
+			name = "settings.local.json"
+		}
+		return filepath.Join(wd, ".claude", name), nil
+	default:
+		return "", fmt.Errorf("unknown Claude Code scope %q (want %s, %s or %s)", scope, ClaudeScopeUser, ClaudeScopeProject, ClaudeScopeProjectLocal)
+	}
+}
+
+// ClaudeSettingsPathFor 返回配置在作用域中实际写入的 settings 文件：
+// 用户作用域下配置了目标目录时写入该目录，项目作用域不受目标目录影响
+func ClaudeSettingsPathFor(config *ServiceConfig, scope string) (string, error) {
+	if (scope == "" || scope == ClaudeScopeUser) && config != nil && strings.TrimSpace(config.TargetDir) != "" {
+		return filepath.Join(expandHome(strings.TrimSpace(config.TargetDir)), "settings.json"), nil
+	}
+	return ClaudeSettingsPath(scope)
+}
+
+// claudeSecretEnvKeys 是 settings.json env 中保存凭证的变量
+var claudeSecretEnvKeys = []string{"ANTHROPIC_AUTH_TOKEN", "ANTHROPIC_API_KEY", "AWS_BEARER_TOKEN_BEDROCK"}
+
+// ClaudeScopeWritesSecrets 报告切换到配置是否会把凭证写入项目作用域的 settings.json。
+// 该文件通常随项目提交，凭证应使用 project-local 作用域
+func ClaudeScopeWritesSecrets(config *ServiceConfig, scope string) bool {
+	if scope != ClaudeScopeProject || config == nil {
+		return false
+	}
+	env := claudeProviderEnv(config)
+	for _, key := range claudeSecretEnvKeys {
+		if env[key] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// SetActiveClaudeCodeInScope 记录作用域中当前使用的配置：
+// 用户作用域使用 Active.ClaudeCode，项目作用域按 settings 文件路径记录配置名
+func (c *Config) SetActiveClaudeCodeInScope(index int, scope string) error {
+	if scope == "" || scope == ClaudeScopeUser {
+		return c.SetActiveClaudeCode(index)
+	}
+	if index < 0 || index >= len(c.ClaudeCode) {
+		return fmt.Errorf("invalid Claude Code index")
+	}
+	path, err := ClaudeSettingsPath(scope)
+	if err != nil {
+		return err
+	}
+	if c.ClaudeProjects == nil {
+		c.ClaudeProjects = map[string]string{}
+	}
+	c.ClaudeProjects[path] = c.ClaudeCode[index].Name
+	return c.Save()
+}
+
+// ActiveClaudeCodeIndexInScope 返回作用域中当前使用的配置索引，没有时返回 -1
+func (c *Config) ActiveClaudeCodeIndexInScope(scope string) int {
+	if scope == "" || scope == ClaudeScopeUser {
+		return c.Active.ClaudeCode
+	}
+	path, err := ClaudeSettingsPath(scope)
+	if err != nil {
+		return -1
+	}
+	name, ok := c.ClaudeProjects[path]
+	if !ok {
+		return -1
+	}
+	for i, sc := range c.ClaudeCode {
+		if sc.Name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -6,11 +6,22 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 )
 
 type ShellManager interface {
+	// SetEnvVar 持久化一个环境变量，新开的 shell 中生效
 	SetEnvVar(key, value string) error
+	// UnsetEnvVar 移除一个由 switcher 设置的环境变量
+	UnsetEnvVar(key string) error
+	// GetEnvVar 读取由 switcher 设置的环境变量
+	GetEnvVar(key string) (string, bool)
+	// Uninstall 移除 switcher 写入的所有 shell 集成（rc 文件中的标记块、env 文件、环境变量）；
+	// legacyKeys 是旧版本可能在 env 文件之外直接设置过的变量，一并清理
+	Uninstall(legacyKeys []string) error
+	// ActivationHint 提示用户如何在当前 shell 中立即生效
+	ActivationHint() string
 }
 
 func NewShellManager() ShellManager {
@@ -20,89 +31,580 @@ func NewShellManager() ShellManager {
 	return &unixShellManager{}
 }
 
-// Unix shell manager (bash, zsh, fish)
+// rc 文件中 switcher 标记块的起止行
+const (
+	shellBlockBegin = "# >>> switcher >>>"
+	shellBlockEnd   = "# <<< switcher <<<"
+)
+
+// 环境变量文件头，提醒用户不要手动修改
+const envFileHeader = "Managed by switcher. Do not edit: this file is rewritten on every switch."
+
+// isValidEnvKey 检查环境变量名是否合法（字母或下划线开头，只含字母、数字和下划线）
+func isValidEnvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, c := range key {
+		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || (i > 0 && c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+func validateEnvVar(key, value string) error {
+	if !isValidEnvKey(key) {
+		return fmt.Errorf("invalid environment variable name %q", key)
+	}
+	if strings.ContainsAny(value, "\r\n\x00") {
+		return fmt.Errorf("value of %s must not contain newlines or NUL characters", key)
+	}
+	return nil
+}
+
+// switcherEnvDir 返回 switcher 自有 env 文件所在目录（与应用配置同目录）
+func switcherEnvDir() string {
+	return filepath.Dir(platformPaths.GetAppConfigPath())
+}
+
+// quotePosix 用单引号包裹值，值中的单引号通过先闭合、转义、再重新打开引号写入，$、"、` 等均按字面量处理
+func quotePosix(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// quoteFish fish 的单引号字符串只识别 \\ 和 \' 两种转义
+func quoteFish(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
+
+// quoteNu nushell 的双引号字符串支持反斜杠转义
+func quoteNu(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// quotePowerShell PowerShell 单引号字符串中单引号写成两个单引号
+func quotePowerShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func sortedEnvKeys(vars map[string]string) []string {
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Unix shell manager (bash, zsh, ksh, fish, nushell)
+//
+// 所有变量写入 switcher 自有的 env.sh / env.fish / env.nu，
+// rc 文件中只添加一次带标记的 source 块，之后切换不会再改动 rc 文件。
 type unixShellManager struct{}
 
-func (m *unixShellManager) SetEnvVar(key, value string) error {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+type shellKind int
+
+const (
+	shellPosix shellKind = iota // bash / zsh / ksh
+	shellFish
+	shellNu
+)
+
+// shellRC 是需要添加 source 块的 rc 文件
+type shellRC struct {
+	path string
+	kind shellKind
+}
+
+func (m *unixShellManager) envFile(kind shellKind) string {
+	switch kind {
+	case shellFish:
+		return filepath.Join(switcherEnvDir(), "env.fish")
+	case shellNu:
+		return filepath.Join(switcherEnvDir(), "env.nu")
+	default:
+		return filepath.Join(switcherEnvDir(), "env.sh")
 	}
+}
 
-	// Detect current shell
-	shell := os.Getenv("SHELL")
+// rcTargets 返回当前用户需要集成的 rc 文件：当前登录 shell 的 rc 文件总会被包含，
+// 其他 shell 只有在其 rc 文件（或配置目录）已存在时才会被包含。
+func (m *unixShellManager) rcTargets(home string) []shellRC {
+	shell := filepath.Base(os.Getenv("SHELL"))
+	if os.Getenv("SHELL") == "" {
+		shell = "bash"
+	}
+	exists := func(p string) bool {
+		_, err := os.Stat(p)
+		return err == nil
+	}
 
-	// Update bash/zsh
-	if strings.Contains(shell, "bash") || strings.Contains(shell, "zsh") || shell == "" {
-		bashrcPath := filepath.Join(home, ".bashrc")
-		if err := updateBashConfig(bashrcPath, key, value); err != nil {
-			return fmt.Errorf("failed to update .bashrc: %w", err)
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	zdot := os.Getenv("ZDOTDIR")
+	if zdot == "" {
+		zdot = home
+	}
+
+	var targets []shellRC
+	bashrc := filepath.Join(home, ".bashrc")
+	if shell == "bash" || exists(bashrc) {
+		targets = append(targets, shellRC{bashrc, shellPosix})
+	}
+	// macOS 终端默认启动 login shell，只读取 .bash_profile
+	bashProfile := filepath.Join(home, ".bash_profile")
+	if runtime.GOOS == "darwin" && (shell == "bash" || exists(bashProfile)) {
+		targets = append(targets, shellRC{bashProfile, shellPosix})
+	}
+	zshrc := filepath.Join(zdot, ".zshrc")
+	if shell == "zsh" || exists(zshrc) {
+		targets = append(targets, shellRC{zshrc, shellPosix})
+	}
+	kshrc := filepath.Join(home, ".kshrc")
+	if shell == "ksh" || shell == "ksh93" || exists(kshrc) {
+		targets = append(targets, shellRC{kshrc, shellPosix})
+	}
+	// mksh 交互式启动时读取 ~/.mkshrc，而不是 ~/.kshrc
+	mkshrc := filepath.Join(home, ".mkshrc")
+	if shell == "mksh" || exists(mkshrc) {
+		targets = append(targets, shellRC{mkshrc, shellPosix})
+	}
+	fishDir := filepath.Join(configHome, "fish")
+	if shell == "fish" || exists(fishDir) {
+		targets = append(targets, shellRC{filepath.Join(fishDir, "config.fish"), shellFish})
+	}
+	nuDir := filepath.Join(configHome, "nushell")
+	if runtime.GOOS == "darwin" {
+		nuDir = filepath.Join(home, "Library", "Application Support", "nushell")
+	}
+	if shell == "nu" || exists(nuDir) {
+		targets = append(targets, shellRC{filepath.Join(nuDir, "config.nu"), shellNu})
+	}
+	return targets
+}
+
+// allRCFiles 返回所有可能被 switcher 写入过的 rc 文件，用于卸载和清理旧格式
+func (m *unixShellManager) allRCFiles(home string) []shellRC {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	zdot := os.Getenv("ZDOTDIR")
+	if zdot == "" {
+		zdot = home
+	}
+	return []shellRC{
+		{filepath.Join(home, ".bashrc"), shellPosix},
+		{filepath.Join(home, ".bash_profile"), shellPosix},
+		{filepath.Join(zdot, ".zshrc"), shellPosix},
+		{filepath.Join(home, ".kshrc"), shellPosix},
+		{filepath.Join(home, ".mkshrc"), shellPosix},
+		{filepath.Join(configHome, "fish", "config.fish"), shellFish},
+		{filepath.Join(configHome, "nushell", "config.nu"), shellNu},
+		{filepath.Join(home, "Library", "Application Support", "nushell", "config.nu"), shellNu},
+	}
+}
+
+// sourceLine 返回 rc 文件中用于加载 env 文件的语句
+func (m *unixShellManager) sourceLine(kind shellKind) string {
+	path := m.envFile(kind)
+	switch kind {
+	case shellFish:
+		return fmt.Sprintf("test -f %s; and source %s", quoteFish(path), quoteFish(path))
+	case shellNu:
+		// nushell 的 source 在解析期读取文件，env.nu 总是存在
+		return fmt.Sprintf("source %s", quoteNu(path))
+	default:
+		return fmt.Sprintf("[ -f %s ] && . %s", quotePosix(path), quotePosix(path))
+	}
+}
+
+// loadVars 从 env.sh 读取 switcher 当前管理的变量
+func (m *unixShellManager) loadVars() (map[string]string, error) {
+	vars := map[string]string{}
+	data, err := os.ReadFile(m.envFile(shellPosix))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return vars, nil
+		}
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		rest, ok := strings.CutPrefix(line, "export ")
+		if !ok {
+			continue
 		}
+		key, quoted, ok := strings.Cut(rest, "=")
+		if !ok || !isValidEnvKey(key) || len(quoted) < 2 || quoted[0] != '\'' || quoted[len(quoted)-1] != '\'' {
+			continue
+		}
+		vars[key] = strings.ReplaceAll(quoted[1:len(quoted)-1], `'\''`, "'")
+	}
+	return vars, nil
+}
+
+// writeVars 重写 env.sh / env.fish / env.nu
+func (m *unixShellManager) writeVars(vars map[string]string) error {
+	if err := mkdirWithPerms(switcherEnvDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", switcherEnvDir(), err)
+	}
+
+	var sh, fish, nu strings.Builder
+	sh.WriteString("# " + envFileHeader + "\n")
+	fish.WriteString("# " + envFileHeader + "\n")
+	nu.WriteString("# " + envFileHeader + "\n")
+	for _, k := range sortedEnvKeys(vars) {
+		sh.WriteString(fmt.Sprintf("export %s=%s\n", k, quotePosix(vars[k])))
+		fish.WriteString(fmt.Sprintf("set -gx %s %s\n", k, quoteFish(vars[k])))
+		nu.WriteString(fmt.Sprintf("$env.%s = %s\n", k, quoteNu(vars[k])))
 	}
 
-	// Update fish if exists
-	fishConfigDir := filepath.Join(home, ".config", "fish")
-	if _, err := os.Stat(fishConfigDir); err == nil {
-		fishConfigPath := filepath.Join(fishConfigDir, "config.fish")
-		if err := updateFishConfig(fishConfigPath, key, value); err != nil {
-			return fmt.Errorf("failed to update fish config: %w", err)
+	// env 文件中包含密钥，仅允许当前用户读取
+	for kind, content := range map[shellKind]string{shellPosix: sh.String(), shellFish: fish.String(), shellNu: nu.String()} {
+		if err := writeFileWithPerms(m.envFile(kind), []byte(content), 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", m.envFile(kind), err)
 		}
 	}
+	return nil
+}
 
+// install 确保每个目标 rc 文件中恰好有一个 switcher 标记块，并清理旧版本逐行写入的 export 语句
+func (m *unixShellManager) install(keys []string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	for _, rc := range m.rcTargets(home) {
+		content, err := os.ReadFile(rc.path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read %s: %w", rc.path, err)
+		}
+		original := string(content)
+		updated := removeLegacyEnvLines(original, rc.kind, keys)
+		updated = upsertShellBlock(updated, m.sourceLine(rc.kind))
+		if updated == original {
+			continue
+		}
+		if err := mkdirWithPerms(filepath.Dir(rc.path), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(rc.path), err)
+		}
+		if err := writeFileWithPerms(rc.path, []byte(updated), 0644); err != nil {
+			return fmt.Errorf("failed to update %s: %w", rc.path, err)
+		}
+	}
 	return nil
 }
 
-func updateBashConfig(configPath, key, value string) error {
-	content, err := os.ReadFile(configPath)
-	if err != nil && !os.IsNotExist(err) {
+func (m *unixShellManager) SetEnvVar(key, value string) error {
+	if err := validateEnvVar(key, value); err != nil {
 		return err
 	}
+	vars, err := m.loadVars()
+	if err != nil {
+		return fmt.Errorf("failed to read switcher env file: %w", err)
+	}
+	vars[key] = value
+	if err := m.writeVars(vars); err != nil {
+		return err
+	}
+	return m.install([]string{key})
+}
+
+func (m *unixShellManager) UnsetEnvVar(key string) error {
+	vars, err := m.loadVars()
+	if err != nil {
+		return fmt.Errorf("failed to read switcher env file: %w", err)
+	}
+	if _, ok := vars[key]; !ok {
+		return nil
+	}
+	delete(vars, key)
+	return m.writeVars(vars)
+}
 
-	lines := strings.Split(string(content), "\n")
-	keyPattern := fmt.Sprintf("export %s=", key)
+func (m *unixShellManager) GetEnvVar(key string) (string, bool) {
+	vars, err := m.loadVars()
+	if err != nil {
+		return "", false
+	}
+	v, ok := vars[key]
+	return v, ok
+}
 
-	var newLines []string
-	for _, line := range lines {
-		if !strings.Contains(line, keyPattern) {
-			newLines = append(newLines, line)
+func (m *unixShellManager) Uninstall(legacyKeys []string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+	vars, err := m.loadVars()
+	if err != nil {
+		return fmt.Errorf("failed to read switcher env file: %w", err)
+	}
+
+	for _, rc := range m.allRCFiles(home) {
+		content, err := os.ReadFile(rc.path)
+		if err != nil {
+			continue
+		}
+		updated := removeShellBlock(string(content))
+		updated = removeLegacyEnvLines(updated, rc.kind, append(sortedEnvKeys(vars), legacyKeys...))
+		if updated == string(content) {
+			continue
+		}
+		if err := writeFileWithPerms(rc.path, []byte(updated), 0644); err != nil {
+			return fmt.Errorf("failed to update %s: %w", rc.path, err)
 		}
 	}
 
-	newLines = append(newLines, fmt.Sprintf("export %s=\"%s\"", key, value))
-	return writeFileWithPerms(configPath, []byte(strings.Join(newLines, "\n")), 0644)
+	for _, kind := range []shellKind{shellPosix, shellFish, shellNu} {
+		if err := os.Remove(m.envFile(kind)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", m.envFile(kind), err)
+		}
+	}
+	return nil
 }
 
-func updateFishConfig(configPath, key, value string) error {
-	content, err := os.ReadFile(configPath)
-	if err != nil && !os.IsNotExist(err) {
-		return err
+func (m *unixShellManager) ActivationHint() string {
+	shell := filepath.Base(os.Getenv("SHELL"))
+	switch shell {
+	case "fish":
+		return fmt.Sprintf("source %s", quoteFish(m.envFile(shellFish)))
+	case "nu":
+		return fmt.Sprintf("source %s", quoteNu(m.envFile(shellNu)))
+	default:
+		return fmt.Sprintf(". %s", quotePosix(m.envFile(shellPosix)))
 	}
+}
 
-	lines := strings.Split(string(content), "\n")
-	keyPattern := fmt.Sprintf("set -x %s ", key)
+// upsertShellBlock 在内容中插入或替换 switcher 标记块
+func upsertShellBlock(content, body string) string {
+	block := shellBlockBegin + "\n" + body + "\n" + shellBlockEnd
+	lines := strings.Split(content, "\n")
+	start, end := -1, -1
+	for i, line := range lines {
+		switch strings.TrimSpace(line) {
+		case shellBlockBegin:
+			if start == -1 {
+				start = i
+			}
+		case shellBlockEnd:
+			if start != -1 && end == -1 {
+				end = i
+			}
+		}
+	}
+	if start != -1 && end != -1 {
+		result := append([]string{}, lines[:start]...)
+		result = append(result, block)
+		result = append(result, lines[end+1:]...)
+		return strings.Join(result, "\n")
+	}
 
-	var newLines []string
-	for _, line := range lines {
-		if !strings.Contains(line, keyPattern) {
-			newLines = append(newLines, line)
+	trimmed := strings.TrimRight(content, "\n")
+	if trimmed == "" {
+		return block + "\n"
+	}
+	return trimmed + "\n\n" + block + "\n"
+}
+
+// removeShellBlock 移除 switcher 标记块（包括起止行）；缺少结束标记的不完整块保持原样
+func removeShellBlock(content string) string {
+	lines := strings.Split(content, "\n")
+	result := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == shellBlockBegin {
+			end := -1
+			for j := i + 1; j < len(lines); j++ {
+				if strings.TrimSpace(lines[j]) == shellBlockEnd {
+					end = j
+					break
+				}
+			}
+			if end != -1 {
+				i = end
+				continue
+			}
 		}
+		result = append(result, lines[i])
+	}
+	out := strings.Join(result, "\n")
+	for strings.Contains(out, "\n\n\n") {
+		out = strings.ReplaceAll(out, "\n\n\n", "\n\n")
 	}
+	return out
+}
 
-	newLines = append(newLines, fmt.Sprintf("set -x %s \"%s\"", key, value))
-	return writeFileWithPerms(configPath, []byte(strings.Join(newLines, "\n")), 0644)
+// removeLegacyEnvLines 删除旧版本 switcher 直接写入 rc 文件的变量语句。
+// 只匹配以 `export KEY="` / `set -x KEY "` 开头的整行，不会误删仅包含该文本的其他行。
+func removeLegacyEnvLines(content string, kind shellKind, keys []string) string {
+	if kind == shellNu || len(keys) == 0 {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		legacy := false
+		for _, key := range keys {
+			prefix := fmt.Sprintf(`export %s="`, key)
+			if kind == shellFish {
+				prefix = fmt.Sprintf(`set -x %s "`, key)
+			}
+			if strings.HasPrefix(line, prefix) && strings.HasSuffix(line, `"`) {
+				legacy = true
+				break
+			}
+		}
+		if !legacy {
+			result = append(result, line)
+		}
+	}
+	return strings.Join(result, "\n")
 }
 
 // Windows shell manager (PowerShell)
+//
+// 变量写入用户级环境变量，同时记录在 switcher 自有的 env.ps1 中，
+// 既可以 `. env.ps1` 在当前会话生效，也用于卸载时找回需要删除的变量。
 type windowsShellManager struct{}
 
-func (m *windowsShellManager) SetEnvVar(key, value string) error {
-	// Set user environment variable permanently
-	cmd := exec.Command("powershell", "-Command",
-		fmt.Sprintf("[Environment]::SetEnvironmentVariable('%s', '%s', 'User')", key, value))
+func (m *windowsShellManager) envFile() string {
+	return filepath.Join(switcherEnvDir(), "env.ps1")
+}
+
+func (m *windowsShellManager) loadVars() (map[string]string, error) {
+	vars := map[string]string{}
+	data, err := os.ReadFile(m.envFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return vars, nil
+		}
+		return nil, err
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		rest, ok := strings.CutPrefix(line, "$env:")
+		if !ok {
+			continue
+		}
+		key, quoted, ok := strings.Cut(rest, " = ")
+		if !ok || !isValidEnvKey(key) || len(quoted) < 2 || quoted[0] != '\'' || quoted[len(quoted)-1] != '\'' {
+			continue
+		}
+		vars[key] = strings.ReplaceAll(quoted[1:len(quoted)-1], "''", "'")
+	}
+	return vars, nil
+}
 
+func (m *windowsShellManager) writeVars(vars map[string]string) error {
+	if err := mkdirWithPerms(switcherEnvDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", switcherEnvDir(), err)
+	}
+	var b strings.Builder
+	b.WriteString("# " + envFileHeader + "\r\n")
+	for _, k := range sortedEnvKeys(vars) {
+		b.WriteString(fmt.Sprintf("$env:%s = %s\r\n", k, quotePowerShell(vars[k])))
+	}
+	return writeFileWithPerms(m.envFile(), []byte(b.String()), 0600)
+}
+
+func (m *windowsShellManager) setUserEnv(key, psValue string) error {
+	cmd := exec.Command("powershell", "-NoProfile", "-Command",
+		fmt.Sprintf("[Environment]::SetEnvironmentVariable(%s, %s, 'User')", quotePowerShell(key), psValue))
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to set environment variable: %w", err)
 	}
+	return nil
+}
+
+func (m *windowsShellManager) SetEnvVar(key, value string) error {
+	if err := validateEnvVar(key, value); err != nil {
+		return err
+	}
+	// Set user environment variable permanently
+	if err := m.setUserEnv(key, quotePowerShell(value)); err != nil {
+		return err
+	}
+	vars, err := m.loadVars()
+	if err != nil {
+		return fmt.Errorf("failed to read switcher env file: %w", err)
+	}
+	vars[key] = value
+	return m.writeVars(vars)
+}
+
+func (m *windowsShellManager) UnsetEnvVar(key string) error {
+	vars, err := m.loadVars()
+	if err != nil {
+		return fmt.Errorf("failed to read switcher env file: %w", err)
+	}
+	if _, ok := vars[key]; !ok {
+		return nil
+	}
+	if err := m.setUserEnv(key, "$null"); err != nil {
+		return err
+	}
+	delete(vars, key)
+	return m.writeVars(vars)
+}
+
+func (m *windowsShellManager) GetEnvVar(key string) (string, bool) {
+	vars, err := m.loadVars()
+	if err != nil {
+		return "", false
+	}
+	v, ok := vars[key]
+	return v, ok
+}
 
+func (m *windowsShellManager) Uninstall(legacyKeys []string) error {
+	vars, err := m.loadVars()
+	if err != nil {
+		return fmt.Errorf("failed to read switcher env file: %w", err)
+	}
+	for _, k := range legacyKeys {
+		if _, ok := vars[k]; !ok && isValidEnvKey(k) {
+			vars[k] = ""
+		}
+	}
+	for _, k := range sortedEnvKeys(vars) {
+		if err := m.setUserEnv(k, "$null"); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(m.envFile()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", m.envFile(), err)
+	}
 	return nil
 }
+
+func (m *windowsShellManager) ActivationHint() string {
+	return fmt.Sprintf(". %s", quotePowerShell(m.envFile()))
+}
+
+// ShellActivationHint 返回在当前 shell 中立即加载 switcher 环境变量的命令
+func ShellActivationHint() string {
+	return shellManager.ActivationHint()
+}
+
+// UninstallShellIntegration 移除 switcher 写入的 shell 集成，包括旧版本直接写入 rc 文件的
+// Codex 变量，并清空记录的 Codex 环境变量，之后的切换不会再去移除它们
+func (c *Config) UninstallShellIntegration() error {
+	legacyKeys := append([]string{DefaultEnvKey}, c.Managed.CodexEnvVars...)
+	for _, sc := range c.Codex {
+		if sc.EnvKey != "" {
+			legacyKeys = append(legacyKeys, sc.EnvKey)
+		}
+	}
+	if err := shellManager.Uninstall(legacyKeys); err != nil {
+		return err
+	}
+	c.Managed.CodexEnvVars = nil
+	return c.Save()
+}
@@ -0,0 +1,158 @@
+package tui
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestQuotePosixRoundTripsThroughShell(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	value := `a'b"c$HOME` + "`d`" + `\e`
+	out, err := exec.Command(sh, "-c", "printf %s "+quotePosix(value)).Output()
+	if err != nil {
+		t.Fatalf("sh failed: %v", err)
+	}
+	if string(out) != value {
+		t.Fatalf("shell printed %q, want %q", out, value)
+	}
+}
+
+func TestUnixShellManagerWritesSingleManagedBlock(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix shell integration")
+	}
+	useTempPlatformPaths(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SHELL", "/bin/zsh")
+	t.Setenv("ZDOTDIR", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	// 旧版本直接写入 .bashrc 的 export 行应被清理；只是包含该文本的行不能被误删
+	bashrc := filepath.Join(home, ".bashrc")
+	legacy := "alias x='echo export CODEX_KEY=1'\nexport CODEX_KEY=\"old\"\n"
+	if err := os.WriteFile(bashrc, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &unixShellManager{}
+	if err := m.SetEnvVar("CODEX_KEY", "first"); err != nil {
+		t.Fatalf("SetEnvVar: %v", err)
+	}
+	if err := m.SetEnvVar("CODEX_KEY", "it's $secret"); err != nil {
+		t.Fatalf("SetEnvVar: %v", err)
+	}
+	if err := m.SetEnvVar("BAD-KEY", "x"); err == nil {
+		t.Fatalf("invalid key should be rejected")
+	}
+
+	zshrc, err := os.ReadFile(filepath.Join(home, ".zshrc"))
+	if err != nil {
+		t.Fatalf(".zshrc not created for zsh users: %v", err)
+	}
+	if n := strings.Count(string(zshrc), shellBlockBegin); n != 1 {
+		t.Fatalf(".zshrc has %d switcher blocks, want 1:\n%s", n, zshrc)
+	}
+	bash, _ := os.ReadFile(bashrc)
+	if strings.Contains(string(bash), `export CODEX_KEY="old"`) {
+		t.Fatalf("legacy export line was not removed:\n%s", bash)
+	}
+	if !strings.Contains(string(bash), "alias x=") {
+		t.Fatalf("unrelated line containing the pattern was removed:\n%s", bash)
+	}
+
+	if got, ok := m.GetEnvVar("CODEX_KEY"); !ok || got != "it's $secret" {
+		t.Fatalf("GetEnvVar = %q, %v", got, ok)
+	}
+
+	if err := m.Uninstall(nil); err != nil {
+		t.Fatalf("Uninstall: %v", err)
+	}
+	zshrc, _ = os.ReadFile(filepath.Join(home, ".zshrc"))
+	if strings.Contains(string(zshrc), shellBlockBegin) {
+		t.Fatalf("block still present after uninstall:\n%s", zshrc)
+	}
+	if _, err := os.Stat(m.envFile(shellPosix)); !os.IsNotExist(err) {
+		t.Fatalf("env.sh should be removed, stat err = %v", err)
+	}
+}
+
+func TestUnixShellManagerWritesMkshrcForMksh(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix shell integration")
+	}
+	useTempPlatformPaths(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SHELL", "/bin/mksh")
+	t.Setenv("ZDOTDIR", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	m := &unixShellManager{}
+	if err := m.SetEnvVar("CODEX_KEY", "k"); err != nil {
+		t.Fatalf("SetEnvVar: %v", err)
+	}
+	mkshrc, err := os.ReadFile(filepath.Join(home, ".mkshrc"))
+	if err != nil {
+		t.Fatalf(".mkshrc not created for mksh users: %v", err)
+	}
+	if !strings.Contains(string(mkshrc), shellBlockBegin) {
+		t.Fatalf(".mkshrc has no switcher block:\n%s", mkshrc)
+	}
+
+	if err := m.Uninstall(nil); err != nil {
+		t.Fatalf("Uninstall: %v", err)
+	}
+	mkshrc, _ = os.ReadFile(filepath.Join(home, ".mkshrc"))
+	if strings.Contains(string(mkshrc), shellBlockBegin) {
+		t.Fatalf("block still present in .mkshrc after uninstall:\n%s", mkshrc)
+	}
+}
+
+func TestUninstallShellIntegrationClearsLegacyLinesAndManagedVars(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix shell integration")
+	}
+	useTempPlatformPaths(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SHELL", "/bin/bash")
+	t.Setenv("ZDOTDIR", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	old := shellManager
+	shellManager = &unixShellManager{}
+	t.Cleanup(func() { shellManager = old })
+
+	// 旧版本写入的变量不在 env 文件中：配置的 env key 和默认的 CODEX_KEY 都应被清理
+	bashrc := filepath.Join(home, ".bashrc")
+	legacy := "export PATH=\"$HOME/bin:$PATH\"\nexport CODEX_KEY=\"old\"\nexport RELAY_KEY=\"old\"\n"
+	if err := os.WriteFile(bashrc, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{
+		Active:  ActiveConfig{ClaudeCode: -1, Codex: -1, Droid: -1},
+		Codex:   []ServiceConfig{{Name: "relay", AuthMethod: "env", EnvKey: "RELAY_KEY"}},
+		Managed: ManagedState{CodexEnvVars: []string{"OPENAI_BASE_URL"}},
+	}
+	if err := c.UninstallShellIntegration(); err != nil {
+		t.Fatalf("UninstallShellIntegration: %v", err)
+	}
+	data, err := os.ReadFile(bashrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != "export PATH=\"$HOME/bin:$PATH\"\n" {
+		t.Fatalf("legacy export lines were not removed:\n%s", got)
+	}
+	if len(c.Managed.CodexEnvVars) != 0 {
+		t.Fatalf("Managed.CodexEnvVars should be cleared, got %v", c.Managed.CodexEnvVars)
+	}
+}
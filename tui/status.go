@@ -0,0 +1,21 @@
+package tui
+
+// ToolStatus 描述一个工具的活动配置是否与磁盘上实际生效的设置一致，供命令行 status 使用
+type ToolStatus struct {
+	Tool    string // 工具名（ToolAdapter.ID）
+	Active  string // 活动配置名，没有活动配置时为空
+	Applied bool   // 实际设置与活动配置一致
+	Actual  string // 不一致时实际生效的端点（或模型）
+	Err     error  // 读取或解析配置文件失败
+}
+
+// Status 按注册顺序检查每个工具的活动配置是否已生效（Claude Code 检查用户作用域）
+func (c *Config) Status() []ToolStatus {
+	var statuses []ToolStatus
+	for _, a := range toolAdapters {
+		st := ToolStatus{Tool: a.ID(), Active: ActiveToolName(c, a)}
+		st.Applied, st.Actual, st.Err = a.Verify(c)
+		statuses = append(statuses, st)
+	}
+	return statuses
+}
@@ -77,12 +77,25 @@ func menuItemView(text string, selected bool) string {
 	return menuItemStyle.Render("  " + text)
 }
 
-func listRowView(cfg ServiceConfig, selected, active bool, compact bool) string {
+// extra 为展开视图中追加在基础信息之后的行
+func listRowView(cfg ServiceConfig, selected, active bool, compact bool, extra ...string) string {
 	name := cfg.Name
 	if active {
 		name = name + " " + activeStyle.Render(t("display_active"))
 	}
-	badge := providerBadge(cfg.Provider)
+	provider := cfg.Provider
+	baseURL := cfg.BaseURL
+	if cfg.UsesBuiltinOpenAI() {
+		provider = CodexProviderModeOpenAI
+	}
+	// Bedrock/Vertex 配置显示类型，没有自定义端点时显示区域
+	if usesClaudeCloud(&cfg) {
+		provider = claudeProviderType(&cfg)
+		if baseURL == "" {
+			baseURL = claudeEndpointOf(claudeProviderEnv(&cfg))
+		}
+	}
+	badge := providerBadge(provider)
 	var text string
 	if compact {
 		text = fmt.Sprintf("%s  · %s", name, badge)
@@ -90,8 +103,16 @@ func listRowView(cfg ServiceConfig, selected, active bool, compact bool) string
 		lines := []string{
 			fmt.Sprintf(t("display_name"), name),
 			fmt.Sprintf(t("display_provider"), badge),
-			fmt.Sprintf(t("display_base_url"), cfg.BaseURL),
+			fmt.Sprintf(t("display_base_url"), baseURL),
+		}
+		if len(cfg.MCPServers) > 0 {
+			names := make([]string, len(cfg.MCPServers))
+			for i, s := range cfg.MCPServers {
+				names[i] = s.Name
+			}
+			lines = append(lines, fmt.Sprintf(t("display_mcp_servers"), strings.Join(names, ", ")))
 		}
+		lines = append(lines, extra...)
 		text = strings.Join(lines, "\n")
 	}
 	if selected {
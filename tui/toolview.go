@@ -0,0 +1,416 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// openTool 从主菜单进入工具的配置列表：内置工具使用各自的专用列表，其余使用通用列表
+func (m *model) openTool(a ToolAdapter) {
+	m.cursor = 0
+	m.error = ""
+	if b, ok := a.(builtinTool); ok {
+		m.state = b.listState()
+		switch m.state {
+		case claudeCodeList:
+			m.sortClaudeCodeConfigs() // 进入时排序一次
+		case codexList:
+			m.sortCodexConfigs()
+		case droidList:
+			m.sortDroidConfigs()
+		}
+		return
+	}
+	m.tool = a
+	m.state = toolList
+	m.sortToolConfigs()
+}
+
+// toolNames 返回当前通用工具的全部配置名
+func (m model) toolNames() []string {
+	if m.tool == nil {
+		return nil
+	}
+	return m.tool.Names(m.config)
+}
+
+// sortToolConfigs 计算通用列表的显示顺序：活动配置在前，其余按名称排序
+func (m *model) sortToolConfigs() {
+	m.toolOrder = activeFirstOrder(m.toolNames(), m.tool.ActiveIndex(m.config))
+}
+
+// activeFirstOrder 返回列表中各行对应的配置索引：活动配置在前，其余按名称排序
+func activeFirstOrder(names []string, active int) []int {
+	order := make([]int, 0, len(names))
+	for i := range names {
+		order = append(order, i)
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		if (a == active) != (b == active) {
+			if a == active {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(names[a], names[b])
+	})
+	return order
+}
+
+// activeFirst 按 activeFirstOrder 的顺序返回 items 的副本，供内置工具的专用列表使用
+func activeFirst[T any](items []T, active int, name func(T) string) []T {
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = name(item)
+	}
+	sorted := make([]T, 0, len(items))
+	for _, i := range activeFirstOrder(names, active) {
+		sorted = append(sorted, items[i])
+	}
+	return sorted
+}
+
+// openToolForm 编辑 index 处的配置；index 为 -1 时新增配置，选择字段使用第一个选项
+func (m *model) openToolForm(index int) {
+	fields := m.tool.Fields()
+	m.toolEditIndex = index
+	m.toolForm = make([]string, len(fields)+1)
+	if st := m.config.Tools[m.tool.ID()]; index >= 0 && st != nil && index < len(st.Configs) {
+		cfg := st.Configs[index]
+		m.toolForm[0] = cfg.Name
+		for i, f := range fields {
+			m.toolForm[i+1] = cfg.Values[f.Key]
+		}
+	} else {
+		for i, f := range fields {
+			if len(f.Options) > 0 {
+				m.toolForm[i+1] = f.Options[0]
+			}
+		}
+	}
+	m.formField = 0
+	m.state = editTool
+	m.error = ""
+}
+
+// toolFormFields 把 adapter 的字段描述转换为表单字段，第一个字段固定为配置名
+func (m *model) toolFormFields() []formField {
+	fields := []formField{{label: t("field_name"), kind: fieldText, value: &m.toolForm[0]}}
+	for i, f := range m.tool.Fields() {
+		field := formField{label: t(f.Label), kind: fieldText, value: &m.toolForm[i+1], options: f.Options}
+		switch {
+		case len(f.Options) > 0:
+			field.kind = fieldChoice
+		case f.Secret:
+			field.kind = fieldSecret
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// saveToolForm 校验并保存通用表单（新增或更新），成功后回到列表
+func (m *model) saveToolForm() {
+	cfg := ToolConfig{Name: strings.TrimSpace(m.toolForm[0]), Values: map[string]string{}}
+	for i, f := range m.tool.Fields() {
+		if v := strings.TrimSpace(m.toolForm[i+1]); v != "" {
+			cfg.Values[f.Key] = v
+		}
+	}
+	var err error
+	success := fmt.Sprintf(t("success_add_tool"), m.tool.DisplayName())
+	if m.toolEditIndex >= 0 {
+		err = m.config.UpdateToolConfig(m.tool, m.toolEditIndex, cfg)
+		success = fmt.Sprintf(t("success_update_tool"), m.tool.DisplayName())
+	} else {
+		err = m.config.AddToolConfig(m.tool, cfg)
+	}
+	if err != nil {
+		m.error = err.Error()
+		return
+	}
+	m.error = success
+	m.state = toolList
+	m.cursor = 0
+	m.sortToolConfigs()
+}
+
+// applyTool 切换到通用列表中 cursor 处的配置
+func (m *model) applyTool() {
+	index := m.toolOrder[m.cursor]
+	if err := m.tool.Apply(m.config, index); err != nil {
+		m.error = fmt.Sprintf(t("error_switch_tool"), m.tool.DisplayName(), err)
+		return
+	}
+	m.error = fmt.Sprintf(t("success_switch_tool"), m.tool.DisplayName())
+	if n, ok := m.tool.(ToolNotifier); ok {
+		if notice := n.Notice(m.config, index); notice != "" {
+			m.error += "\n" + notice
+		}
+	}
+	m.sortToolConfigs()
+	m.cursor = 0
+}
+
+// importTool 导入工具当前的设置，并在状态栏报告新增和已存在的数量
+func (m *model) importTool() {
+	results, err := m.tool.Import(m.config)
+	switch {
+	case errors.Is(err, ErrImportUnsupported):
+		m.error = fmt.Sprintf(t("error_import_unsupported"), m.tool.DisplayName())
+		return
+	case err != nil:
+		m.error = err.Error()
+		return
+	case len(results) == 0:
+		m.error = fmt.Sprintf(t("error_import_tool_none"), m.tool.DisplayName())
+		return
+	}
+	added := 0
+	for _, r := range results {
+		if !r.Existing {
+			added++
+		}
+	}
+	m.error = fmt.Sprintf(t("success_import_tool"), added, len(results)-added)
+	m.sortToolConfigs()
+	m.cursor = 0
+}
+
+func (m model) handleToolListKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	count := len(m.toolOrder)
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = mainMenu
+		m.cursor = 0
+		m.error = ""
+	case tea.KeyUp:
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case tea.KeyDown:
+		if m.cursor < count+1 {
+			m.cursor++
+		}
+	case tea.KeyLeft, tea.KeyRight:
+		// 在“返回”和“新增”按钮之间切换
+		if m.cursor == count {
+			m.cursor = count + 1
+		} else if m.cursor == count+1 {
+			m.cursor = count
+		}
+	case tea.KeyEnter:
+		switch {
+		case m.cursor == count:
+			m.state = mainMenu
+			m.cursor = 0
+		case m.cursor == count+1:
+			m.openToolForm(-1)
+		default:
+			m.applyTool()
+		}
+	case tea.KeyTab:
+		if m.cursor < count {
+			m.openToolForm(m.toolOrder[m.cursor])
+		}
+	case tea.KeyDelete:
+		if m.cursor < count {
+			m.deleteIndex = m.toolOrder[m.cursor]
+			m.state = confirmDeleteTool
+			m.cursor = 1 // 默认选择"否"
+		}
+	case tea.KeyRunes:
+		switch msg.Runes[0] {
+		case 'k', 'K':
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case 'j', 'J':
+			if m.cursor < count+1 {
+				m.cursor++
+			}
+		case 'a', 'A':
+			m.openToolForm(-1)
+		case 'i', 'I':
+			m.importTool()
+		case 'v', 'V':
+			m.compact = !m.compact
+		}
+	}
+	return m, nil
+}
+
+func (m model) handleToolFormKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	fields := m.toolFormFields()
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = toolList
+		m.cursor = 0
+		m.error = ""
+	case tea.KeyUp:
+		m.formField = (m.formField - 1 + len(fields)) % len(fields)
+	case tea.KeyDown, tea.KeyTab:
+		m.formField = (m.formField + 1) % len(fields)
+	case tea.KeyLeft:
+		fields[m.formField].cycle(-1)
+	case tea.KeyRight:
+		fields[m.formField].cycle(1)
+	case tea.KeyEnter, tea.KeyCtrlS:
+		m.saveToolForm()
+	case tea.KeyBackspace, tea.KeyCtrlH:
+		fields[m.formField].backspace()
+	case tea.KeyRunes, tea.KeySpace:
+		if s := sanitizeInput(msg.String()); s != "" {
+			fields[m.formField].input(s)
+		}
+	}
+	return m, nil
+}
+
+func (m model) handleToolDeleteKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = toolList
+		m.cursor = 0
+	case tea.KeyUp:
+		m.cursor = 0
+	case tea.KeyDown:
+		m.cursor = 1
+	case tea.KeyEnter:
+		if m.cursor == 0 {
+			name := m.toolNames()[m.deleteIndex]
+			if err := m.config.DeleteToolConfig(m.tool, m.deleteIndex); err != nil {
+				m.error = err.Error()
+			} else {
+				m.error = fmt.Sprintf(t("success_delete_tool"), m.tool.DisplayName(), name)
+			}
+		}
+		m.state = toolList
+		m.cursor = 0
+		m.sortToolConfigs()
+	}
+	return m, nil
+}
+
+func (m model) toolListView() string {
+	header := headerView(fmt.Sprintf(t("header_tool"), m.tool.DisplayName()))
+	fields := m.tool.Fields()
+
+	var rows []string
+
+	hasWarning := false
+	if ok, actual, _ := m.tool.Verify(m.config); !ok && actual != "" {
+		warn := errorStyle.Render(fmt.Sprintf(t("warn_mismatch"), m.tool.DisplayName()) + actual)
+		rows = append(rows, itemBoxStyle.Render(warn))
+		hasWarning = true
+	}
+
+	var configs []ToolConfig
+	if st := m.config.Tools[m.tool.ID()]; st != nil {
+		configs = st.Configs
+	}
+	if count := len(m.toolOrder); count > 0 {
+		viewportSize := calculateListViewportHeight(m.windowHeight, hasWarning, m.compact, max(len(fields)-2, 0))
+		start, end := updateCursorViewport(m.cursor, count, viewportSize)
+		for i := start; i < end; i++ {
+			index := m.toolOrder[i]
+			r := toolListRowView(configs[index], fields, index == m.tool.ActiveIndex(m.config), m.compact)
+			if i == m.cursor {
+				r = itemBoxSelStyle.Render(r)
+			} else {
+				r = itemBoxStyle.Render(r)
+			}
+			rows = append(rows, r)
+		}
+		if start > 0 {
+			rows = append([]string{scrollIndicatorStyle.Render("↑ More items above")}, rows...)
+		}
+		if end < count {
+			rows = append(rows, scrollIndicatorStyle.Render("↓ More items below"))
+		}
+	}
+
+	backSel := m.cursor == len(m.toolOrder)
+	addSel := m.cursor == len(m.toolOrder)+1
+	back := menuItemView(t("back_to_menu"), backSel)
+	add := menuItemView(t("menu_add_item"), addSel)
+	if backSel {
+		back = itemBoxSelStyle.Render(back)
+	} else {
+		back = itemBoxStyle.Render(back)
+	}
+	if addSel {
+		add = itemBoxSelStyle.Render(add)
+	} else {
+		add = itemBoxStyle.Render(add)
+	}
+	rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Bottom, back, add))
+
+	var content strings.Builder
+	content.WriteString(header)
+	content.WriteString("\n\n")
+	content.WriteString(lipgloss.JoinVertical(lipgloss.Left, rows...))
+	content.WriteString("\n")
+	content.WriteString(statusBarView(t("nav_select"), t("nav_confirm"), t("nav_edit"), t("nav_add")+"  "+t("nav_import")+"  "+t("nav_view")+"  "+t("nav_back")+"  "+t("nav_switch_buttons")))
+	return content.String()
+}
+
+// toolListRowView 渲染通用列表中的一行；展开视图中逐行显示已填写的字段，密钥始终遮蔽
+func toolListRowView(cfg ToolConfig, fields []ToolField, active bool, compact bool) string {
+	status := "  "
+	if active {
+		status = activeStyle.Render("✓ ")
+	}
+	if compact {
+		return status + cfg.Name
+	}
+	lines := []string{status + cfg.Name}
+	for _, f := range fields {
+		v := cfg.Values[f.Key]
+		if v == "" {
+			continue
+		}
+		if f.Secret {
+			v = maskAPIKey(v)
+		}
+		lines = append(lines, fmt.Sprintf("    %s: %s", t(f.Label), v))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m model) toolFormView() string {
+	header := fmt.Sprintf(t("form_add"), m.tool.DisplayName())
+	if m.toolEditIndex >= 0 {
+		header = fmt.Sprintf(t("form_edit"), m.tool.DisplayName())
+	}
+	title := headerView(header)
+
+	fields := m.toolFormFields()
+	var inner strings.Builder
+	for i, field := range fields {
+		prefix := "  "
+		highlight := ""
+		if m.formField == i {
+			prefix = cursorStyle.Render(">")
+			if field.kind == fieldChoice {
+				highlight = fieldHighlightStyle.Render(" " + t("hint_use_arrows"))
+			} else {
+				highlight = fieldHighlightStyle.Render(" " + t("hint_input"))
+			}
+		}
+		inner.WriteString(formRowStyle.Render(fmt.Sprintf("%s %s:%s %s", prefix, field.label, highlight, field.display(m.formField == i))) + "\n")
+	}
+
+	var content strings.Builder
+	content.WriteString(title)
+	content.WriteString("\n\n")
+	content.WriteString(boxStyle.Render(inner.String()))
+	content.WriteString("\n")
+	content.WriteString(statusBarView(t("form_nav_field"), t("form_nav_save"), t("form_nav_cancel"), ""))
+	return content.String()
+}
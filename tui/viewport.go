@@ -22,7 +22,7 @@ var scrollIndicatorStyle = lipgloss.NewStyle().
 // calculateListViewportHeight 计算列表视口的可用高度
 // 注意：我们不预留滚动指示器的空间，因为滚动指示器和配置项共享显示区域
 // 当需要显示滚动指示器时，会减少显示的配置项数量
-func calculateListViewportHeight(windowHeight int, hasWarning bool, compact bool) int {
+func calculateListViewportHeight(windowHeight int, hasWarning bool, compact bool, extraLines int) int {
 	if windowHeight <= 0 {
 		// 默认高度10，但需要通过实际渲染验证是否适合当前窗口
 		return 10
@@ -48,7 +48,8 @@ func calculateListViewportHeight(windowHeight int, hasWarning bool, compact bool
 		// - 下边框：1行
 		// - MarginBottom：0行（在 itemBoxStyle 中设置）
 		// 总计：5行
-		itemHeight = 5
+		// extraLines 为列表在展开视图中额外显示的行数（如 Claude Code 的隐私开关）
+		itemHeight = 5 + extraLines
 	}
 
 	// 计算可用高度